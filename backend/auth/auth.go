@@ -1,67 +1,191 @@
 package auth
 
 import (
-	"database/sql"
-	"fmt"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net"
 	"net/http"
+	"strings"
 	"time"
 
-	"github.com/gofrs/uuid"
+	"forum/backend/config"
+	"forum/backend/controllers/structs"
+	"forum/backend/store"
 )
 
-func CreateSessionToken() (uuid.UUID, error) {
-	sessionToken, err := uuid.NewV4()
-	if err != nil {
-		return sessionToken, err
+const SessionCookieName = "session_token"
+
+// touchInterval limits how often a session's last-seen time is written, so
+// a page that makes several API calls costs one UPDATE at most.
+const touchInterval = time.Minute
+
+func CreateSessionToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
 	}
-	return sessionToken, nil
+	return base64.RawURLEncoding.EncodeToString(b), nil
 }
 
-func SetCookie(w http.ResponseWriter, sessionToken uuid.UUID) {
-	cookie := &http.Cookie{
-		Name:     "session_token",
-		Value:    sessionToken.String(),
-		Path:     "/",
-		Expires:  time.Now().Add(24 * time.Hour),
-		HttpOnly: true,
-	}
-	http.SetCookie(w, cookie)
+// HashToken is what the sessions table stores instead of the token itself.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
 
-func SetTokenInDatabase(w http.ResponseWriter, db *sql.DB, sessionToken uuid.UUID, userID int) error {
-	_, err := db.Exec("UPDATE USERS SET session_token = ? WHERE ID = ?", sessionToken.String(), userID)
+// StartSession logs the user in on this device: it records a new session
+// with the request's user agent and IP and sets the session cookie.
+func StartSession(w http.ResponseWriter, r *http.Request, sessions store.SessionRepo, userID int) error {
+	token, err := CreateSessionToken()
+	if err != nil {
+		return err
+	}
+
+	cfg := config.Get()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	// Logging in is a good moment to forget sessions that ran out.
+	if _, err := sessions.DeleteExpired(now); err != nil {
+		return err
+	}
+
+	_, err = sessions.Create(structs.Session{
+		UserID:     userID,
+		UserAgent:  r.UserAgent(),
+		IP:         ClientIP(r),
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(min(cfg.SessionIdleTimeout, cfg.SessionMaxAge)),
+	}, HashToken(token))
 	if err != nil {
 		return err
 	}
+
+	SetCookie(w, token, now.Add(cfg.SessionMaxAge))
 	return nil
 }
 
+// SetCookie lasts as long as the session could at most; the server decides
+// whether it is still valid. Secure and SameSite come from the config.
+func SetCookie(w http.ResponseWriter, sessionToken string, expires time.Time) {
+	cfg := config.Get()
+	cookie := &http.Cookie{
+		Name:     SessionCookieName,
+		Value:    sessionToken,
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   cfg.CookieSecure,
+		SameSite: cfg.CookieSameSite,
+	}
+	http.SetCookie(w, cookie)
+}
+
 func RemoveCookie(w http.ResponseWriter, r *http.Request, cookieName string) {
+	cfg := config.Get()
 	expiredCookie := &http.Cookie{
 		Name:     cookieName,
 		Value:    "",
 		Path:     "/", // Çerezin ayarlandığı yola dikkat edin
 		Expires:  time.Unix(0, 0),
 		HttpOnly: true,
+		Secure:   cfg.CookieSecure,
+		SameSite: cfg.CookieSameSite,
 	}
 
 	http.SetCookie(w, expiredCookie)
 }
 
-func IsAuthenticated(r *http.Request, db *sql.DB) (bool, int, string) {
-	cookie, errCookie := r.Cookie("session_token")
-	if errCookie != nil {
-		return false, 0, ""
+// ClientIP is the address the request came from. X-Forwarded-For is only
+// trusted from this machine, where the pages forward the browser's address
+// to the API; anyone else could set it to anything.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		forwarded := r.Header.Get("X-Forwarded-For")
+		if i := strings.LastIndex(forwarded, ","); i >= 0 {
+			forwarded = forwarded[i+1:]
+		}
+		if forwarded = strings.TrimSpace(forwarded); net.ParseIP(forwarded) != nil {
+			return forwarded
+		}
 	}
+	return host
+}
+
+// Forward makes the API request req on behalf of the browser request r, so
+// the API sees the browser's address and user agent.
+func Forward(req, r *http.Request) {
+	req.Header.Set("X-Forwarded-For", ClientIP(r))
+	req.Header.Set("User-Agent", r.UserAgent())
+}
 
-	var userID int
-	var userName string
+// CurrentSession returns the unexpired session the request's cookie belongs
+// to, sliding its expiry forward, along with the owner's user name.
+func CurrentSession(r *http.Request, sessions store.SessionRepo) (structs.Session, string, error) {
+	cookie, errCookie := r.Cookie(SessionCookieName)
+	if errCookie != nil || cookie.Value == "" {
+		return structs.Session{}, "", store.ErrNotFound
+	}
 
-	err := db.QueryRow("SELECT ID, UserName FROM USERS WHERE session_token = ?", cookie.Value).Scan(&userID, &userName)
+	now := time.Now().UTC().Truncate(time.Second)
+	session, userName, err := sessions.ByTokenHash(HashToken(cookie.Value), now)
 	if err != nil {
-		fmt.Println(err)
+		return structs.Session{}, "", err
+	}
+
+	if now.Sub(session.LastSeenAt) >= touchInterval {
+		cfg := config.Get()
+		expires := now.Add(cfg.SessionIdleTimeout)
+		if limit := session.CreatedAt.Add(cfg.SessionMaxAge); expires.After(limit) {
+			expires = limit
+		}
+		if err := sessions.Touch(session.ID, now, expires); err != nil {
+			return structs.Session{}, "", err
+		}
+		session.LastSeenAt, session.ExpiresAt = now, expires
+	}
+
+	session.Current = true
+	return session, userName, nil
+}
+
+// IsAuthenticated reports whether the request comes from a logged in user
+// who isn't banned or suspended.
+func IsAuthenticated(r *http.Request, sessions store.SessionRepo) (bool, int, string) {
+	session, userName, err := CurrentSession(r, sessions)
+	if err != nil {
+		if !errors.Is(err, store.ErrNotFound) {
+			log.Printf("Failed to look up session: %v", err)
+		}
 		return false, 0, ""
 	}
 
-	return true, userID, userName
+	banned, err := Banned(store.Get().Bans(), session.UserID)
+	if err != nil {
+		log.Printf("Failed to check bans of user %d: %v", session.UserID, err)
+		return false, 0, ""
+	}
+	if banned {
+		return false, 0, ""
+	}
+
+	return true, session.UserID, userName
+}
+
+// MayPost reports whether the user may create posts and comments. With
+// REQUIRE_VERIFIED_EMAIL on, that waits until their email is verified.
+func MayPost(users store.UserRepo, userID int) bool {
+	if !config.Get().RequireVerifiedEmail {
+		return true
+	}
+	user, err := users.ByID(userID)
+	return err == nil && user.EmailVerified
 }
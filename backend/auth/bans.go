@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"forum/backend/controllers/structs"
+	"forum/backend/store"
+)
+
+// ActiveBan returns the user's ban in force, if any.
+func ActiveBan(repo store.BanRepo, userID int) (structs.Ban, bool, error) {
+	ban, err := repo.Active(userID, time.Now().UTC())
+	if errors.Is(err, store.ErrNotFound) {
+		return structs.Ban{}, false, nil
+	}
+	return ban, err == nil, err
+}
+
+// Banned reports whether the user is banned or suspended. Shadow banned
+// users aren't: they are let in and don't notice.
+func Banned(repo store.BanRepo, userID int) (bool, error) {
+	ban, found, err := ActiveBan(repo, userID)
+	return found && !ban.Shadow, err
+}
+
+// ShadowBanned reports whether what the user posts now is only shown to them.
+func ShadowBanned(repo store.BanRepo, userID int) (bool, error) {
+	ban, found, err := ActiveBan(repo, userID)
+	return found && ban.Shadow, err
+}
+
+// Audience is who the request's user is for listings: the public, or a
+// user who also sees what they posted while shadow banned.
+func Audience(r *http.Request) store.Audience {
+	_, userId, _ := IsAuthenticated(r, store.Get().Sessions())
+	return store.Audience{UserID: userId}
+}
+
+// BanUser places the ban. Unless it is a shadow ban, the user is logged out
+// everywhere.
+func BanUser(repos store.Store, ban structs.Ban) error {
+	ban.CreatedAt = time.Now().UTC().Truncate(time.Second)
+	if _, err := repos.Bans().Ban(ban); err != nil {
+		return err
+	}
+	if ban.Shadow {
+		return nil
+	}
+	return repos.Sessions().DeleteByUser(ban.UserID)
+}
+
+// AddressBanned returns the ban on the IP address or the email's domain, if
+// either is banned. A domain ban covers its subdomains too.
+func AddressBanned(repo store.AddressBanRepo, ip, email string) (structs.AddressBan, bool, error) {
+	bans, err := repo.All()
+	if err != nil {
+		return structs.AddressBan{}, false, err
+	}
+
+	addr := net.ParseIP(ip)
+	domain := ""
+	if at := strings.LastIndex(email, "@"); at >= 0 {
+		domain = strings.ToLower(email[at+1:])
+	}
+	for _, ban := range bans {
+		switch ban.Kind {
+		case structs.AddressIP:
+			if addr != nil && ipMatches(ban.Value, addr) {
+				return ban, true, nil
+			}
+		case structs.AddressDomain:
+			if domain != "" && (domain == ban.Value || strings.HasSuffix(domain, "."+ban.Value)) {
+				return ban, true, nil
+			}
+		}
+	}
+	return structs.AddressBan{}, false, nil
+}
+
+// ParseAddressBan checks and normalizes the value of an address ban of the
+// kind: an IP address or CIDR range, or a domain name.
+func ParseAddressBan(kind, value string) (string, bool) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	switch kind {
+	case structs.AddressIP:
+		if _, network, err := net.ParseCIDR(value); err == nil {
+			return network.String(), true
+		}
+		if ip := net.ParseIP(value); ip != nil {
+			return ip.String(), true
+		}
+	case structs.AddressDomain:
+		value = strings.TrimPrefix(value, "@")
+		if value != "" && !strings.ContainsAny(value, "@/ ") && strings.Contains(value, ".") {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+func ipMatches(value string, addr net.IP) bool {
+	if _, network, err := net.ParseCIDR(value); err == nil {
+		return network.Contains(addr)
+	}
+	return addr.Equal(net.ParseIP(value))
+}
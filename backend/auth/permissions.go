@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+
+	"forum/backend/controllers/structs"
+	"forum/backend/rbac"
+	"forum/backend/reputation"
+	"forum/backend/store"
+)
+
+// RoleOf is the user's role; the zero User is a guest.
+func RoleOf(user structs.User) rbac.Role {
+	if user.ID == 0 {
+		return rbac.Guest
+	}
+	return rbac.ParseRole(user.Role)
+}
+
+// Can reports whether the user may use the permission. Elevated permissions
+// also need two-factor authentication to be on, unless the user earned them
+// with their reputation.
+func Can(user structs.User, p rbac.Permission) bool {
+	if Earned(user, p) {
+		return true
+	}
+	if !rbac.Can(RoleOf(user), p) {
+		return false
+	}
+	return !rbac.Elevated(p) || TwoFactorSatisfied(store.Get().TwoFactor(), user)
+}
+
+// Earned reports whether the user's reputation unlocks the permission.
+// Guests earn nothing.
+func Earned(user structs.User, p rbac.Permission) bool {
+	return user.ID != 0 && reputation.Unlocks(user.Reputation, p)
+}
+
+// Authorize loads the request's user and checks that they have the
+// permission, answering 401 or 403 if not. Guests come back as the zero
+// User.
+func Authorize(w http.ResponseWriter, r *http.Request, p rbac.Permission) (structs.User, bool) {
+	repos := store.Get()
+
+	var user structs.User
+	authenticated, userId, _ := IsAuthenticated(r, repos.Sessions())
+	if authenticated {
+		var err error
+		user, err = repos.Users().ByID(userId)
+		if err != nil {
+			http.Error(w, "ERROR: Query error", http.StatusInternalServerError)
+			return structs.User{}, false
+		}
+	}
+
+	if !allow(w, user, p) {
+		return structs.User{}, false
+	}
+	return user, true
+}
+
+// AuthorizeOwner checks that a user from Authorize may act on something
+// owned by ownerID: with own if it is theirs, with anyone otherwise.
+func AuthorizeOwner(w http.ResponseWriter, user structs.User, ownerID int, own, anyone rbac.Permission) bool {
+	if user.ID != 0 && user.ID == ownerID {
+		return allow(w, user, own)
+	}
+	return allow(w, user, anyone)
+}
+
+func allow(w http.ResponseWriter, user structs.User, p rbac.Permission) bool {
+	threshold, earnable := reputation.Threshold(p)
+	switch {
+	case Earned(user, p):
+		return true
+	case !rbac.Can(RoleOf(user), p) && user.ID == 0:
+		http.Error(w, "ERROR: You are not logged in", http.StatusUnauthorized)
+	case !rbac.Can(RoleOf(user), p) && earnable:
+		http.Error(w, fmt.Sprintf("ERROR: You need %d reputation for %s", threshold, p), http.StatusForbidden)
+	case !rbac.Can(RoleOf(user), p):
+		http.Error(w, "ERROR: Missing permission "+string(p), http.StatusForbidden)
+	case rbac.Elevated(p) && !TwoFactorSatisfied(store.Get().TwoFactor(), user):
+		http.Error(w, "ERROR: Turn on two-factor authentication to use your role's privileges", http.StatusForbidden)
+	default:
+		return true
+	}
+	return false
+}
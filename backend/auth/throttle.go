@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"forum/backend/config"
+	"forum/backend/controllers/structs"
+	"forum/backend/mail"
+	"forum/backend/ratelimit"
+	"forum/backend/store"
+)
+
+// LoginFailureWindow is how long failed logins are held against an IP or
+// account.
+const LoginFailureWindow = time.Hour
+
+// Reasons recorded in the audit log of failed logins.
+const (
+	ReasonUnknownEmail = "unknown-email"
+	ReasonBadPassword  = "bad-password"
+	ReasonBadCode      = "bad-code"
+	ReasonLocked       = "locked"
+	ReasonThrottled    = "throttled"
+)
+
+// Many users can share an address behind NAT, so an IP gets more attempts
+// than a single account before it is slowed down.
+var (
+	ipPolicy      = ratelimit.Policy{Free: 20, Base: time.Second, Max: 15 * time.Minute}
+	accountPolicy = ratelimit.Policy{Free: 3, Base: time.Second, Max: 15 * time.Minute}
+)
+
+// Every password reset sends mail, so unlike logins each request counts, not
+// just failures, and the waits grow by minutes.
+var (
+	resetIPPolicy      = ratelimit.Policy{Free: 10, Base: time.Minute, Max: time.Hour}
+	resetAccountPolicy = ratelimit.Policy{Free: 3, Base: time.Minute, Max: time.Hour}
+)
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+func accountKey(email string) string {
+	return "account:" + strings.ToLower(email)
+}
+
+// LoginRetryAfter is how long the request's IP or the account has to wait
+// before trying to log in again; zero if it may try now.
+func LoginRetryAfter(r *http.Request, email string) (time.Duration, error) {
+	return retryAfter(ipKey(ClientIP(r)), accountKey(email), ipPolicy, accountPolicy, time.Now().UTC())
+}
+
+// AllowPasswordReset counts a request to email a reset link to the address,
+// and tells how long the request's IP or the address has to wait first if
+// it has asked too often. A request that has to wait isn't counted. Unknown
+// addresses are counted the same, so the wait doesn't tell who is
+// registered.
+func AllowPasswordReset(r *http.Request, email string) (time.Duration, error) {
+	now := time.Now().UTC()
+	ip, account := "reset-"+ipKey(ClientIP(r)), "reset-"+accountKey(email)
+
+	wait, err := retryAfter(ip, account, resetIPPolicy, resetAccountPolicy, now)
+	if err != nil || wait > 0 {
+		return wait, err
+	}
+
+	limiter := ratelimit.Get()
+	if _, err := limiter.Fail(ip, now); err != nil {
+		return 0, err
+	}
+	_, err = limiter.Fail(account, now)
+	return 0, err
+}
+
+// retryAfter is the longer of the waits the IP and account owe under their
+// policies.
+func retryAfter(ip, account string, ipPolicy, accountPolicy ratelimit.Policy, now time.Time) (time.Duration, error) {
+	limiter := ratelimit.Get()
+
+	failures, last, err := limiter.Failures(ip, now)
+	if err != nil {
+		return 0, err
+	}
+	wait := ipPolicy.RetryAfter(failures, last, now)
+
+	failures, last, err = limiter.Failures(account, now)
+	if err != nil {
+		return 0, err
+	}
+	return max(wait, accountPolicy.RetryAfter(failures, last, now)), nil
+}
+
+// RecordLoginFailure writes the failure to the audit log and counts it
+// against the IP and account. Once the account has failed
+// LockoutThreshold times in a row it is locked, and the owner is emailed a
+// link to unlock it. user is the zero User if the email is unknown.
+func RecordLoginFailure(r *http.Request, email string, user structs.User, reason string) error {
+	repos := store.Get()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	err := repos.LoginFailures().Record(structs.LoginFailure{
+		UserID:    user.ID,
+		Email:     email,
+		IP:        ClientIP(r),
+		UserAgent: r.UserAgent(),
+		Reason:    reason,
+		CreatedAt: now,
+	})
+	if err != nil {
+		return err
+	}
+	if reason == ReasonThrottled || reason == ReasonLocked {
+		return nil
+	}
+
+	limiter := ratelimit.Get()
+	if _, err := limiter.Fail(ipKey(ClientIP(r)), now); err != nil {
+		return err
+	}
+	failures, err := limiter.Fail(accountKey(email), now)
+	if err != nil {
+		return err
+	}
+
+	cfg := config.Get()
+	if user.ID == 0 || failures < cfg.LockoutThreshold || user.Locked(now) {
+		return nil
+	}
+
+	user.LockedUntil = now.Add(cfg.LockoutDuration)
+	if err := repos.Users().Lock(user.ID, user.LockedUntil); err != nil {
+		return err
+	}
+	log.Printf("Locked user %d until %s after %d failed logins", user.ID, user.LockedUntil.Format(time.RFC3339), failures)
+	if err := mail.SendUnlock(user); err != nil {
+		log.Printf("Failed to send unlock email to user %d: %v", user.ID, err)
+	}
+	return nil
+}
+
+// RecordLoginSuccess clears the account's failures. The IP's are kept, so
+// that logging in to one's own account doesn't buy more guesses at others.
+func RecordLoginSuccess(email string) error {
+	return ratelimit.Get().Reset(accountKey(email))
+}
+
+// UnlockAccount lifts the user's lock and clears their failures.
+func UnlockAccount(users store.UserRepo, user structs.User) error {
+	if err := users.Unlock(user.ID); err != nil {
+		return err
+	}
+	return ratelimit.Get().Reset(accountKey(user.Email))
+}
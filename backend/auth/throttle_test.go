@@ -0,0 +1,36 @@
+package auth
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"forum/backend/ratelimit"
+)
+
+func TestAllowPasswordReset(t *testing.T) {
+	ratelimit.Set(ratelimit.NewMemory(LoginFailureWindow))
+	r := httptest.NewRequest("POST", "/api/forgotpassword", nil)
+
+	// The first few requests and the one that reaches the limit go through;
+	// the next has to wait, and isn't counted.
+	for i := 0; i <= resetAccountPolicy.Free; i++ {
+		if wait, err := AllowPasswordReset(r, "ada@example.com"); err != nil || wait != 0 {
+			t.Fatalf("request %d: wait %v, %v; want none", i+1, wait, err)
+		}
+	}
+	for range 2 {
+		if wait, err := AllowPasswordReset(r, "ADA@example.com"); err != nil || wait <= 0 {
+			t.Fatalf("wait %v, %v; want a wait", wait, err)
+		}
+	}
+
+	// Another address from the same IP may still ask.
+	if wait, err := AllowPasswordReset(r, "grace@example.com"); err != nil || wait != 0 {
+		t.Fatalf("other address: wait %v, %v; want none", wait, err)
+	}
+
+	// Login attempts are counted apart.
+	if wait, err := LoginRetryAfter(r, "ada@example.com"); err != nil || wait != 0 {
+		t.Fatalf("LoginRetryAfter = %v, %v; want no wait", wait, err)
+	}
+}
@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"forum/backend/controllers/structs"
+	"forum/backend/rbac"
+	"forum/backend/store"
+	"forum/backend/totp"
+)
+
+// RecoveryCodeCount is how many recovery codes a user gets at a time.
+const RecoveryCodeCount = 10
+
+// RequiresTwoFactor reports whether users with the role have to use
+// two-factor authentication before they can use its privileges.
+func RequiresTwoFactor(role string) bool {
+	return rbac.ParseRole(role).Privileged()
+}
+
+// HasTwoFactor reports whether the user has an authenticator turned on.
+func HasTwoFactor(repo store.TwoFactorRepo, userID int) (bool, error) {
+	tf, err := repo.ByUser(userID)
+	if errors.Is(err, store.ErrNotFound) {
+		return false, nil
+	}
+	return tf.Enabled, err
+}
+
+// TwoFactorSatisfied reports whether the user may act with their role: it
+// is false for admins and moderators until they turn on two-factor
+// authentication.
+func TwoFactorSatisfied(repo store.TwoFactorRepo, user structs.User) bool {
+	if !RequiresTwoFactor(user.Role) {
+		return true
+	}
+	enabled, err := HasTwoFactor(repo, user.ID)
+	return err == nil && enabled
+}
+
+// CheckSecondFactor accepts a code from the user's authenticator or one of
+// their recovery codes. Either can only be used once.
+func CheckSecondFactor(repo store.TwoFactorRepo, userID int, code string) (bool, error) {
+	tf, err := repo.ByUser(userID)
+	if errors.Is(err, store.ErrNotFound) || (err == nil && !tf.Enabled) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	now := time.Now().UTC()
+	if step, ok := totp.Validate(tf.Secret, code, now); ok {
+		return repo.UseStep(userID, step)
+	}
+	return repo.UseRecoveryCode(userID, HashToken(totp.NormalizeRecoveryCode(code)), now)
+}
+
+// NewRecoveryCodes returns fresh recovery codes to show the user once, and
+// the hashes to store.
+func NewRecoveryCodes() ([]string, []string, error) {
+	codes, err := totp.RecoveryCodes(RecoveryCodeCount)
+	if err != nil {
+		return nil, nil, err
+	}
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = HashToken(code)
+	}
+	return codes, hashes, nil
+}
@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"forum/backend/controllers/structs"
+	"forum/backend/database"
+	"forum/backend/totp"
+)
+
+// Neither an authenticator code nor a recovery code works a second time.
+func TestSecondFactorsWorkOnce(t *testing.T) {
+	st, err := database.OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	userID, err := st.Users().Create(structs.User{Email: "ada@example.com", UserName: "ada", Password: "hash"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	codes, hashes, err := NewRecoveryCodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := st.TwoFactor()
+	if err := repo.Begin(userID, secret, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Enable(userID, 0, hashes); err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := totp.Code(secret, totp.Step(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []bool{true, false} {
+		if ok, err := CheckSecondFactor(repo, userID, code); err != nil || ok != want {
+			t.Errorf("authenticator code, attempt %d: %v, %v; want %v", i+1, ok, err, want)
+		}
+	}
+
+	// Typed in upper case with a space, as people do.
+	typed := strings.ToUpper(codes[0][:5] + " " + codes[0][6:])
+	for i, want := range []bool{true, false} {
+		if ok, err := CheckSecondFactor(repo, userID, typed); err != nil || ok != want {
+			t.Errorf("recovery code, attempt %d: %v, %v; want %v", i+1, ok, err, want)
+		}
+	}
+	if ok, err := CheckSecondFactor(repo, userID, codes[1]); err != nil || !ok {
+		t.Errorf("another recovery code: %v, %v; want it accepted", ok, err)
+	}
+}
@@ -0,0 +1,90 @@
+// Package avatar stores the images users upload for their profile.
+package avatar
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MaxSize is the largest avatar accepted, in bytes.
+const MaxSize = 2 << 20
+
+// dir is served under /uploads/avatars/. It is kept apart from the photos
+// of posts, so no upload can take an avatar's name.
+const dir = "./uploads/avatars"
+
+var (
+	ErrUnsupported = errors.New("only JPEG, PNG and GIF avatars are allowed")
+	ErrTooLarge    = errors.New("avatars must be under 2MB")
+)
+
+var extensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// Save stores the image under a name of its own and returns its path. The
+// type is taken from the content, not the uploaded file name, and images
+// over MaxSize are refused however the request was parsed.
+func Save(userID int, image io.Reader) (string, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(image, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", err
+	}
+	head = head[:n]
+	ext, ok := extensions[http.DetectContentType(head)]
+	if !ok {
+		return "", ErrUnsupported
+	}
+
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("avatar-%d-%s%s", userID, hex.EncodeToString(suffix), ext)
+
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return "", err
+	}
+	file, err := os.Create(filepath.Join(dir, name))
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	_, err = file.Write(head)
+	if err == nil {
+		var copied int64
+		copied, err = io.Copy(file, io.LimitReader(image, MaxSize-int64(len(head))+1))
+		if err == nil && int64(len(head))+copied > MaxSize {
+			err = ErrTooLarge
+		}
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+	return "/uploads/avatars/" + name, nil
+}
+
+// Remove deletes the avatar at the path Save returned. Other paths are
+// left alone.
+func Remove(path string) error {
+	name := strings.TrimPrefix(path, "/uploads/avatars/")
+	if name == path || !strings.HasPrefix(name, "avatar-") || name != filepath.Base(name) {
+		return nil
+	}
+	err := os.Remove(filepath.Join(dir, name))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,63 @@
+package avatar
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+)
+
+// inTempDir runs the test from an empty directory, so uploads land there.
+func inTempDir(t *testing.T) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func gif(size int) []byte {
+	image := append([]byte("GIF89a"), make([]byte, size)...)
+	return image[:size]
+}
+
+func TestSaveRefusesLargeAvatar(t *testing.T) {
+	inTempDir(t)
+
+	_, err := Save(1, bytes.NewReader(gif(5<<20)))
+	if !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("Save(5MB) error = %v, want ErrTooLarge", err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Fatalf("%d files left in %s, want none", len(entries), dir)
+	}
+}
+
+func TestSaveAcceptsMaxSize(t *testing.T) {
+	inTempDir(t)
+
+	path, err := Save(1, bytes.NewReader(gif(MaxSize)))
+	if err != nil {
+		t.Fatalf("Save(MaxSize): %v", err)
+	}
+	info, err := os.Stat("." + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != MaxSize {
+		t.Fatalf("stored %d bytes, want %d", info.Size(), MaxSize)
+	}
+}
+
+func TestSaveRefusesOtherTypes(t *testing.T) {
+	inTempDir(t)
+
+	if _, err := Save(1, bytes.NewReader([]byte("<html>hi</html>"))); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("Save(html) error = %v, want ErrUnsupported", err)
+	}
+}
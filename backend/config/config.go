@@ -0,0 +1,281 @@
+package config
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const envFile = "backend/.env"
+
+const (
+	DriverSQLite   = "sqlite3"
+	DriverPostgres = "postgres"
+)
+
+// WAL lets readers run alongside the single writer, busy_timeout makes writers
+// wait for the lock instead of failing, and immediate transactions take the
+// write lock up front so read-then-write transactions can't deadlock.
+const defaultSQLiteDSN = "file:./forum.db?_journal_mode=WAL&_busy_timeout=5000&_txlock=immediate"
+
+const defaultBaseURL = "http://localhost:8080"
+
+const (
+	defaultSessionIdleTimeout = 24 * time.Hour
+	defaultSessionMaxAge      = 30 * 24 * time.Hour
+)
+
+const (
+	LimiterMemory   = "memory"
+	LimiterDatabase = "database"
+)
+
+type Config struct {
+	DatabaseDriver string
+	DatabaseURL    string
+	// BaseURL is where users reach the forum; OAuth redirect URIs are built
+	// from it.
+	BaseURL string
+	// A session ends after SessionIdleTimeout without use, and in any case
+	// SessionMaxAge after login.
+	SessionIdleTimeout time.Duration
+	SessionMaxAge      time.Duration
+	// CookieSecure should be on whenever the forum is served over HTTPS.
+	CookieSecure   bool
+	CookieSameSite http.SameSite
+	// SecretKey signs the links in verification and password reset emails.
+	SecretKey string
+	// RequireVerifiedEmail keeps users from posting and commenting until
+	// they have confirmed their email address.
+	RequireVerifiedEmail bool
+	Mail                 Mail
+	// LoginLimiter keeps the failed login counters in memory, or in the
+	// database so that several instances share them.
+	LoginLimiter string
+	// An account is locked for LockoutDuration after LockoutThreshold
+	// failed logins in a row.
+	LockoutThreshold int
+	LockoutDuration  time.Duration
+	// A post or comment is hidden until a moderator reviews it once
+	// ReportHideThreshold different users have reported it.
+	ReportHideThreshold int
+	// Deleted posts and comments can be restored for TrashRetention; the
+	// purge job runs every PurgeInterval and removes them after that.
+	TrashRetention time.Duration
+	PurgeInterval  time.Duration
+	// Comment threads are nested CommentMaxDepth replies deep; deeper ones
+	// are loaded separately.
+	CommentMaxDepth int
+	// Users whose role doesn't allow downvoting, editing other people's
+	// posts or creating tags earn it at these reputations.
+	ReputationDownvote  int
+	ReputationEditPosts int
+	ReputationCreateTag int
+}
+
+// Mail selects how outgoing email is sent: "smtp" through the configured
+// server, or "outbox" (the default) to write each message to a file in
+// OutboxDir, which is handy during development.
+type Mail struct {
+	Driver       string
+	From         string
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	OutboxDir    string
+}
+
+var current Config
+
+// Load reads backend/.env into the process environment (variables that are
+// already set win) and builds the configuration from it.
+func Load() Config {
+	loadEnvFile(envFile)
+
+	cfg := Config{
+		DatabaseURL:          os.Getenv("DATABASE_URL"),
+		DatabaseDriver:       os.Getenv("DATABASE_DRIVER"),
+		BaseURL:              strings.TrimRight(os.Getenv("BASE_URL"), "/"),
+		SessionIdleTimeout:   durationEnv("SESSION_IDLE_TIMEOUT", defaultSessionIdleTimeout),
+		SessionMaxAge:        durationEnv("SESSION_MAX_AGE", defaultSessionMaxAge),
+		CookieSecure:         boolEnv("COOKIE_SECURE", false),
+		CookieSameSite:       sameSiteEnv("COOKIE_SAMESITE", http.SameSiteLaxMode),
+		SecretKey:            os.Getenv("SECRET_KEY"),
+		RequireVerifiedEmail: boolEnv("REQUIRE_VERIFIED_EMAIL", false),
+		Mail: Mail{
+			Driver:       stringEnv("MAIL_DRIVER", "outbox"),
+			From:         stringEnv("MAIL_FROM", "Forum Ware <no-reply@localhost>"),
+			SMTPHost:     os.Getenv("SMTP_HOST"),
+			SMTPPort:     stringEnv("SMTP_PORT", "587"),
+			SMTPUsername: os.Getenv("SMTP_USERNAME"),
+			SMTPPassword: os.Getenv("SMTP_PASSWORD"),
+			OutboxDir:    stringEnv("MAIL_OUTBOX_DIR", "./outbox"),
+		},
+		LoginLimiter:        strings.ToLower(stringEnv("LOGIN_LIMITER", LimiterMemory)),
+		LockoutThreshold:    intEnv("LOGIN_LOCKOUT_THRESHOLD", 10),
+		LockoutDuration:     durationEnv("LOGIN_LOCKOUT_DURATION", 30*time.Minute),
+		ReportHideThreshold: intEnv("REPORT_HIDE_THRESHOLD", 3),
+		TrashRetention:      durationEnv("TRASH_RETENTION", 30*24*time.Hour),
+		PurgeInterval:       durationEnv("PURGE_INTERVAL", time.Hour),
+		CommentMaxDepth:     intEnv("COMMENT_MAX_DEPTH", 5),
+		ReputationDownvote:  intEnv("REPUTATION_DOWNVOTE", 15),
+		ReputationEditPosts: intEnv("REPUTATION_EDIT_POSTS", 1000),
+		ReputationCreateTag: intEnv("REPUTATION_CREATE_TAG", 300),
+	}
+	if cfg.SecretKey == "" {
+		cfg.SecretKey = generatedSecret()
+	}
+	if cfg.CookieSameSite == http.SameSiteNoneMode && !cfg.CookieSecure {
+		log.Printf("COOKIE_SAMESITE=none requires COOKIE_SECURE, enabling it")
+		cfg.CookieSecure = true
+	}
+	if cfg.DatabaseURL == "" {
+		cfg.DatabaseURL = defaultSQLiteDSN
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = defaultBaseURL
+	}
+	if cfg.DatabaseDriver == "" {
+		cfg.DatabaseDriver = driverFromURL(cfg.DatabaseURL)
+	}
+
+	current = cfg
+	return cfg
+}
+
+// Get returns the configuration loaded at startup, or the defaults if Load
+// hasn't run.
+func Get() Config {
+	if current == (Config{}) {
+		return Load()
+	}
+	return current
+}
+
+func durationEnv(name string, fallback time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Printf("Ignoring invalid %s %q, using %s", name, value, fallback)
+		return fallback
+	}
+	return d
+}
+
+func intEnv(name string, fallback int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		log.Printf("Ignoring invalid %s %q, using %d", name, value, fallback)
+		return fallback
+	}
+	return n
+}
+
+func stringEnv(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
+
+var (
+	secretOnce sync.Once
+	secret     string
+)
+
+// generatedSecret stands in for a missing SECRET_KEY. Links signed with it
+// stop working when the server restarts, and aren't accepted by other
+// instances.
+func generatedSecret() string {
+	secretOnce.Do(func() {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			log.Fatalf("Error generating secret key: %v", err)
+		}
+		secret = hex.EncodeToString(b)
+		log.Printf("SECRET_KEY is not set; using a random key, so emailed links expire on restart")
+	})
+	return secret
+}
+
+func boolEnv(name string, fallback bool) bool {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Ignoring invalid %s %q, using %t", name, value, fallback)
+		return fallback
+	}
+	return b
+}
+
+func sameSiteEnv(name string, fallback http.SameSite) http.SameSite {
+	switch value := strings.ToLower(os.Getenv(name)); value {
+	case "":
+		return fallback
+	case "lax":
+		return http.SameSiteLaxMode
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		log.Printf("Ignoring invalid %s %q (want lax, strict or none)", name, value)
+		return fallback
+	}
+}
+
+func driverFromURL(dsn string) string {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		return DriverPostgres
+	}
+	return DriverSQLite
+}
+
+func loadEnvFile(path string) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return
+	}
+	if err != nil {
+		log.Fatalf("Error opening .env file: %v", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		if _, set := os.LookupEnv(parts[0]); set {
+			continue
+		}
+		os.Setenv(parts[0], parts[1])
+	}
+
+	if err := scanner.Err(); err != nil {
+		log.Fatalf("Error reading .env file: %v", err)
+	}
+}
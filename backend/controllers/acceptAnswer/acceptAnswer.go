@@ -0,0 +1,94 @@
+package acceptanswer
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"forum/backend/auth"
+	"forum/backend/rbac"
+	"forum/backend/store"
+)
+
+// AcceptAnswer marks a comment as the accepted answer of the question it
+// is on, replacing any earlier one; with undo=true it takes the acceptance
+// back. The asker can accept answers to their own questions, moderators to
+// any.
+func AcceptAnswer(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	commentIdInt, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		http.Error(w, "ERROR: Invalid comment ID format", http.StatusBadRequest)
+		return
+	}
+	undo := r.FormValue("undo") == "true"
+
+	user, ok := auth.Authorize(w, r, rbac.PostEditOwn)
+	if !ok {
+		return
+	}
+
+	repos := store.Get()
+
+	comment, err := repos.Comments().ByID(commentIdInt)
+	if err == nil && (!comment.DeletedAt.IsZero() || comment.Hidden || comment.Shadow) {
+		err = store.ErrNotFound
+	}
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "ERROR: Comment not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "ERROR: Query error", http.StatusInternalServerError)
+		return
+	}
+
+	post, err := repos.Posts().ByID(comment.PostId)
+	if err == nil && !post.DeletedAt.IsZero() {
+		err = store.ErrNotFound
+	}
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "ERROR: Post not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "ERROR: Query error", http.StatusInternalServerError)
+		return
+	}
+	if !post.Question {
+		http.Error(w, "ERROR: Only questions have accepted answers", http.StatusBadRequest)
+		return
+	}
+
+	if !auth.AuthorizeOwner(w, user, post.UserID, rbac.PostEditOwn, rbac.AnswerAcceptAny) {
+		return
+	}
+
+	accepted := commentIdInt
+	if undo {
+		if post.AcceptedID != commentIdInt {
+			http.Error(w, "ERROR: This is not the accepted answer", http.StatusBadRequest)
+			return
+		}
+		accepted = 0
+	}
+
+	err = repos.Posts().Accept(post.ID, accepted, time.Now())
+	if err != nil {
+		http.Error(w, "ERROR: Unable to accept the answer", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if undo {
+		fmt.Fprintf(w, "Answer no longer accepted")
+		return
+	}
+	fmt.Fprintf(w, "Answer accepted")
+}
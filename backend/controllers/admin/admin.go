@@ -0,0 +1,67 @@
+// Package admin is the API behind the /admin pages. Every handler asks for
+// its own permission, so moderators get the parts their role allows.
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"forum/backend/auth"
+	"forum/backend/controllers/structs"
+	"forum/backend/rbac"
+	"forum/backend/store"
+)
+
+// targetUser loads the user named by the "id" field and checks that the
+// acting user outranks them. Nobody can act on themselves or their equals
+// here; admins are managed with the CLI.
+func targetUser(w http.ResponseWriter, r *http.Request, actor structs.User) (structs.User, bool) {
+	userId, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		http.Error(w, "ERROR: Invalid user id", http.StatusBadRequest)
+		return structs.User{}, false
+	}
+
+	user, err := store.Get().Users().ByID(userId)
+	if err != nil {
+		http.Error(w, "ERROR: User not found", http.StatusNotFound)
+		return structs.User{}, false
+	}
+
+	if !auth.RoleOf(actor).Outranks(rbac.ParseRole(user.Role)) {
+		http.Error(w, "ERROR: You can only manage users below your role", http.StatusForbidden)
+		return structs.User{}, false
+	}
+	return user, true
+}
+
+// formIDs reads every "id" value of the form.
+func formIDs(w http.ResponseWriter, r *http.Request) ([]int, bool) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "ERROR: Invalid form", http.StatusBadRequest)
+		return nil, false
+	}
+
+	ids := make([]int, 0, len(r.Form["id"]))
+	for _, value := range r.Form["id"] {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "ERROR: Invalid id "+value, http.StatusBadRequest)
+			return nil, false
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		http.Error(w, "ERROR: Nothing selected", http.StatusBadRequest)
+		return nil, false
+	}
+	return ids, true
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, "ERROR: Failed to encode response to JSON", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,128 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"forum/backend/auth"
+	"forum/backend/controllers/structs"
+	"forum/backend/rbac"
+	"forum/backend/store"
+)
+
+// GetUserBans lists every ban the user has had, newest first.
+func GetUserBans(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if _, ok := auth.Authorize(w, r, rbac.UserBan); !ok {
+		return
+	}
+
+	userId, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		http.Error(w, "ERROR: Invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	bans, err := store.Get().Bans().ByUser(userId)
+	if err != nil {
+		http.Error(w, "ERROR: Query execution failed", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, bans)
+}
+
+func GetAddressBans(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if _, ok := auth.Authorize(w, r, rbac.UserBan); !ok {
+		return
+	}
+
+	bans, err := store.Get().AddressBans().All()
+	if err != nil {
+		http.Error(w, "ERROR: Query execution failed", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, bans)
+}
+
+// BanAddress refuses registrations and logins from an IP address or range
+// (kind "ip") or for an email domain (kind "domain").
+func BanAddress(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	actor, ok := auth.Authorize(w, r, rbac.UserBan)
+	if !ok {
+		return
+	}
+
+	kind := r.FormValue("kind")
+	value, valid := auth.ParseAddressBan(kind, r.FormValue("value"))
+	if !valid {
+		http.Error(w, "ERROR: Give an IP address or CIDR range, or a domain", http.StatusBadRequest)
+		return
+	}
+
+	_, err := store.Get().AddressBans().Create(structs.AddressBan{
+		Kind:      kind,
+		Value:     value,
+		Reason:    strings.TrimSpace(r.FormValue("reason")),
+		CreatedBy: actor.ID,
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+	})
+	if errors.Is(err, store.ErrAddressBanned) {
+		http.Error(w, "ERROR: "+err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "ERROR: Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "Address successfully banned")
+}
+
+func DeleteAddressBan(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if _, ok := auth.Authorize(w, r, rbac.UserBan); !ok {
+		return
+	}
+
+	banId, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		http.Error(w, "ERROR: Invalid id", http.StatusBadRequest)
+		return
+	}
+
+	err = store.Get().AddressBans().Delete(banId)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "ERROR: Address ban not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "ERROR: Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "Address ban successfully removed")
+}
@@ -0,0 +1,214 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"forum/backend/auth"
+	"forum/backend/config"
+	"forum/backend/controllers/structs"
+	"forum/backend/pagination"
+	"forum/backend/rbac"
+	"forum/backend/store"
+)
+
+// GetPosts lists every post, newest first.
+func GetPosts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if _, ok := auth.Authorize(w, r, rbac.AdminAccess); !ok {
+		return
+	}
+
+	page, err := pagination.FromRequest(r)
+	if err != nil {
+		http.Error(w, "ERROR: Invalid cursor", http.StatusBadRequest)
+		return
+	}
+
+	posts, more, err := store.Get().Posts().All(store.Audience{Everything: true}, page)
+	if err != nil {
+		http.Error(w, "ERROR: Query execution failed", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, structs.PostList{Posts: posts, Cursors: pagination.Keyset(page, pagination.PostIDs(posts), more)})
+}
+
+// GetComments lists every comment, newest first.
+func GetComments(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if _, ok := auth.Authorize(w, r, rbac.AdminAccess); !ok {
+		return
+	}
+
+	page, err := pagination.FromRequest(r)
+	if err != nil {
+		http.Error(w, "ERROR: Invalid cursor", http.StatusBadRequest)
+		return
+	}
+
+	comments, more, err := store.Get().Comments().All(page)
+	if err != nil {
+		http.Error(w, "ERROR: Query execution failed", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, structs.CommentList{Comments: comments, Cursors: pagination.Keyset(page, pagination.CommentIDs(comments), more)})
+}
+
+// DeletePosts moves every post in the "id" fields to the trash.
+func DeletePosts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	moderator, ok := auth.Authorize(w, r, rbac.PostDeleteAny)
+	if !ok {
+		return
+	}
+	ids, ok := formIDs(w, r)
+	if !ok {
+		return
+	}
+
+	repos := store.Get()
+	now := time.Now()
+	for _, id := range ids {
+		// Ones already in the trash stay as they were.
+		err := repos.Posts().Delete(id, moderator.ID, now)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			http.Error(w, fmt.Sprintf("ERROR: Unable to delete post %d", id), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "%d posts successfully deleted", len(ids))
+}
+
+// DeleteComments moves every comment in the "id" fields to the trash.
+func DeleteComments(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	moderator, ok := auth.Authorize(w, r, rbac.CommentDeleteAny)
+	if !ok {
+		return
+	}
+	ids, ok := formIDs(w, r)
+	if !ok {
+		return
+	}
+
+	repos := store.Get()
+	now := time.Now()
+	for _, id := range ids {
+		// Ones already in the trash stay as they were.
+		err := repos.Comments().Delete(id, moderator.ID, now)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			http.Error(w, fmt.Sprintf("ERROR: Unable to delete comment %d", id), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "%d comments successfully deleted", len(ids))
+}
+
+// RestorePosts takes every post in the "id" fields back out of the trash.
+// Ones deleted longer ago than the trash keeps them are left for the purge.
+func RestorePosts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if _, ok := auth.Authorize(w, r, rbac.PostDeleteAny); !ok {
+		return
+	}
+	ids, ok := formIDs(w, r)
+	if !ok {
+		return
+	}
+
+	repos := store.Get()
+	restored := 0
+	for _, id := range ids {
+		post, err := repos.Posts().ByID(id)
+		if err == nil {
+			if post.DeletedAt.IsZero() || expired(post.DeletedAt) {
+				continue
+			}
+			err = repos.Posts().Restore(id)
+		}
+		if errors.Is(err, store.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("ERROR: Unable to restore post %d", id), http.StatusInternalServerError)
+			return
+		}
+		restored++
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "%d posts successfully restored", restored)
+}
+
+// RestoreComments takes every comment in the "id" fields back out of the
+// trash, like RestorePosts.
+func RestoreComments(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if _, ok := auth.Authorize(w, r, rbac.CommentDeleteAny); !ok {
+		return
+	}
+	ids, ok := formIDs(w, r)
+	if !ok {
+		return
+	}
+
+	repos := store.Get()
+	restored := 0
+	for _, id := range ids {
+		comment, err := repos.Comments().ByID(id)
+		if err == nil {
+			if comment.DeletedAt.IsZero() || expired(comment.DeletedAt) {
+				continue
+			}
+			err = repos.Comments().Restore(id)
+		}
+		if errors.Is(err, store.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("ERROR: Unable to restore comment %d", id), http.StatusInternalServerError)
+			return
+		}
+		restored++
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "%d comments successfully restored", restored)
+}
+
+// expired reports whether what was deleted then is past the trash retention.
+func expired(deletedAt time.Time) bool {
+	return time.Since(deletedAt) > config.Get().TrashRetention
+}
@@ -0,0 +1,81 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"forum/backend/auth"
+	"forum/backend/moderation"
+	"forum/backend/rbac"
+	"forum/backend/store"
+)
+
+// GetReports lists the moderation queue.
+func GetReports(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if _, ok := auth.Authorize(w, r, rbac.ReportResolve); !ok {
+		return
+	}
+
+	items, err := store.Get().Reports().Queue()
+	if err != nil {
+		http.Error(w, "ERROR: Query execution failed", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, items)
+}
+
+// ResolveReport closes the open reports on a post or comment with one of
+// moderation.Actions.
+func ResolveReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	actor, ok := auth.Authorize(w, r, rbac.ReportResolve)
+	if !ok {
+		return
+	}
+
+	targetId, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		http.Error(w, "ERROR: Invalid ID", http.StatusBadRequest)
+		return
+	}
+	isComment := r.FormValue("isComment") == "true"
+	action := r.FormValue("action")
+
+	if !moderation.ValidAction(action) {
+		http.Error(w, "ERROR: Choose one of the actions: "+strings.Join(moderation.Actions, ", "), http.StatusBadRequest)
+		return
+	}
+	if action == moderation.ActionBan && !auth.Can(actor, rbac.UserBan) {
+		http.Error(w, "ERROR: Missing permission "+string(rbac.UserBan), http.StatusForbidden)
+		return
+	}
+
+	err = moderation.Resolve(store.Get(), actor, targetId, isComment, action, strings.TrimSpace(r.FormValue("note")))
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "ERROR: No open reports on this post or comment", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, moderation.ErrOutranked) {
+		http.Error(w, "ERROR: You can only ban users below your role", http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		http.Error(w, "ERROR: Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "Report successfully resolved")
+}
@@ -0,0 +1,42 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"forum/backend/auth"
+	"forum/backend/rbac"
+	"forum/backend/store"
+)
+
+const (
+	defaultStatsDays = 30
+	maxStatsDays     = 365
+)
+
+// GetStats reports the site's totals and the activity of the last ?days=
+// days.
+func GetStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if _, ok := auth.Authorize(w, r, rbac.AdminAccess); !ok {
+		return
+	}
+
+	days := defaultStatsDays
+	if n, err := strconv.Atoi(r.FormValue("days")); err == nil && n > 0 {
+		days = min(n, maxStatsDays)
+	}
+
+	stats, err := store.Get().Stats().Site(time.Now(), days)
+	if err != nil {
+		http.Error(w, "ERROR: Query execution failed", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, stats)
+}
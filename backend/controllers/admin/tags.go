@@ -0,0 +1,87 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"forum/backend/auth"
+	createtag "forum/backend/controllers/create/createTag"
+	"forum/backend/controllers/structs"
+	"forum/backend/rbac"
+	"forum/backend/store"
+)
+
+func UpdateTag(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if _, ok := auth.Authorize(w, r, rbac.TagEdit); !ok {
+		return
+	}
+
+	tagId, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		http.Error(w, "ERROR: Invalid tag id", http.StatusBadRequest)
+		return
+	}
+
+	tag := structs.Tag{
+		ID:          tagId,
+		Name:        strings.TrimSpace(r.FormValue("name")),
+		Description: strings.TrimSpace(r.FormValue("description")),
+		Color:       strings.TrimSpace(r.FormValue("color")),
+	}
+	if !createtag.AreTagDetailsValid(tag) {
+		http.Error(w, "ERROR: Tag needs a name and a #rrggbb color", http.StatusBadRequest)
+		return
+	}
+
+	err = store.Get().Tags().Update(tag)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "ERROR: Tag not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "ERROR: Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "Tag successfully updated")
+}
+
+// DeleteTag removes the tag from every post and deletes it.
+func DeleteTag(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if _, ok := auth.Authorize(w, r, rbac.TagDelete); !ok {
+		return
+	}
+
+	tagId, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		http.Error(w, "ERROR: Invalid tag id", http.StatusBadRequest)
+		return
+	}
+
+	err = store.Get().Tags().Delete(tagId)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "ERROR: Tag not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "ERROR: Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "Tag successfully deleted")
+}
@@ -0,0 +1,227 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"forum/backend/auth"
+	"forum/backend/controllers/structs"
+	"forum/backend/pagination"
+	"forum/backend/rbac"
+	"forum/backend/reputation"
+	"forum/backend/store"
+)
+
+// GetUsers lists users, searching names and emails with ?q=.
+func GetUsers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if _, ok := auth.Authorize(w, r, rbac.AdminAccess); !ok {
+		return
+	}
+
+	page, err := pagination.FromRequest(r)
+	if err != nil {
+		http.Error(w, "ERROR: Invalid cursor", http.StatusBadRequest)
+		return
+	}
+
+	users, more, err := store.Get().Users().Search(strings.TrimSpace(r.FormValue("q")), time.Now().UTC(), page)
+	if err != nil {
+		http.Error(w, "ERROR: Query execution failed", http.StatusInternalServerError)
+		return
+	}
+
+	ids := make([]int, len(users))
+	for i, user := range users {
+		ids[i] = user.ID
+	}
+	writeJSON(w, structs.UserList{Users: users, Cursors: pagination.Keyset(page, ids, more)})
+}
+
+func SetRole(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	actor, ok := auth.Authorize(w, r, rbac.UserSetRole)
+	if !ok {
+		return
+	}
+	user, ok := targetUser(w, r, actor)
+	if !ok {
+		return
+	}
+
+	role, found := rbac.Lookup(r.FormValue("role"))
+	if !found {
+		http.Error(w, "ERROR: Unknown role", http.StatusBadRequest)
+		return
+	}
+	if !auth.RoleOf(actor).Outranks(role) {
+		http.Error(w, "ERROR: You can only give roles below your own", http.StatusForbidden)
+		return
+	}
+
+	err := store.Get().Users().SetRole(user.ID, string(role))
+	if err != nil {
+		http.Error(w, "ERROR: Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "Role successfully changed")
+}
+
+// BanUser bans the user for good, or suspends them for the "duration" (a
+// Go duration such as 72h). With "shadow" they are shadow banned instead.
+func BanUser(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	actor, ok := auth.Authorize(w, r, rbac.UserBan)
+	if !ok {
+		return
+	}
+	user, ok := targetUser(w, r, actor)
+	if !ok {
+		return
+	}
+
+	ban := structs.Ban{
+		UserID:   user.ID,
+		Reason:   strings.TrimSpace(r.FormValue("reason")),
+		BannedBy: actor.ID,
+		Shadow:   r.FormValue("shadow") == "true",
+	}
+	if duration := r.FormValue("duration"); duration != "" {
+		d, err := time.ParseDuration(duration)
+		if err != nil || d <= 0 {
+			http.Error(w, "ERROR: Invalid duration", http.StatusBadRequest)
+			return
+		}
+		ban.ExpiresAt = time.Now().UTC().Add(d).Truncate(time.Second)
+	}
+
+	err := auth.BanUser(store.Get(), ban)
+	if errors.Is(err, store.ErrAlreadyBanned) {
+		http.Error(w, "ERROR: "+err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "ERROR: Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "User successfully banned")
+}
+
+func UnbanUser(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	actor, ok := auth.Authorize(w, r, rbac.UserBan)
+	if !ok {
+		return
+	}
+	user, ok := targetUser(w, r, actor)
+	if !ok {
+		return
+	}
+
+	err := store.Get().Bans().Lift(user.ID, actor.ID, time.Now().UTC().Truncate(time.Second))
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "ERROR: User is not banned", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "ERROR: Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "Ban successfully lifted")
+}
+
+// RevokeSessions logs the user out everywhere.
+func RevokeSessions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	actor, ok := auth.Authorize(w, r, rbac.UserSessions)
+	if !ok {
+		return
+	}
+	user, ok := targetUser(w, r, actor)
+	if !ok {
+		return
+	}
+
+	err := store.Get().Sessions().DeleteByUser(user.ID)
+	if err != nil {
+		http.Error(w, "ERROR: Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "Sessions successfully revoked")
+}
+
+// AdjustReputation adds "points" (negative to take some away) to the user's
+// reputation, with a "note" saying why.
+func AdjustReputation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	actor, ok := auth.Authorize(w, r, rbac.UserReputation)
+	if !ok {
+		return
+	}
+	user, ok := targetUser(w, r, actor)
+	if !ok {
+		return
+	}
+
+	points, err := strconv.Atoi(r.FormValue("points"))
+	if err != nil || points == 0 {
+		http.Error(w, "ERROR: Points must be a whole number other than 0", http.StatusBadRequest)
+		return
+	}
+	note := strings.TrimSpace(r.FormValue("note"))
+	if note == "" {
+		http.Error(w, "ERROR: Say why the reputation changes", http.StatusBadRequest)
+		return
+	}
+
+	err = store.Get().Reputation().Record(structs.ReputationEvent{
+		UserID:  user.ID,
+		Points:  points,
+		Reason:  reputation.ReasonAdjusted,
+		ActorID: actor.ID,
+		Note:    note,
+	})
+	if err != nil {
+		http.Error(w, "ERROR: Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "Reputation successfully adjusted")
+}
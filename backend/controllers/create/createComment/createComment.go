@@ -6,7 +6,10 @@ import (
 	"strconv"
 
 	"forum/backend/auth"
-	"forum/backend/database"
+	"forum/backend/controllers/structs"
+	"forum/backend/markdown"
+	"forum/backend/rbac"
+	"forum/backend/store"
 )
 
 func CreateComment(w http.ResponseWriter, r *http.Request) {
@@ -29,26 +32,46 @@ func CreateComment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, errDb := database.OpenDb(w)
-	if errDb != nil {
-		http.Error(w, "ERROR: Database cannot open", http.StatusBadRequest)
+	repos := store.Get()
+
+	user, ok := auth.Authorize(w, r, rbac.CommentCreate)
+	if !ok {
 		return
 	}
-	defer db.Close()
-
-	authenticated, userId, userName := auth.IsAuthenticated(r, db)
-	if !authenticated {
-		http.Error(w, "ERROR: You are not authorized to create comment", http.StatusUnauthorized)
+	userId, userName := user.ID, user.UserName
+	if !auth.MayPost(repos.Users(), userId) {
+		http.Error(w, "ERROR: Please verify your email address first", http.StatusForbidden)
 		return
 	}
-	var secondUserId int
-	err := db.QueryRow("SELECT UserId FROM POSTS WHERE ID = ?", postIdInt).Scan(&secondUserId)
+	// Shadow banned users aren't told; only they will see what they post.
+	shadow, err := auth.ShadowBanned(repos.Bans(), userId)
+	if err != nil {
+		http.Error(w, "ERROR: Database error", http.StatusInternalServerError)
+		return
+	}
+	_, _, err = repos.Posts().Owner(postIdInt)
 	if err != nil {
 		http.Error(w, "ERROR: Invalid post ID", http.StatusBadRequest)
 		return
 	}
 
-	_, errEx := db.Exec(`INSERT INTO COMMENTS (PostID, UserId, UserName, Comment) VALUES (?, ?, ?, ?)`, postIdInt, userId, userName, comment)
+	// A reply names the comment it answers, which must be on the same post.
+	parentId := 0
+	if parent := r.FormValue("parent"); parent != "" {
+		parentId, atoiErr = strconv.Atoi(parent)
+		if atoiErr != nil {
+			http.Error(w, "ERROR: Invalid parent ID format", http.StatusBadRequest)
+			return
+		}
+		parentComment, err := repos.Comments().ByID(parentId)
+		if err != nil || parentComment.PostId != postIdInt || !parentComment.DeletedAt.IsZero() {
+			http.Error(w, "ERROR: Invalid parent comment", http.StatusBadRequest)
+			return
+		}
+	}
+
+	_, errEx := repos.Comments().Create(structs.Comment{PostId: postIdInt, UserId: userId, UserName: userName, Comment: comment, CommentHTML: markdown.Render(comment),
+		Shadow: shadow, ParentID: parentId})
 	if errEx != nil {
 		http.Error(w, "ERROR: Post did not add to the database", http.StatusBadRequest)
 		return
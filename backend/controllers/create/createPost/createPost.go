@@ -1,15 +1,18 @@
 package createpost
 
 import (
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strings"
 
 	"forum/backend/auth"
-	"forum/backend/database"
+	"forum/backend/controllers/structs"
+	"forum/backend/markdown"
+	"forum/backend/photo"
+	"forum/backend/purge"
+	"forum/backend/rbac"
+	"forum/backend/store"
 )
 
 func CreatePost(w http.ResponseWriter, r *http.Request) {
@@ -32,74 +35,57 @@ func CreatePost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	repos := store.Get()
+	user, ok := auth.Authorize(w, r, rbac.PostCreate)
+	if !ok {
+		return
+	}
+	userId, userName := user.ID, user.UserName
+	if !auth.MayPost(repos.Users(), userId) {
+		http.Error(w, "ERROR: Please verify your email address first", http.StatusForbidden)
+		return
+	}
+	// Shadow banned users aren't told; only they will see what they post.
+	shadow, err := auth.ShadowBanned(repos.Bans(), userId)
+	if err != nil {
+		http.Error(w, "ERROR: Database error", http.StatusInternalServerError)
+		return
+	}
+
+	tagSlugs := GetTagSlugs(r)
+	for _, slug := range tagSlugs {
+		if _, err := repos.Tags().BySlug(slug); err != nil {
+			http.Error(w, "ERROR: Unknown tag "+slug, http.StatusBadRequest)
+			return
+		}
+	}
+
 	var PhotoPath string
-	file, handler, err := r.FormFile("photo")
+	file, _, err := r.FormFile("photo")
 	if err == nil {
 		defer file.Close()
-
-		// Dosya türünü kontrol et
-		fileExt := strings.ToLower(filepath.Ext(handler.Filename))
-		if fileExt != ".jpg" && fileExt != ".jpeg" && fileExt != ".png" && fileExt != ".gif" {
+		PhotoPath, err = photo.Save(file)
+		if errors.Is(err, photo.ErrUnsupported) {
 			http.Error(w, "ERROR: Unsupported file type. Only JPEG, PNG, and GIF are allowed.", http.StatusBadRequest)
 			return
 		}
-
-		// Fotoğrafı uploads dizinine kaydet
-		uploadDir := "./uploads"
-		if _, err := os.Stat(uploadDir); os.IsNotExist(err) {
-			err = os.MkdirAll(uploadDir, os.ModePerm)
-			if err != nil {
-				http.Error(w, "Unable to create upload directory", http.StatusInternalServerError)
-				return
-			}
-		}
-
-		tempFile, err := os.Create(fmt.Sprintf("%s/%s", uploadDir, handler.Filename))
 		if err != nil {
 			http.Error(w, "Unable to save file", http.StatusInternalServerError)
 			return
 		}
-		defer tempFile.Close()
-		_, err = io.Copy(tempFile, file)
-		if err != nil {
-			http.Error(w, "Unable to copy file content", http.StatusInternalServerError)
-			return
-		}
-		PhotoPath = fmt.Sprintf("/uploads/%s", handler.Filename)
-	}
-
-	db, errDb := database.OpenDb(w)
-	if errDb != nil {
-		http.Error(w, "ERROR: Database cannot open", http.StatusBadRequest)
-		return
-	}
-	defer db.Close()
-	authenticated, userId, userName := auth.IsAuthenticated(r, db)
-	if !authenticated {
-		http.Error(w, "ERROR: You are not authorized to create post", http.StatusUnauthorized)
-		return
 	}
 
-	result, errEx := db.Exec(`INSERT INTO POSTS (UserID, UserName, Title, Content, PhotoPath) VALUES (?, ?, ?, ?, ?)`, userId, userName, title, content, PhotoPath)
+	postID, errEx := repos.Posts().Create(structs.Post{UserID: userId, UserName: userName, Title: title, Content: content, ContentHTML: markdown.Render(content),
+		PhotoPath: PhotoPath, Shadow: shadow, Question: r.FormValue("question") == "true"})
 	if errEx != nil {
+		purge.RemovePhotos([]string{PhotoPath})
 		http.Error(w, "ERROR: Post did not add to the database", http.StatusBadRequest)
 		return
 	}
 
-	postID, err := result.LastInsertId()
-	if err != nil {
-		http.Error(w, "ERROR: Could not retrieve post ID", http.StatusBadRequest)
-		return
-	}
-
-	categoryValues := GetCategoryValues(r)
-	_, err = db.Exec(`INSERT INTO CATEGORIES (USERID, PostID, GO, HTML, CSS, PHP, PYTHON, C, "CPP", "CSHARP", JS, ASSEMBLY, REACT, FLUTTER, RUST) 
-        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-		userId, postID, categoryValues["go"], categoryValues["html"], categoryValues["css"], categoryValues["php"],
-		categoryValues["python"], categoryValues["c"], categoryValues["cpp"], categoryValues["csharp"],
-		categoryValues["js"], categoryValues["assembly"], categoryValues["react"], categoryValues["flutter"], categoryValues["rust"])
+	err = repos.Tags().SetForPost(postID, tagSlugs)
 	if err != nil {
-		http.Error(w, "ERROR: Could not add categories to the database", http.StatusBadRequest)
+		http.Error(w, "ERROR: Could not add tags to the database", http.StatusBadRequest)
 		return
 	}
 
@@ -111,16 +97,18 @@ func IsForumPostValid(title, content string) bool {
 	return title != "" && content != ""
 }
 
-func GetCategoryValues(r *http.Request) map[string]int {
-	categories := []string{"go", "html", "css", "php", "python", "c", "cpp", "csharp", "js", "assembly", "react", "flutter", "rust"}
-	categoryValues := make(map[string]int)
+// GetTagSlugs returns the distinct tag slugs submitted in the "tags" field.
+func GetTagSlugs(r *http.Request) []string {
+	var slugs []string
+	seen := make(map[string]bool)
 
-	for _, category := range categories {
-		if r.FormValue(category) == "true" {
-			categoryValues[category] = 1
-		} else {
-			categoryValues[category] = 0
+	for _, slug := range r.Form["tags"] {
+		slug = strings.ToLower(strings.TrimSpace(slug))
+		if slug == "" || seen[slug] {
+			continue
 		}
+		seen[slug] = true
+		slugs = append(slugs, slug)
 	}
-	return categoryValues
+	return slugs
 }
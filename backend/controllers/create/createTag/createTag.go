@@ -0,0 +1,78 @@
+package createtag
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"forum/backend/auth"
+	"forum/backend/controllers/structs"
+	"forum/backend/rbac"
+	"forum/backend/store"
+)
+
+const defaultColor = "#006989"
+
+var (
+	slugPattern  = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+	colorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+)
+
+func CreateTag(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	repos := store.Get()
+
+	user, ok := auth.Authorize(w, r, rbac.TagCreate)
+	if !ok {
+		return
+	}
+
+	tag := structs.Tag{
+		Slug:        strings.ToLower(strings.TrimSpace(r.FormValue("slug"))),
+		Name:        strings.TrimSpace(r.FormValue("name")),
+		Description: strings.TrimSpace(r.FormValue("description")),
+		Color:       strings.TrimSpace(r.FormValue("color")),
+	}
+	if tag.Color == "" {
+		tag.Color = defaultColor
+	}
+
+	if !IsTagValid(tag) {
+		http.Error(w, "ERROR: Tag needs a name, a slug of lowercase letters, digits and dashes, and a #rrggbb color", http.StatusBadRequest)
+		return
+	}
+
+	_, err := repos.Tags().BySlug(tag.Slug)
+	if err == nil {
+		http.Error(w, "ERROR: Tag already exists", http.StatusConflict)
+		return
+	}
+	if !errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "ERROR: Query execution failed", http.StatusInternalServerError)
+		return
+	}
+
+	_, err = repos.Tags().Create(tag, user.ID)
+	if err != nil {
+		http.Error(w, "ERROR: Tag did not add to the database", http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "Tag successfully created")
+}
+
+func IsTagValid(tag structs.Tag) bool {
+	return slugPattern.MatchString(tag.Slug) && AreTagDetailsValid(tag)
+}
+
+// AreTagDetailsValid checks the parts of a tag that can be changed later.
+func AreTagDetailsValid(tag structs.Tag) bool {
+	return tag.Name != "" && len(tag.Name) <= 64 && colorPattern.MatchString(tag.Color)
+}
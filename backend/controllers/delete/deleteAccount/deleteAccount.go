@@ -2,11 +2,14 @@ package deleteaccount
 
 import (
 	"fmt"
+	"log"
 	"net/http"
 
 	"forum/backend/auth"
+	"forum/backend/avatar"
 	"forum/backend/controllers/login"
-	"forum/backend/database"
+	"forum/backend/purge"
+	"forum/backend/store"
 )
 
 func DeleteAccount(w http.ResponseWriter, r *http.Request) {
@@ -15,14 +18,9 @@ func DeleteAccount(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, errDb := database.OpenDb(w)
-	if errDb != nil {
-		http.Error(w, "ERROR: Database cannot open", http.StatusBadRequest)
-		return
-	}
-	defer db.Close()
+	repos := store.Get()
 
-	authenticated, userId, _ := auth.IsAuthenticated(r, db)
+	authenticated, userId, _ := auth.IsAuthenticated(r, repos.Sessions())
 	if !authenticated {
 		http.Error(w, "ERROR: You are not authorized to delete account", http.StatusUnauthorized)
 		return
@@ -30,49 +28,41 @@ func DeleteAccount(w http.ResponseWriter, r *http.Request) {
 
 	password := r.FormValue("password")
 
-	var storedPassword string
-	err := db.QueryRow("SELECT Password FROM USERS WHERE ID = ?", userId).Scan(&storedPassword)
+	user, err := repos.Users().ByID(userId)
 	if err != nil {
 		http.Error(w, "ERROR: Invalid query", http.StatusBadRequest)
 		return
 	}
 
-	errComparePasswd := login.ArePasswordsMatching(storedPassword, password)
-	if errComparePasswd != nil {
-		http.Error(w, "ERROR: Invalid password", http.StatusBadRequest)
-		return
-	}
-
-	_, err = db.Exec("DELETE FROM USERLIKES WHERE UserID = ?", userId)
-	if err != nil {
-		http.Error(w, "Database error", http.StatusInternalServerError)
-		return
+	// Accounts created through an OAuth provider have no password; their
+	// owners confirm by typing the user name instead.
+	if user.Password == "" {
+		if r.FormValue("confirm") != user.UserName {
+			http.Error(w, "ERROR: Please type your user name to confirm", http.StatusBadRequest)
+			return
+		}
+	} else {
+		errComparePasswd := login.ArePasswordsMatching(user.Password, password)
+		if errComparePasswd != nil {
+			http.Error(w, "ERROR: Invalid password", http.StatusBadRequest)
+			return
+		}
 	}
 
-	_, err = db.Exec("DELETE FROM COMMENTS WHERE UserID = ?", userId)
+	profile, err := repos.Users().ProfileByID(userId)
 	if err != nil {
-		http.Error(w, "Database error", http.StatusInternalServerError)
-		return
-	}
-
-	// Kullanıcının oluşturduğu tüm gönderileri sil
-	_, err = db.Exec("DELETE FROM POSTS WHERE UserID = ?", userId)
-	if err != nil {
-		http.Error(w, "Database error", http.StatusInternalServerError)
+		http.Error(w, "ERROR: Invalid query", http.StatusBadRequest)
 		return
 	}
 
-	// Kullanıcıyı veritabanından sil
-	_, err = db.Exec("DELETE FROM USERS WHERE ID = ?", userId)
+	photos, err := repos.Users().Delete(userId)
 	if err != nil {
 		http.Error(w, "Database error", http.StatusInternalServerError)
 		return
 	}
-
-	_, err = db.Exec("DELETE FROM CATEGORIES WHERE USERID = ?", userId)
-	if err != nil {
-		http.Error(w, "Database error", http.StatusInternalServerError)
-		return
+	purge.RemovePhotos(photos)
+	if err := avatar.Remove(profile.Avatar); err != nil {
+		log.Printf("Failed to remove avatar %s: %v", profile.Avatar, err)
 	}
 
 	auth.RemoveCookie(w, r, "session_token")
@@ -4,10 +4,11 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"forum/backend/auth"
-	deletepost "forum/backend/controllers/delete/deletePost"
-	"forum/backend/database"
+	"forum/backend/rbac"
+	"forum/backend/store"
 )
 
 func DeleteComment(w http.ResponseWriter, r *http.Request) {
@@ -23,39 +24,27 @@ func DeleteComment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, errDb := database.OpenDb(w)
-	if errDb != nil {
-		http.Error(w, "ERROR: Database cannot open", http.StatusBadRequest)
-		return
-	}
-	defer db.Close()
+	repos := store.Get()
 
-	authenticated, userId, userName := auth.IsAuthenticated(r, db)
-	if !authenticated {
-		http.Error(w, "ERROR: You are not authorized to create post", http.StatusUnauthorized)
+	user, ok := auth.Authorize(w, r, rbac.CommentDeleteOwn)
+	if !ok {
 		return
 	}
 
-	var comUserId int
-	var comUserName string
-
-	err := db.QueryRow("SELECT UserId, UserName FROM COMMENTS WHERE ID = ?", commentIdInt).Scan(&comUserId, &comUserName)
+	comUserId, _, err := repos.Comments().Owner(commentIdInt)
 	if err != nil {
 		http.Error(w, "ERROR: Comment cannot found in the database", http.StatusBadRequest)
 		return
 	}
 
-	if deletepost.AreTheCredentialsMatch(comUserId, userId, comUserName, userName) {
-		_, errComDel := db.Exec(`DELETE FROM COMMENTS WHERE ID = ?`, commentIdInt)
-		if errComDel != nil {
-			http.Error(w, "ERROR: Unable to delete comment", http.StatusInternalServerError)
-			return
-		}
-		_, errUserLikeDel := db.Exec(`DELETE FROM USERLIKES WHERE PostID = ? AND IsComment = 1`, commentIdInt)
-		if errUserLikeDel != nil {
-			http.Error(w, "ERROR: Unable to delete user likes", http.StatusInternalServerError)
-			return
-		}
+	if !auth.AuthorizeOwner(w, user, comUserId, rbac.CommentDeleteOwn, rbac.CommentDeleteAny) {
+		return
+	}
+
+	errComDel := repos.Comments().Delete(commentIdInt, user.ID, time.Now())
+	if errComDel != nil {
+		http.Error(w, "ERROR: Unable to delete comment", http.StatusInternalServerError)
+		return
 	}
 
 	w.WriteHeader(http.StatusOK)
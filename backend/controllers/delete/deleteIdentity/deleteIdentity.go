@@ -0,0 +1,51 @@
+package deleteidentity
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"forum/backend/auth"
+	"forum/backend/store"
+)
+
+// DeleteIdentity unlinks a provider account from the user, unless it is the
+// only way left to log in.
+func DeleteIdentity(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	identityId, atoiErr := strconv.Atoi(r.FormValue("id"))
+	if atoiErr != nil {
+		http.Error(w, "ERROR: Invalid identity ID format", http.StatusBadRequest)
+		return
+	}
+
+	repos := store.Get()
+
+	authenticated, userId, _ := auth.IsAuthenticated(r, repos.Sessions())
+	if !authenticated {
+		http.Error(w, "ERROR: You are not authorized to unlink logins", http.StatusUnauthorized)
+		return
+	}
+
+	err := repos.Identities().Unlink(userId, identityId)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "ERROR: Login not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, store.ErrLastLoginMethod) {
+		http.Error(w, "ERROR: Set a password or link another login first", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "ERROR: Unable to unlink login", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "Login successfully unlinked")
+}
@@ -0,0 +1,51 @@
+package deletesession
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"forum/backend/auth"
+	"forum/backend/store"
+)
+
+// DeleteSession revokes one of the user's sessions, for example a phone that
+// was lost. Revoking the current session logs this browser out too.
+func DeleteSession(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sessionId, atoiErr := strconv.Atoi(r.FormValue("id"))
+	if atoiErr != nil {
+		http.Error(w, "ERROR: Invalid session ID format", http.StatusBadRequest)
+		return
+	}
+
+	repos := store.Get()
+
+	current, _, err := auth.CurrentSession(r, repos.Sessions())
+	if err != nil {
+		http.Error(w, "ERROR: You are not authorized to revoke sessions", http.StatusUnauthorized)
+		return
+	}
+
+	err = repos.Sessions().Delete(sessionId, current.UserID)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "ERROR: Session not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "ERROR: Unable to revoke session", http.StatusInternalServerError)
+		return
+	}
+
+	if sessionId == current.ID {
+		auth.RemoveCookie(w, r, auth.SessionCookieName)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "Session successfully revoked")
+}
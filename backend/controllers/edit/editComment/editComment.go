@@ -0,0 +1,60 @@
+package editcomment
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"forum/backend/auth"
+	"forum/backend/markdown"
+	"forum/backend/rbac"
+	"forum/backend/store"
+)
+
+// EditComment replaces the comment's text; the old text is kept as a
+// revision.
+func EditComment(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	commentIdInt, atoiErr := strconv.Atoi(r.FormValue("id"))
+	if atoiErr != nil {
+		http.Error(w, "ERROR: Invalid comment ID format", http.StatusBadRequest)
+		return
+	}
+
+	comment := r.FormValue("comment")
+	if comment == "" {
+		http.Error(w, "ERROR: Comment cannot be empty", http.StatusBadRequest)
+		return
+	}
+
+	repos := store.Get()
+
+	user, ok := auth.Authorize(w, r, rbac.CommentEditOwn)
+	if !ok {
+		return
+	}
+
+	comUserId, _, err := repos.Comments().Owner(commentIdInt)
+	if err != nil {
+		http.Error(w, "ERROR: Comment cannot found in the database", http.StatusBadRequest)
+		return
+	}
+
+	if !auth.AuthorizeOwner(w, user, comUserId, rbac.CommentEditOwn, rbac.CommentEditAny) {
+		return
+	}
+
+	err = repos.Comments().Edit(commentIdInt, comment, markdown.Render(comment), user.ID, time.Now())
+	if err != nil {
+		http.Error(w, "ERROR: Unable to edit comment", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "Comment successfully edited")
+}
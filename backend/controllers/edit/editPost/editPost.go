@@ -0,0 +1,69 @@
+package editpost
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"forum/backend/auth"
+	createpost "forum/backend/controllers/create/createPost"
+	"forum/backend/controllers/structs"
+	"forum/backend/markdown"
+	"forum/backend/rbac"
+	"forum/backend/store"
+)
+
+// EditPost replaces the post's title, content and tags with the submitted
+// ones. The old version is kept as a revision.
+func EditPost(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	postIdInt, atoiErr := strconv.Atoi(r.FormValue("id"))
+	if atoiErr != nil {
+		http.Error(w, "ERROR: Invalid post ID format", http.StatusBadRequest)
+		return
+	}
+
+	title := r.FormValue("title")
+	content := r.FormValue("content")
+	if !createpost.IsForumPostValid(title, content) {
+		http.Error(w, "ERROR: Content or title cannot be empty", http.StatusBadRequest)
+		return
+	}
+
+	repos := store.Get()
+
+	user, ok := auth.Authorize(w, r, rbac.PostEditOwn)
+	if !ok {
+		return
+	}
+
+	postUserID, _, err := repos.Posts().Owner(postIdInt)
+	if err != nil {
+		http.Error(w, "ERROR: Cannot find post from database", http.StatusBadRequest)
+		return
+	}
+
+	if !auth.AuthorizeOwner(w, user, postUserID, rbac.PostEditOwn, rbac.PostEditAny) {
+		return
+	}
+
+	post := structs.Post{ID: postIdInt, Title: title, Content: content, ContentHTML: markdown.Render(content)}
+	err = repos.Posts().Edit(post, createpost.GetTagSlugs(r), user.ID, time.Now())
+	if errors.Is(err, store.ErrUnknownTag) {
+		http.Error(w, "ERROR: Unknown tag", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "ERROR: Unable to edit post", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "Post successfully edited")
+}
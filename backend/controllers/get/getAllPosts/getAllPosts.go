@@ -4,8 +4,10 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"forum/backend/auth"
 	"forum/backend/controllers/structs"
-	"forum/backend/database"
+	"forum/backend/pagination"
+	"forum/backend/store"
 )
 
 func GetAllPosts(w http.ResponseWriter, r *http.Request) {
@@ -14,40 +16,22 @@ func GetAllPosts(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, errDb := database.OpenDb(w)
-	if errDb != nil {
-		http.Error(w, "ERROR: Database cannot open", http.StatusBadRequest)
-		return
-	}
-	defer db.Close()
-
-	rows, err := db.Query("SELECT ID, UserID, UserName, Title, Content, LikeCount FROM POSTS ORDER BY PostDate desc")
+	page, err := pagination.FromRequest(r)
 	if err != nil {
-		http.Error(w, "ERROR: Query execution failed", http.StatusInternalServerError)
+		http.Error(w, "ERROR: Invalid cursor", http.StatusBadRequest)
 		return
 	}
-	defer rows.Close()
 
-	var posts []structs.Post
+	repos := store.Get()
 
-	for rows.Next() {
-		var post structs.Post
-		err := rows.Scan(&post.ID, &post.UserID, &post.UserName, &post.Title, &post.Content, &post.LikeCount)
-		if err != nil {
-			http.Error(w, "ERROR: Database scan error", http.StatusBadRequest)
-			return
-		}
-		posts = append(posts, post)
-	}
-
-	err = rows.Err()
+	posts, more, err := repos.Posts().All(auth.Audience(r), page)
 	if err != nil {
-		http.Error(w, "ERROR: Row iteration error", http.StatusBadRequest)
+		http.Error(w, "ERROR: Query execution failed", http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	err = json.NewEncoder(w).Encode(posts)
+	err = json.NewEncoder(w).Encode(structs.PostList{Posts: posts, Cursors: pagination.Keyset(page, pagination.PostIDs(posts), more)})
 	if err != nil {
 		http.Error(w, "ERROR: Failed to encode posts to JSON", http.StatusInternalServerError)
 		return
@@ -0,0 +1,51 @@
+package getloginmethods
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"forum/backend/auth"
+	"forum/backend/controllers/structs"
+	"forum/backend/store"
+)
+
+// GetLoginMethods tells whether the user has a password and which provider
+// accounts they can log in with.
+func GetLoginMethods(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	repos := store.Get()
+
+	authenticated, userId, _ := auth.IsAuthenticated(r, repos.Sessions())
+	if !authenticated {
+		http.Error(w, "ERROR: You are not authorized to see login methods", http.StatusUnauthorized)
+		return
+	}
+
+	user, err := repos.Users().ByID(userId)
+	if err != nil {
+		http.Error(w, "ERROR: Query error", http.StatusInternalServerError)
+		return
+	}
+
+	identities, err := repos.Identities().ByUser(userId)
+	if err != nil {
+		http.Error(w, "ERROR: Query error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(structs.LoginMethods{
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		HasPassword:   user.Password != "",
+		Identities:    identities,
+	})
+	if err != nil {
+		http.Error(w, "ERROR: Failed to encode login methods to JSON", http.StatusInternalServerError)
+		return
+	}
+}
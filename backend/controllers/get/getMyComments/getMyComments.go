@@ -6,7 +6,8 @@ import (
 
 	"forum/backend/auth"
 	"forum/backend/controllers/structs"
-	"forum/backend/database"
+	"forum/backend/pagination"
+	"forum/backend/store"
 )
 
 func GetMyComments(w http.ResponseWriter, r *http.Request) {
@@ -15,45 +16,28 @@ func GetMyComments(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, errDb := database.OpenDb(w)
-	if errDb != nil {
-		http.Error(w, "ERROR: Database cannot open", http.StatusBadRequest)
-		return
-	}
-	defer db.Close()
+	repos := store.Get()
 
-	authenticated, userId, _ := auth.IsAuthenticated(r, db)
+	authenticated, userId, _ := auth.IsAuthenticated(r, repos.Sessions())
 	if !authenticated {
 		http.Error(w, "ERROR: You are not authorized to create post", http.StatusUnauthorized)
 		return
 	}
 
-	rows, err := db.Query("SELECT ID, PostId, UserId, Comment, UserName, LikeCount FROM COMMENTS WHERE UserId = ? ORDER BY created_at desc", userId)
+	page, err := pagination.FromRequest(r)
 	if err != nil {
-		http.Error(w, "ERROR: Query error", http.StatusBadRequest)
+		http.Error(w, "ERROR: Invalid cursor", http.StatusBadRequest)
 		return
 	}
-	defer rows.Close()
-
-	var comments []structs.Comment
-	for rows.Next() {
-		var comment structs.Comment
-		err := rows.Scan(&comment.ID, &comment.PostId, &comment.UserId, &comment.Comment, &comment.UserName, &comment.LikeCount)
-		if err != nil {
-			http.Error(w, "ERROR: Database scan error", http.StatusBadRequest)
-			return
-		}
-		comments = append(comments, comment)
-	}
 
-	err = rows.Err()
+	comments, more, err := repos.Comments().ByUser(userId, page)
 	if err != nil {
-		http.Error(w, "ERROR: Row iteration error", http.StatusBadRequest)
+		http.Error(w, "ERROR: Query error", http.StatusBadRequest)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	err = json.NewEncoder(w).Encode(comments)
+	err = json.NewEncoder(w).Encode(structs.CommentList{Comments: comments, Cursors: pagination.Keyset(page, pagination.CommentIDs(comments), more)})
 	if err != nil {
 		http.Error(w, "ERROR: Failed to encode posts to JSON", http.StatusInternalServerError)
 		return
@@ -6,7 +6,8 @@ import (
 
 	"forum/backend/auth"
 	"forum/backend/controllers/structs"
-	"forum/backend/database"
+	"forum/backend/pagination"
+	"forum/backend/store"
 )
 
 func GetMyPosts(w http.ResponseWriter, r *http.Request) {
@@ -15,45 +16,28 @@ func GetMyPosts(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, errDb := database.OpenDb(w)
-	if errDb != nil {
-		http.Error(w, "ERROR: Database cannot open", http.StatusBadRequest)
-		return
-	}
-	defer db.Close()
+	repos := store.Get()
 
-	authenticated, userId, _ := auth.IsAuthenticated(r, db)
+	authenticated, userId, _ := auth.IsAuthenticated(r, repos.Sessions())
 	if !authenticated {
 		http.Error(w, "ERROR: You are not authorized to get posts for my posts", http.StatusUnauthorized)
 		return
 	}
 
-	rows, err := db.Query("SELECT ID, UserId, UserName, Title, Content, UserName, LikeCount FROM POSTS WHERE UserID = ? ORDER BY PostDate desc", userId)
+	page, err := pagination.FromRequest(r)
 	if err != nil {
-		http.Error(w, "ERROR: Query error", http.StatusBadRequest)
+		http.Error(w, "ERROR: Invalid cursor", http.StatusBadRequest)
 		return
 	}
-	defer rows.Close()
-
-	var posts []structs.Post
-	for rows.Next() {
-		var post structs.Post
-		err := rows.Scan(&post.ID, &post.UserID, &post.UserName, &post.Title, &post.Content, &post.UserName, &post.LikeCount)
-		if err != nil {
-			http.Error(w, "ERROR: Database scan error", http.StatusBadRequest)
-			return
-		}
-		posts = append(posts, post)
-	}
 
-	err = rows.Err()
+	posts, more, err := repos.Posts().ByUser(userId, page)
 	if err != nil {
-		http.Error(w, "ERROR: Row iteration error", http.StatusBadRequest)
+		http.Error(w, "ERROR: Query error", http.StatusBadRequest)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	err = json.NewEncoder(w).Encode(posts)
+	err = json.NewEncoder(w).Encode(structs.PostList{Posts: posts, Cursors: pagination.Keyset(page, pagination.PostIDs(posts), more)})
 	if err != nil {
 		http.Error(w, "ERROR: Failed to encode posts to JSON", http.StatusInternalServerError)
 		return
@@ -2,25 +2,27 @@ package getpostandcomments
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
+	"forum/backend/auth"
 	"forum/backend/controllers/structs"
-	"forum/backend/database"
+	"forum/backend/pagination"
+	"forum/backend/store"
+	"forum/backend/threads"
 )
 
+// GetPostAndComments returns the post with a page of its top-level comments
+// and their replies. ?sort= is old, new or top and ?depth= limits how deep
+// the replies go.
 func GetPostAndComments(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
 		return
 	}
 
-	db, errDb := database.OpenDb(w)
-	if errDb != nil {
-		http.Error(w, "ERROR: Database cannot open", http.StatusBadRequest)
-		return
-	}
-	defer db.Close()
+	repos := store.Get()
 
 	postId := r.FormValue("id")
 
@@ -30,42 +32,73 @@ func GetPostAndComments(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var post structs.Post
-
-	err = db.QueryRow("SELECT ID, UserID, UserName, Title, Content, LikeCount, PhotoPath FROM POSTS WHERE ID = ?", postIdInt).Scan(&post.ID, &post.UserID, &post.UserName, &post.Title, &post.Content, &post.LikeCount, &post.PhotoPath)
+	page, err := pagination.FromRequest(r)
 	if err != nil {
-		http.Error(w, "ERROR: Query execution failed", http.StatusInternalServerError)
+		http.Error(w, "ERROR: Invalid cursor", http.StatusBadRequest)
 		return
 	}
 
-	commentRows, err := db.Query("SELECT ID, PostId, UserId, Comment, UserName, LikeCount FROM COMMENTS WHERE PostID = ?", postIdInt)
+	post, err := repos.Posts().ByID(postIdInt)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "ERROR: Post not found", http.StatusNotFound)
+		return
+	}
 	if err != nil {
-		http.Error(w, "ERROR: Query error for comments", http.StatusBadRequest)
+		http.Error(w, "ERROR: Query execution failed", http.StatusInternalServerError)
 		return
 	}
-	defer commentRows.Close()
 
-	var comments []structs.Comment
+	audience := auth.Audience(r)
+	if post.Shadow && post.UserID != audience.UserID {
+		http.Error(w, "ERROR: Post not found", http.StatusNotFound)
+		return
+	}
+	// The comments of a deleted post stay readable under a placeholder.
+	if !post.DeletedAt.IsZero() {
+		post = post.Placeholder()
+	}
+	if post.Hidden {
+		http.Error(w, "ERROR: This post is hidden until a moderator has reviewed it", http.StatusNotFound)
+		return
+	}
 
-	for commentRows.Next() {
-		var comment structs.Comment
-		err := commentRows.Scan(&comment.ID, &comment.PostId, &comment.UserId, &comment.Comment, &comment.UserName, &comment.LikeCount)
+	if post.DeletedAt.IsZero() {
+		post.Tags, err = repos.Tags().ForPost(postIdInt)
 		if err != nil {
-			http.Error(w, "ERROR: Database scan error", http.StatusBadRequest)
+			http.Error(w, "ERROR: Query error for tags", http.StatusInternalServerError)
 			return
 		}
-		comments = append(comments, comment)
 	}
 
-	err = commentRows.Err()
+	sort := store.ParseCommentSort(r.FormValue("sort"))
+	comments, more, err := repos.Comments().ByPost(postIdInt, audience, sort, page)
 	if err != nil {
-		http.Error(w, "ERROR: Comment Row iteration error", http.StatusBadRequest)
+		http.Error(w, "ERROR: Query error for comments", http.StatusBadRequest)
 		return
 	}
 
+	err = threads.Load(repos.Comments(), comments, audience, sort, threads.Depth(r.FormValue("depth")))
+	if err != nil {
+		http.Error(w, "ERROR: Query error for replies", http.StatusInternalServerError)
+		return
+	}
+
+	accepted, err := acceptedAnswer(repos, post, audience)
+	if err != nil {
+		http.Error(w, "ERROR: Query error for the accepted answer", http.StatusInternalServerError)
+		return
+	}
+
+	cursors := pagination.Keyset(page, pagination.CommentIDs(comments), more)
+	if sort == store.SortTop {
+		cursors = pagination.Offset(page, len(comments), more)
+	}
+
 	data := structs.PostWithComments{
 		Post:     post,
 		Comments: comments,
+		Accepted: accepted,
+		Cursors:  cursors,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -75,3 +108,22 @@ func GetPostAndComments(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 }
+
+// acceptedAnswer is the accepted answer of a question, if it has one the
+// audience can see.
+func acceptedAnswer(repos store.Store, post structs.Post, audience store.Audience) (*structs.Comment, error) {
+	if !post.Question || post.AcceptedID == 0 {
+		return nil, nil
+	}
+	comment, err := repos.Comments().ByID(post.AcceptedID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !comment.DeletedAt.IsZero() || comment.Hidden || (comment.Shadow && comment.UserId != audience.UserID) {
+		return nil, nil
+	}
+	return &comment, nil
+}
@@ -0,0 +1,74 @@
+package getprofile
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"forum/backend/auth"
+	"forum/backend/controllers/structs"
+	"forum/backend/store"
+)
+
+// How much of the user's activity a profile shows.
+const (
+	recentItems = 5
+	topTags     = 10
+)
+
+// GetProfile returns the public profile of the user with ?username=, or
+// of the one logged in without it, with their latest posts and comments
+// and the tags they are most active in.
+func GetProfile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	repos := store.Get()
+	audience := auth.Audience(r)
+
+	var profile structs.Profile
+	var err error
+	if userName := r.FormValue("username"); userName != "" {
+		profile, err = repos.Users().Profile(userName)
+	} else if audience.UserID != 0 {
+		profile, err = repos.Users().ProfileByID(audience.UserID)
+	} else {
+		http.Error(w, "ERROR: You are not logged in", http.StatusUnauthorized)
+		return
+	}
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "ERROR: User not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "ERROR: Query error", http.StatusInternalServerError)
+		return
+	}
+
+	data := structs.UserProfile{Profile: profile}
+	recent := store.Page{Limit: recentItems}
+	data.Posts, _, err = repos.Posts().ByAuthor(profile.ID, audience, recent)
+	if err != nil {
+		http.Error(w, "ERROR: Query error", http.StatusInternalServerError)
+		return
+	}
+	data.Comments, _, err = repos.Comments().ByAuthor(profile.ID, audience, recent)
+	if err != nil {
+		http.Error(w, "ERROR: Query error", http.StatusInternalServerError)
+		return
+	}
+	data.Tags, err = repos.Tags().Activity(profile.ID, audience, topTags)
+	if err != nil {
+		http.Error(w, "ERROR: Query error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(data)
+	if err != nil {
+		http.Error(w, "ERROR: Failed to encode profile to JSON", http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,72 @@
+package getreplies
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"forum/backend/auth"
+	"forum/backend/controllers/structs"
+	"forum/backend/store"
+	"forum/backend/threads"
+)
+
+// GetReplies returns a comment with the replies under it, for following a
+// thread deeper than the post page goes. It takes ?sort= and ?depth= like
+// GetPostAndComments.
+func GetReplies(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	commentIdInt, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		http.Error(w, "ERROR: Invalid comment ID", http.StatusBadRequest)
+		return
+	}
+
+	repos := store.Get()
+	audience := auth.Audience(r)
+
+	comment, err := repos.Comments().ByID(commentIdInt)
+	if err == nil && !canSee(audience, comment.UserId, comment.Hidden, comment.Shadow) {
+		err = store.ErrNotFound
+	}
+	if err == nil {
+		var post structs.Post
+		post, err = repos.Posts().ByID(comment.PostId)
+		if err == nil && !canSee(audience, post.UserID, post.Hidden, post.Shadow) {
+			err = store.ErrNotFound
+		}
+	}
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "ERROR: Comment not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "ERROR: Query execution failed", http.StatusInternalServerError)
+		return
+	}
+
+	thread := []structs.Comment{comment}
+	err = threads.Load(repos.Comments(), thread, audience, store.ParseCommentSort(r.FormValue("sort")), threads.Depth(r.FormValue("depth")))
+	if err != nil {
+		http.Error(w, "ERROR: Query error for replies", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(thread[0])
+	if err != nil {
+		http.Error(w, "ERROR: Failed to encode replies to JSON", http.StatusInternalServerError)
+		return
+	}
+}
+
+// canSee mirrors what the post page shows: nothing hidden, and shadowed
+// content only to its author.
+func canSee(audience store.Audience, authorID int, hidden, shadow bool) bool {
+	return !hidden && (!shadow || authorID == audience.UserID)
+}
@@ -0,0 +1,71 @@
+package getreputation
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"forum/backend/auth"
+	"forum/backend/controllers/structs"
+	"forum/backend/pagination"
+	"forum/backend/store"
+)
+
+// GetReputation returns a user's reputation and a page of the events it
+// is made of: the user with ?id=, otherwise the one logged in.
+func GetReputation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	repos := store.Get()
+
+	var userId int
+	if id := r.FormValue("id"); id != "" {
+		var err error
+		userId, err = strconv.Atoi(id)
+		if err != nil {
+			http.Error(w, "ERROR: Invalid user id", http.StatusBadRequest)
+			return
+		}
+	} else {
+		var authenticated bool
+		authenticated, userId, _ = auth.IsAuthenticated(r, repos.Sessions())
+		if !authenticated {
+			http.Error(w, "ERROR: You are not logged in", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	page, err := pagination.FromRequest(r)
+	if err != nil {
+		http.Error(w, "ERROR: Invalid cursor", http.StatusBadRequest)
+		return
+	}
+
+	user, err := repos.Users().ByID(userId)
+	if err != nil {
+		http.Error(w, "ERROR: User not found", http.StatusNotFound)
+		return
+	}
+
+	events, more, err := repos.Reputation().History(userId, page)
+	if err != nil {
+		http.Error(w, "ERROR: Query error", http.StatusInternalServerError)
+		return
+	}
+
+	data := structs.ReputationHistory{
+		Reputation: user.Reputation,
+		Events:     events,
+		Cursors:    pagination.Keyset(page, pagination.EventIDs(events), more),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(data)
+	if err != nil {
+		http.Error(w, "ERROR: Failed to encode reputation to JSON", http.StatusInternalServerError)
+		return
+	}
+}
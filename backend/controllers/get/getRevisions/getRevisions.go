@@ -0,0 +1,87 @@
+package getrevisions
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"forum/backend/auth"
+	"forum/backend/controllers/structs"
+	"forum/backend/store"
+)
+
+// GetRevisions lists every version of a post, or of a comment with
+// ?comment=true, to whoever can see it now.
+func GetRevisions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	idInt, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		http.Error(w, "ERROR: Invalid ID", http.StatusBadRequest)
+		return
+	}
+	isComment := r.FormValue("comment") == "true"
+
+	repos := store.Get()
+	audience := auth.Audience(r)
+
+	postId := idInt
+	if isComment {
+		comment, err := repos.Comments().ByID(idInt)
+		if err == nil && (!comment.DeletedAt.IsZero() || !canSee(audience, comment.UserId, comment.Hidden, comment.Shadow)) {
+			err = store.ErrNotFound
+		}
+		if errors.Is(err, store.ErrNotFound) {
+			http.Error(w, "ERROR: Comment not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, "ERROR: Query execution failed", http.StatusInternalServerError)
+			return
+		}
+		postId = comment.PostId
+	}
+
+	post, err := repos.Posts().ByID(postId)
+	if err == nil && (!post.DeletedAt.IsZero() || !canSee(audience, post.UserID, post.Hidden, post.Shadow)) {
+		err = store.ErrNotFound
+	}
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "ERROR: Post not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "ERROR: Query execution failed", http.StatusInternalServerError)
+		return
+	}
+
+	versions, err := repos.Revisions().History(idInt, isComment)
+	if err != nil {
+		http.Error(w, "ERROR: Query error for revisions", http.StatusInternalServerError)
+		return
+	}
+
+	data := structs.RevisionHistory{
+		TargetID:  idInt,
+		IsComment: isComment,
+		PostID:    postId,
+		Versions:  versions,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(data)
+	if err != nil {
+		http.Error(w, "ERROR: Failed to encode revisions to JSON", http.StatusInternalServerError)
+		return
+	}
+}
+
+// canSee mirrors what the post page shows: nothing hidden, and shadowed
+// content only to its author.
+func canSee(audience store.Audience, authorID int, hidden, shadow bool) bool {
+	return !hidden && (!shadow || authorID == audience.UserID)
+}
@@ -0,0 +1,41 @@
+package getsessions
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"forum/backend/auth"
+	"forum/backend/store"
+)
+
+func GetSessions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	repos := store.Get()
+
+	current, _, err := auth.CurrentSession(r, repos.Sessions())
+	if err != nil {
+		http.Error(w, "ERROR: You are not authorized to see sessions", http.StatusUnauthorized)
+		return
+	}
+
+	sessions, err := repos.Sessions().ByUser(current.UserID, time.Now())
+	if err != nil {
+		http.Error(w, "ERROR: Query error", http.StatusInternalServerError)
+		return
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == current.ID
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(sessions)
+	if err != nil {
+		http.Error(w, "ERROR: Failed to encode sessions to JSON", http.StatusInternalServerError)
+		return
+	}
+}
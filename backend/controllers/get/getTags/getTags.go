@@ -0,0 +1,30 @@
+package gettags
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"forum/backend/store"
+)
+
+func GetTags(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	repos := store.Get()
+
+	tags, err := repos.Tags().All()
+	if err != nil {
+		http.Error(w, "ERROR: Query execution failed", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(tags)
+	if err != nil {
+		http.Error(w, "ERROR: Failed to encode tags to JSON", http.StatusInternalServerError)
+		return
+	}
+}
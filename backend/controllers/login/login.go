@@ -1,20 +1,20 @@
 package login
 
 import (
-	"bufio"
-	"database/sql"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"net/url"
-	"os"
-	"strings"
+	"strconv"
 	"time"
 
-	"forum/backend/database"
+	"forum/backend/auth"
+	"forum/backend/controllers/structs"
+	"forum/backend/oauth"
+	"forum/backend/store"
+	"forum/backend/tokens"
 
-	"github.com/gofrs/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -32,527 +32,369 @@ func Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, errDb := database.OpenDb(w)
-	if errDb != nil {
-		http.Error(w, "ERROR: Database cannot open", http.StatusBadRequest)
+	if throttled(w, r, email) {
 		return
 	}
-	defer db.Close()
 
-	var storedPassword string
-	var userID int
-	err := db.QueryRow("SELECT ID, Password FROM USERS WHERE Email = ?", email).Scan(&userID, &storedPassword)
+	repos := store.Get()
+
+	user, err := repos.Users().ByEmail(email)
 	if err != nil {
-		http.Error(w, "ERROR: Invalid email", http.StatusBadRequest)
+		// Take as long as a real password check would.
+		ArePasswordsMatching(string(dummyHash), password)
+		loginFailed(w, r, email, structs.User{}, auth.ReasonUnknownEmail)
 		return
 	}
 
-	errComparePasswd := ArePasswordsMatching(storedPassword, password)
-	if errComparePasswd != nil {
-		http.Error(w, "ERROR: Invalid password", http.StatusBadRequest)
+	if locked(w, r, user) {
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	fmt.Fprintf(w, "User successfully logged in")
-}
-
-func ArePasswordsMatching(storedPassword, password string) error {
-	err := bcrypt.CompareHashAndPassword([]byte(storedPassword), []byte(password))
-	if err != nil {
-		return err
+	errComparePasswd := ArePasswordsMatching(user.Password, password)
+	if errComparePasswd != nil {
+		loginFailed(w, r, email, user, auth.ReasonBadPassword)
+		return
 	}
-	return nil
-}
-
-func AreLoginCredentialsCorrect(email, password string) bool {
-	return email != "" && password != ""
-}
 
-// GitHub and Google login
-
-var (
-	googleClientID       string
-	googleClientSecret   string
-	githubClientID       string
-	githubClientSecret   string
-	facebookClientID     string
-	facebookClientSecret string
-)
+	if banned(w, r, user) {
+		return
+	}
 
-func LoadEnv() {
-	file, err := os.Open("backend/.env")
+	twoFactor, err := auth.HasTwoFactor(repos.TwoFactor(), user.ID)
 	if err != nil {
-		log.Fatalf("Error opening .env file: %v", err)
+		http.Error(w, "ERROR: Internal Server Error", http.StatusInternalServerError)
+		return
 	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
-		if len(line) == 0 || line[0] == '#' {
-			continue
-		}
-		parts := strings.SplitN(line, "=", 2)
-		if len(parts) != 2 {
-			continue
-		}
-		key := parts[0]
-		value := parts[1]
-		os.Setenv(key, value)
+	if twoFactor {
+		// The body is the challenge to pass to LoginTwoFactor with a code.
+		w.WriteHeader(http.StatusAccepted)
+		fmt.Fprint(w, tokens.Sign(tokens.PurposeLoginChallenge, user.ID, user.Password, challengeTTL))
+		return
 	}
 
-	if err := scanner.Err(); err != nil {
-		log.Fatalf("Error reading .env file: %v", err)
+	if err := auth.RecordLoginSuccess(email); err != nil {
+		log.Printf("Failed to clear login failures: %v", err)
 	}
 
-	googleClientID = os.Getenv("GOOGLE_CLIENT_ID")
-	googleClientSecret = os.Getenv("GOOGLE_CLIENT_SECRET")
-	githubClientID = os.Getenv("GITHUB_CLIENT_ID")
-	githubClientSecret = os.Getenv("GITHUB_CLIENT_SECRET")
-	facebookClientID = os.Getenv("FACEBOOK_CLIENT_ID")
-	facebookClientSecret = os.Getenv("FACEBOOK_CLIENT_SECRET")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "User successfully logged in")
 }
 
-func HandleGoogleLogin(w http.ResponseWriter, r *http.Request) {
-	url := "https://accounts.google.com/o/oauth2/auth?client_id=" + googleClientID +
-		"&redirect_uri=http://localhost:8080/callback/google" +
-		"&scope=https://www.googleapis.com/auth/userinfo.profile https://www.googleapis.com/auth/userinfo.email&prompt=select_account" +
-		"&response_type=code" +
-		"&state=random-string"
-	http.Redirect(w, r, url, http.StatusTemporaryRedirect)
-}
+// challengeTTL is how long the user has to enter their code after the
+// password.
+const challengeTTL = 5 * time.Minute
 
-func HandleGoogleCallback(w http.ResponseWriter, r *http.Request) {
-	state := r.URL.Query().Get("state")
-	if state != "random-string" {
-		http.Error(w, "Invalid state", http.StatusBadRequest)
-		return
-	}
-
-	code := r.URL.Query().Get("code")
-	if code == "" {
-		http.Error(w, "No code in response", http.StatusBadRequest)
+// LoginTwoFactor is the second step of logging in, for users with two-factor
+// authentication on. It takes the challenge Login answered with (or a
+// provider login redirected with) and a code from the authenticator app or a
+// recovery code.
+func LoginTwoFactor(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
 		return
 	}
 
-	token, err := ExchangeGoogleCodeForToken(code)
-	if err != nil {
-		http.Error(w, "Failed to exchange code for token: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
+	challenge := r.FormValue("challenge")
 
-	userInfo, err := GetGoogleUserInfo(token)
+	userId, err := tokens.UserID(challenge)
 	if err != nil {
-		http.Error(w, "Failed to get user info: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, "ERROR: Login expired, please start again", http.StatusBadRequest)
 		return
 	}
 
-	email, emailOk := userInfo["email"].(string)
-	name, nameOk := userInfo["name"].(string)
+	repos := store.Get()
 
-	if !emailOk || !nameOk || email == "" {
-		log.Printf("Google user info is missing required fields: %+v", userInfo)
-		http.Error(w, "Failed to get valid user info", http.StatusInternalServerError)
+	// Bound to the password hash, so a challenge dies with a password change.
+	user, err := repos.Users().ByID(userId)
+	if err != nil || tokens.Verify(challenge, tokens.PurposeLoginChallenge, user.Password) != nil {
+		http.Error(w, "ERROR: Login expired, please start again", http.StatusBadRequest)
 		return
 	}
 
-	db, errDb := database.OpenDb(w)
-	if errDb != nil {
-		http.Error(w, "ERROR: Database cannot open", http.StatusBadRequest)
+	if throttled(w, r, user.Email) || locked(w, r, user) || banned(w, r, user) {
 		return
 	}
-	defer db.Close()
 
-	// Kullanıcıyı veritabanında kontrol et
-	var userID int
-	err = db.QueryRow("SELECT ID FROM USERS WHERE Email = ?", email).Scan(&userID)
-	if err == sql.ErrNoRows {
-		// Kullanıcı mevcut değilse, yeni kullanıcı oluştur
-		_, err = db.Exec("INSERT INTO USERS (Email, UserName, Password) VALUES (?, ?, ?)", email, name, "")
-		if err != nil {
-			http.Error(w, "Failed to save user: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-		_ = db.QueryRow("SELECT ID FROM USERS WHERE Email = ?", email).Scan(&userID)
-	} else if err != nil {
-		http.Error(w, "Database error: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	// Kullanıcıyı giriş yapmış olarak işaretleyin
-	sessionToken, err := uuid.NewV4()
+	valid, err := auth.CheckSecondFactor(repos.TwoFactor(), userId, r.FormValue("code"))
 	if err != nil {
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		http.Error(w, "ERROR: Internal Server Error", http.StatusInternalServerError)
 		return
 	}
-
-	http.SetCookie(w, &http.Cookie{
-		Name:     "session_token",
-		Value:    sessionToken.String(),
-		Expires:  time.Now().Add(24 * time.Hour),
-		HttpOnly: true,
-		Path:     "/",
-	})
-
-	_, err = db.Exec("UPDATE USERS SET session_token = ? WHERE ID = ?", sessionToken.String(), userID)
-	if err != nil {
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	if !valid {
+		if err := auth.RecordLoginFailure(r, user.Email, user, auth.ReasonBadCode); err != nil {
+			log.Printf("Failed to record login failure: %v", err)
+		}
+		http.Error(w, "ERROR: Invalid code", http.StatusBadRequest)
 		return
 	}
 
-	http.Redirect(w, r, "/", http.StatusSeeOther)
-}
+	if err := auth.RecordLoginSuccess(user.Email); err != nil {
+		log.Printf("Failed to clear login failures: %v", err)
+	}
 
-func HandleGitHubLogin(w http.ResponseWriter, r *http.Request) {
-	url := "https://github.com/login/oauth/authorize?client_id=" + githubClientID +
-		"&redirect_uri=http://localhost:8080/callback/github" +
-		"&scope=read:user user:email&prompt=select_account" +
-		"&state=random-string"
-	http.Redirect(w, r, url, http.StatusTemporaryRedirect)
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "User successfully logged in")
 }
 
-func HandleGitHubCallback(w http.ResponseWriter, r *http.Request) {
-	state := r.URL.Query().Get("state")
-	if state != "random-string" {
-		http.Error(w, "Invalid state", http.StatusBadRequest)
-		return
-	}
-
-	code := r.URL.Query().Get("code")
-	if code == "" {
-		http.Error(w, "No code in response", http.StatusBadRequest)
-		return
-	}
+// dummyHash is checked against when the email is unknown, so that the
+// response time doesn't tell whether an account exists.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("not a password"), bcrypt.DefaultCost)
 
-	token, err := ExchangeGitHubCodeForToken(code)
+// throttled answers 429 if the IP or account has failed too often lately.
+func throttled(w http.ResponseWriter, r *http.Request, email string) bool {
+	wait, err := auth.LoginRetryAfter(r, email)
 	if err != nil {
-		log.Printf("Failed to exchange code for token: %v", err)
-		http.Error(w, "Failed to exchange code for token: "+err.Error(), http.StatusInternalServerError)
-		return
+		http.Error(w, "ERROR: Internal Server Error", http.StatusInternalServerError)
+		return true
 	}
-
-	userInfo, err := GetGitHubUserInfo(token)
-	if err != nil {
-		log.Printf("Failed to get user info: %v", err)
-		http.Error(w, "Failed to get user info: "+err.Error(), http.StatusInternalServerError)
-		return
+	if wait == 0 {
+		return false
 	}
 
-	email, emailOk := userInfo["email"].(string)
-	if !emailOk || email == "" {
-		// Email doğrudan alınamadıysa, ek endpoint'ten email bilgilerini al
-		emails, err := GetGitHubUserEmails(token)
-		if err != nil || len(emails) == 0 {
-			log.Printf("Failed to get valid user info: %v", err)
-			http.Error(w, "Failed to get valid user info", http.StatusInternalServerError)
-			return
-		}
-		email = emails[0]
+	if err := auth.RecordLoginFailure(r, email, structs.User{}, auth.ReasonThrottled); err != nil {
+		log.Printf("Failed to record login failure: %v", err)
 	}
+	tooManyAttempts(w, wait)
+	return true
+}
 
-	username, usernameOk := userInfo["login"].(string)
-	if !usernameOk || username == "" {
-		http.Error(w, "Failed to get valid user info", http.StatusInternalServerError)
-		return
+// locked answers like throttled if the account is locked.
+func locked(w http.ResponseWriter, r *http.Request, user structs.User) bool {
+	now := time.Now().UTC()
+	if !user.Locked(now) {
+		return false
 	}
 
-	db, errDb := database.OpenDb(w)
-	if errDb != nil {
-		http.Error(w, "ERROR: Database cannot open", http.StatusBadRequest)
-		return
+	if err := auth.RecordLoginFailure(r, user.Email, user, auth.ReasonLocked); err != nil {
+		log.Printf("Failed to record login failure: %v", err)
 	}
-	defer db.Close()
+	tooManyAttempts(w, user.LockedUntil.Sub(now))
+	return true
+}
 
-	// Kullanıcıyı veritabanında kontrol et
-	var userID int
-	err = db.QueryRow("SELECT ID FROM USERS WHERE Email = ?", email).Scan(&userID)
-	if err == sql.ErrNoRows {
-		// Kullanıcı mevcut değilse, yeni kullanıcı oluştur
-		_, err = db.Exec("INSERT INTO USERS (Email, UserName, Password) VALUES (?, ?, ?)", email, username, "")
-		if err != nil {
-			log.Printf("Failed to save user: %v", err)
-			http.Error(w, "Failed to save user: "+err.Error(), http.StatusInternalServerError)
-			return
+// banned answers 403 if the user is banned or suspended, or logs in from a
+// banned address. It is only asked once the password is right, so it
+// doesn't tell others who is banned.
+func banned(w http.ResponseWriter, r *http.Request, user structs.User) bool {
+	repos := store.Get()
+	ban, found, err := auth.ActiveBan(repos.Bans(), user.ID)
+	if err != nil {
+		http.Error(w, "ERROR: Internal Server Error", http.StatusInternalServerError)
+		return true
+	}
+	if found && !ban.Shadow {
+		if ban.ExpiresAt.IsZero() {
+			http.Error(w, "ERROR: This account has been banned", http.StatusForbidden)
+		} else {
+			http.Error(w, "ERROR: This account is suspended until "+ban.ExpiresAt.UTC().Format("2006-01-02 15:04 MST"), http.StatusForbidden)
 		}
-		_ = db.QueryRow("SELECT ID FROM USERS WHERE Email = ?", email).Scan(&userID)
-	} else if err != nil {
-		log.Printf("Database error: %v", err)
-		http.Error(w, "Database error: "+err.Error(), http.StatusInternalServerError)
-		return
+		return true
 	}
 
-	// Kullanıcıyı giriş yapmış olarak işaretleyin
-	sessionToken, err := uuid.NewV4()
+	_, found, err = auth.AddressBanned(repos.AddressBans(), auth.ClientIP(r), user.Email)
 	if err != nil {
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-		return
+		http.Error(w, "ERROR: Internal Server Error", http.StatusInternalServerError)
+		return true
 	}
-
-	http.SetCookie(w, &http.Cookie{
-		Name:     "session_token",
-		Value:    sessionToken.String(),
-		Expires:  time.Now().Add(24 * time.Hour),
-		HttpOnly: true,
-		Path:     "/",
-	})
-
-	_, err = db.Exec("UPDATE USERS SET session_token = ? WHERE ID = ?", sessionToken.String(), userID)
-	if err != nil {
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-		return
+	if found {
+		http.Error(w, "ERROR: Logins from this address or email domain are blocked", http.StatusForbidden)
 	}
+	return found
+}
 
-	http.Redirect(w, r, "/", http.StatusSeeOther)
+// tooManyAttempts is the answer to throttled logins and locked accounts
+// alike. Unknown emails are throttled like real ones, so it doesn't reveal
+// whether an account exists.
+func tooManyAttempts(w http.ResponseWriter, wait time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+	http.Error(w, "ERROR: Too many failed attempts, please try again later", http.StatusTooManyRequests)
 }
 
-func ExchangeGoogleCodeForToken(code string) (string, error) {
-	data := url.Values{}
-	data.Set("code", code)
-	data.Set("client_id", googleClientID)
-	data.Set("client_secret", googleClientSecret)
-	data.Set("redirect_uri", "http://localhost:8080/callback/google")
-	data.Set("grant_type", "authorization_code")
+// loginFailed gives the same answer to a wrong email as to a wrong
+// password, so logging in can't be used to find out who has an account.
+func loginFailed(w http.ResponseWriter, r *http.Request, email string, user structs.User, reason string) {
+	if err := auth.RecordLoginFailure(r, email, user, reason); err != nil {
+		log.Printf("Failed to record login failure: %v", err)
+	}
+	http.Error(w, "ERROR: Invalid email or password", http.StatusBadRequest)
+}
 
-	resp, err := http.PostForm("https://oauth2.googleapis.com/token", data)
+func ArePasswordsMatching(storedPassword, password string) error {
+	err := bcrypt.CompareHashAndPassword([]byte(storedPassword), []byte(password))
 	if err != nil {
-		return "", err
+		return err
 	}
-	defer resp.Body.Close()
-
-	var result map[string]interface{}
-	json.NewDecoder(resp.Body).Decode(&result)
+	return nil
+}
 
-	if token, ok := result["access_token"].(string); ok {
-		return token, nil
-	}
-	return "", fmt.Errorf("no access token in response")
+func AreLoginCredentialsCorrect(email, password string) bool {
+	return email != "" && password != ""
 }
 
-func GetGoogleUserInfo(token string) (map[string]interface{}, error) {
-	req, err := http.NewRequest("GET", "https://www.googleapis.com/oauth2/v2/userinfo", nil)
-	if err != nil {
-		return nil, err
+// HandleProviderLogin sends the browser to the provider named in the path.
+func HandleProviderLogin(w http.ResponseWriter, r *http.Request) {
+	provider, ok := oauth.Get(r.PathValue("provider"))
+	if !ok {
+		http.NotFound(w, r)
+		return
 	}
-	req.Header.Set("Authorization", "Bearer "+token)
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	authURL, err := oauth.Begin(w, store.Get().OAuthStates(), provider, 0)
 	if err != nil {
-		return nil, err
+		log.Printf("Failed to start %s login: %v", provider.Name(), err)
+		http.Error(w, "ERROR: Unable to start login", http.StatusBadGateway)
+		return
 	}
-	defer resp.Body.Close()
 
-	var userInfo map[string]interface{}
-	json.NewDecoder(resp.Body).Decode(&userInfo)
-	return userInfo, nil
+	http.Redirect(w, r, authURL, http.StatusTemporaryRedirect)
 }
 
-func ExchangeGitHubCodeForToken(code string) (string, error) {
-	data := url.Values{}
-	data.Set("client_id", githubClientID)
-	data.Set("client_secret", githubClientSecret)
-	data.Set("code", code)
-	data.Set("redirect_uri", "http://localhost:8080/callback/github")
-
-	req, err := http.NewRequest("POST", "https://github.com/login/oauth/access_token", nil)
-	if err != nil {
-		return "", err
+// HandleProviderLink sends the logged in user to the provider to add it as
+// another way of logging in to their account.
+func HandleProviderLink(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
 	}
-	req.URL.RawQuery = data.Encode()
-	req.Header.Set("Accept", "application/json")
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", err
+	provider, ok := oauth.Get(r.PathValue("provider"))
+	if !ok {
+		http.NotFound(w, r)
+		return
 	}
-	defer resp.Body.Close()
 
-	var result map[string]interface{}
-	json.NewDecoder(resp.Body).Decode(&result)
+	repos := store.Get()
 
-	if token, ok := result["access_token"].(string); ok {
-		return token, nil
-	}
-	return "", fmt.Errorf("no access token in response")
-}
-
-func GetGitHubUserInfo(token string) (map[string]interface{}, error) {
-	req, err := http.NewRequest("GET", "https://api.github.com/user", nil)
-	if err != nil {
-		return nil, err
+	authenticated, userId, _ := auth.IsAuthenticated(r, repos.Sessions())
+	if !authenticated {
+		http.Error(w, "ERROR: You are not logged in", http.StatusUnauthorized)
+		return
 	}
-	req.Header.Set("Authorization", "Bearer "+token)
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	authURL, err := oauth.Begin(w, repos.OAuthStates(), provider, userId)
 	if err != nil {
-		return nil, err
+		log.Printf("Failed to start %s link: %v", provider.Name(), err)
+		http.Error(w, "ERROR: Unable to start login", http.StatusBadGateway)
+		return
 	}
-	defer resp.Body.Close()
 
-	var userInfo map[string]interface{}
-	json.NewDecoder(resp.Body).Decode(&userInfo)
-	return userInfo, nil
+	http.Redirect(w, r, authURL, http.StatusSeeOther)
 }
 
-func GetGitHubUserEmails(token string) ([]string, error) {
-	req, err := http.NewRequest("GET", "https://api.github.com/user/emails", nil)
-	if err != nil {
-		return nil, err
+func HandleProviderCallback(w http.ResponseWriter, r *http.Request) {
+	provider, ok := oauth.Get(r.PathValue("provider"))
+	if !ok {
+		http.NotFound(w, r)
+		return
 	}
-	req.Header.Set("Authorization", "Bearer "+token)
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+	repos := store.Get()
 
-	var emails []struct {
-		Email      string `json:"email"`
-		Primary    bool   `json:"primary"`
-		Verified   bool   `json:"verified"`
-		Visibility string `json:"visibility"`
+	identity, linkUserID, err := oauth.Complete(w, r, repos.OAuthStates(), provider)
+	if errors.Is(err, oauth.ErrInvalidState) {
+		http.Error(w, "ERROR: Invalid state", http.StatusBadRequest)
+		return
 	}
-	err = json.NewDecoder(resp.Body).Decode(&emails)
 	if err != nil {
-		return nil, err
-	}
-
-	var result []string
-	for _, e := range emails {
-		if e.Verified {
-			result = append(result, e.Email)
-		}
+		log.Printf("%s login failed: %v", provider.Name(), err)
+		http.Error(w, "ERROR: Login failed: "+err.Error(), http.StatusBadGateway)
+		return
 	}
-	return result, nil
-}
 
-func HandleFacebookLogin(w http.ResponseWriter, r *http.Request) {
-	url := "https://www.facebook.com/v12.0/dialog/oauth?client_id=" + facebookClientID +
-		"&redirect_uri=http://localhost:8080/callback/facebook" +
-		"&scope=email&state=random-string"
-	http.Redirect(w, r, url, http.StatusTemporaryRedirect)
-}
+	linked := structs.Identity{Provider: identity.Provider, Subject: identity.Subject, Email: identity.Email}
 
-func HandleFacebookCallback(w http.ResponseWriter, r *http.Request) {
-	state := r.URL.Query().Get("state")
-	if state != "random-string" {
-		http.Error(w, "Invalid state", http.StatusBadRequest)
+	if linkUserID != 0 {
+		linkIdentity(w, r, linked, linkUserID)
 		return
 	}
 
-	code := r.URL.Query().Get("code")
-	if code == "" {
-		http.Error(w, "No code in response", http.StatusBadRequest)
+	// Provider accounts seen for the first time are matched to users by
+	// email, so an address the provider hasn't verified could be used to
+	// take over someone else's account. One linked already, perhaps from
+	// the settings page without a verified email, is known by its ID.
+	_, err = repos.Identities().UserID(identity.Provider, identity.Subject)
+	if errors.Is(err, store.ErrNotFound) && (identity.Email == "" || !identity.EmailVerified) {
+		http.Error(w, "ERROR: "+oauth.ErrEmailNotVerified.Error(), http.StatusForbidden)
 		return
 	}
-
-	token, err := ExchangeFacebookCodeForToken(code)
-	if err != nil {
-		http.Error(w, "Failed to exchange code for token: "+err.Error(), http.StatusInternalServerError)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 		return
 	}
 
-	userInfo, err := GetFacebookUserInfo(token)
+	// Checked before the account is created, as registration would be.
+	_, addressBanned, err := auth.AddressBanned(repos.AddressBans(), auth.ClientIP(r), identity.Email)
 	if err != nil {
-		http.Error(w, "Failed to get user info: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	if addressBanned {
+		http.Error(w, "ERROR: Logins from this address or email domain are blocked", http.StatusForbidden)
 		return
 	}
 
-	email, emailOk := userInfo["email"].(string)
-	name, nameOk := userInfo["name"].(string)
-
-	if !emailOk || !nameOk || email == "" {
-		log.Printf("Facebook user info is missing required fields: %+v", userInfo)
-		http.Error(w, "Failed to get valid user info", http.StatusInternalServerError)
+	userID, err := repos.Users().FindOrCreateByIdentity(linked, identity.Name)
+	if err != nil {
+		http.Error(w, "Failed to save user: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	db, errDb := database.OpenDb(w)
-	if errDb != nil {
-		http.Error(w, "ERROR: Database cannot open", http.StatusBadRequest)
+	user, err := repos.Users().ByID(userID)
+	if err != nil {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 		return
 	}
-	defer db.Close()
 
-	var userID int
-	err = db.QueryRow("SELECT ID FROM USERS WHERE Email = ?", email).Scan(&userID)
-	if err == sql.ErrNoRows {
-		_, err = db.Exec("INSERT INTO USERS (Email, UserName, Password) VALUES (?, ?, ?)", email, name, "")
-		if err != nil {
-			http.Error(w, "Failed to save user: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-		_ = db.QueryRow("SELECT ID FROM USERS WHERE Email = ?", email).Scan(&userID)
-	} else if err != nil {
-		http.Error(w, "Database error: "+err.Error(), http.StatusInternalServerError)
+	if banned(w, r, user) {
 		return
 	}
 
-	sessionToken, err := uuid.NewV4()
+	// A provider login doesn't skip the user's second factor.
+	twoFactor, err := auth.HasTwoFactor(repos.TwoFactor(), userID)
 	if err != nil {
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 		return
 	}
+	if twoFactor {
+		challenge := tokens.Sign(tokens.PurposeLoginChallenge, userID, user.Password, challengeTTL)
+		http.Redirect(w, r, "/twofactor?challenge="+url.QueryEscape(challenge), http.StatusSeeOther)
+		return
+	}
 
-	http.SetCookie(w, &http.Cookie{
-		Name:     "session_token",
-		Value:    sessionToken.String(),
-		Expires:  time.Now().Add(24 * time.Hour),
-		HttpOnly: true,
-		Path:     "/",
-	})
-
-	_, err = db.Exec("UPDATE USERS SET session_token = ? WHERE ID = ?", sessionToken.String(), userID)
+	err = auth.StartSession(w, r, repos.Sessions(), userID)
 	if err != nil {
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 		return
 	}
 
+	if !auth.TwoFactorSatisfied(repos.TwoFactor(), user) {
+		http.Redirect(w, r, "/settings/twofactor", http.StatusSeeOther)
+		return
+	}
+
 	http.Redirect(w, r, "/", http.StatusSeeOther)
 }
 
-func ExchangeFacebookCodeForToken(code string) (string, error) {
-	data := url.Values{}
-	data.Set("client_id", facebookClientID)
-	data.Set("client_secret", facebookClientSecret)
-	data.Set("redirect_uri", "http://localhost:8080/callback/facebook")
-	data.Set("code", code)
-
-	resp, err := http.PostForm("https://graph.facebook.com/v12.0/oauth/access_token", data)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	var result map[string]interface{}
-	json.NewDecoder(resp.Body).Decode(&result)
+func linkIdentity(w http.ResponseWriter, r *http.Request, identity structs.Identity, linkUserID int) {
+	repos := store.Get()
 
-	if token, ok := result["access_token"].(string); ok {
-		return token, nil
+	// The browser must still be logged in as the user who started linking.
+	authenticated, userId, _ := auth.IsAuthenticated(r, repos.Sessions())
+	if !authenticated || userId != linkUserID {
+		http.Error(w, "ERROR: You are not logged in", http.StatusUnauthorized)
+		return
 	}
-	return "", fmt.Errorf("no access token in response")
-}
 
-func GetFacebookUserInfo(token string) (map[string]interface{}, error) {
-	req, err := http.NewRequest("GET", "https://graph.facebook.com/me?fields=email,name", nil)
-	if err != nil {
-		return nil, err
+	identity.UserID = userId
+	err := repos.Identities().Link(identity)
+	if errors.Is(err, store.ErrIdentityTaken) {
+		http.Error(w, "ERROR: "+err.Error(), http.StatusConflict)
+		return
 	}
-	req.Header.Set("Authorization", "Bearer "+token)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
 	if err != nil {
-		return nil, err
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
 	}
-	defer resp.Body.Close()
 
-	var userInfo map[string]interface{}
-	json.NewDecoder(resp.Body).Decode(&userInfo)
-	return userInfo, nil
+	http.Redirect(w, r, "/settings", http.StatusSeeOther)
 }
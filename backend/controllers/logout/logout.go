@@ -5,7 +5,7 @@ import (
 	"net/http"
 
 	"forum/backend/auth"
-	"forum/backend/database"
+	"forum/backend/store"
 )
 
 func Logout(w http.ResponseWriter, r *http.Request) {
@@ -20,14 +20,9 @@ func Logout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, errDb := database.OpenDb(w)
-	if errDb != nil {
-		http.Error(w, "ERROR: Database cannot open", http.StatusBadRequest)
-		return
-	}
-	defer db.Close()
+	repos := store.Get()
 
-	_, err = db.Exec("UPDATE USERS SET session_token = '' WHERE session_token = ?", cookie.Value)
+	err = repos.Sessions().DeleteByTokenHash(auth.HashToken(cookie.Value))
 	if err != nil {
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 		return
@@ -38,3 +33,30 @@ func Logout(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	fmt.Fprintf(w, "User successfully logged out")
 }
+
+// LogoutEverywhere ends every session of the user, on all devices.
+func LogoutEverywhere(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	repos := store.Get()
+
+	authenticated, userId, _ := auth.IsAuthenticated(r, repos.Sessions())
+	if !authenticated {
+		http.Error(w, "ERROR: You are not logged in", http.StatusUnauthorized)
+		return
+	}
+
+	err := repos.Sessions().DeleteByUser(userId)
+	if err != nil {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	auth.RemoveCookie(w, r, auth.SessionCookieName)
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "User successfully logged out everywhere")
+}
@@ -0,0 +1,105 @@
+package passwordreset
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"forum/backend/auth"
+	"forum/backend/controllers/register"
+	"forum/backend/mail"
+	"forum/backend/store"
+	"forum/backend/tokens"
+)
+
+// RequestPasswordReset emails a reset link if an account uses the address.
+// It answers the same either way, so it can't be used to find out who is
+// registered. Each IP and address may only ask a few times an hour.
+func RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	email := r.FormValue("email")
+	if email == "" {
+		http.Error(w, "ERROR: Please provide an email", http.StatusBadRequest)
+		return
+	}
+
+	wait, err := auth.AllowPasswordReset(r, email)
+	if err != nil {
+		http.Error(w, "ERROR: Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+		http.Error(w, "ERROR: Too many reset requests, please try again later", http.StatusTooManyRequests)
+		return
+	}
+
+	user, err := store.Get().Users().ByEmail(email)
+	if err == nil {
+		if err := mail.SendPasswordReset(user); err != nil {
+			log.Printf("Failed to send password reset email to user %d: %v", user.ID, err)
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "If an account uses this email, a reset link is on its way")
+}
+
+// ResetPassword sets a new password with the token from the reset email and
+// logs the user out everywhere.
+func ResetPassword(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	token := r.FormValue("token")
+	password := r.FormValue("password")
+	if password == "" {
+		http.Error(w, "ERROR: Please provide a new password", http.StatusBadRequest)
+		return
+	}
+
+	userId, err := tokens.UserID(token)
+	if err != nil {
+		http.Error(w, "ERROR: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	repos := store.Get()
+
+	user, err := repos.Users().ByID(userId)
+	if err != nil || tokens.Verify(token, tokens.PurposeResetPassword, user.Password) != nil {
+		http.Error(w, "ERROR: "+tokens.ErrInvalid.Error(), http.StatusBadRequest)
+		return
+	}
+
+	hashedPasswd, errHash := register.HashThePasswd(password)
+	if errHash != nil {
+		http.Error(w, "ERROR: Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	err = repos.Users().SetPassword(userId, hashedPasswd)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	// Whoever had the password before shouldn't stay logged in, and the
+	// link proved the user reads this inbox.
+	if err := repos.Sessions().DeleteByUser(userId); err != nil {
+		log.Printf("Failed to end sessions of user %d: %v", userId, err)
+	}
+	if err := repos.Users().SetEmailVerified(userId); err != nil {
+		log.Printf("Failed to mark email of user %d verified: %v", userId, err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "Password successfully reset")
+}
@@ -0,0 +1,33 @@
+package preview
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"forum/backend/auth"
+	"forum/backend/controllers/structs"
+	"forum/backend/markdown"
+	"forum/backend/store"
+)
+
+// Preview renders text the way a post or comment with it would show,
+// without saving anything.
+func Preview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	authenticated, _, _ := auth.IsAuthenticated(r, store.Get().Sessions())
+	if !authenticated {
+		http.Error(w, "ERROR: You are not authorized to preview", http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(structs.Preview{HTML: markdown.Render(r.FormValue("text"))})
+	if err != nil {
+		http.Error(w, "ERROR: Failed to encode preview to JSON", http.StatusInternalServerError)
+		return
+	}
+}
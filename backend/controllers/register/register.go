@@ -2,9 +2,15 @@ package register
 
 import (
 	"fmt"
+	"log"
 	"net/http"
+	netmail "net/mail"
 
-	"forum/backend/database"
+	"forum/backend/auth"
+	"forum/backend/controllers/structs"
+	"forum/backend/mail"
+	"forum/backend/rbac"
+	"forum/backend/store"
 
 	"golang.org/x/crypto/bcrypt"
 )
@@ -30,37 +36,87 @@ func Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, errDb := database.OpenDb(w)
-	if errDb != nil {
-		http.Error(w, "ERROR: Database cannot open", http.StatusBadRequest)
+	repos := store.Get()
+
+	_, addressBanned, err := auth.AddressBanned(repos.AddressBans(), auth.ClientIP(r), email)
+	if err != nil {
+		http.Error(w, "ERROR: Internal server error", http.StatusInternalServerError)
 		return
 	}
-	defer db.Close()
-
-	var userID int
-	errMail := db.QueryRow("SELECT ID FROM USERS WHERE Email = ?", email).Scan(&userID)
-	if errMail == nil {
-		http.Error(w, "ERROR: Email already taken", http.StatusBadRequest)
+	if addressBanned {
+		http.Error(w, "ERROR: Registrations from this address or email domain are blocked", http.StatusForbidden)
 		return
 	}
 
-	errUsername := db.QueryRow("SELECT ID FROM USERS WHERE UserName = ?", username).Scan(&userID)
-	if errUsername == nil {
+	usernameTaken, errUsername := repos.Users().UserNameTaken(username)
+	if errUsername != nil || usernameTaken {
 		http.Error(w, "ERROR: Username already taken", http.StatusBadRequest)
 		return
 	}
-	_, err := db.Exec("INSERT INTO USERS (Email, UserName, Password, Role) VALUES (?, ?, ?, ?)", email, username, hashedPasswd, "User")
+
+	emailTaken, errMail := repos.Users().EmailTaken(email)
+	if errMail != nil {
+		http.Error(w, "ERROR: Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if emailTaken {
+		notifyOwner(r, email)
+		registered(w)
+		return
+	}
+
+	user := structs.User{Email: email, UserName: username, Password: hashedPasswd, Role: string(rbac.User)}
+	id, err := repos.Users().Create(user)
 	if err != nil {
 		http.Error(w, "ERROR: Bad Request", http.StatusBadRequest)
 		return
 	}
 
+	// The account works without it; the user can ask for another link.
+	user.ID = id
+	if err := mail.SendVerification(user); err != nil {
+		log.Printf("Failed to send verification email to user %d: %v", id, err)
+	}
+
+	registered(w)
+}
+
+// registered is the answer to a registration whether or not the email was
+// free, so registering can't be used to find out who has an account.
+func registered(w http.ResponseWriter) {
 	w.WriteHeader(http.StatusOK)
-	fmt.Fprintf(w, "User successfully created")
+	fmt.Fprintf(w, "Registration received, please check your email")
+}
+
+// notifyOwner emails the owner of a taken address instead of telling the
+// client. The mails count as password resets, so they can't be used to flood
+// the inbox.
+func notifyOwner(r *http.Request, email string) {
+	wait, err := auth.AllowPasswordReset(r, email)
+	if err != nil {
+		log.Printf("Failed to count password reset: %v", err)
+		return
+	}
+	if wait > 0 {
+		return
+	}
+
+	user, err := store.Get().Users().ByEmail(email)
+	if err != nil {
+		log.Printf("Failed to look up account for registration notice: %v", err)
+		return
+	}
+	if err := mail.SendAccountExists(user); err != nil {
+		log.Printf("Failed to send account notice to user %d: %v", user.ID, err)
+	}
 }
 
 func AreRegisterCredentialsCorrect(email, username, password string) bool {
-	return email != "" && username != "" && password != ""
+	if email == "" || username == "" || password == "" {
+		return false
+	}
+	addr, err := netmail.ParseAddress(email)
+	return err == nil && addr.Address == email
 }
 
 func HashThePasswd(password string) (string, error) {
@@ -0,0 +1,75 @@
+package report
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"forum/backend/auth"
+	"forum/backend/controllers/structs"
+	"forum/backend/moderation"
+	"forum/backend/rbac"
+	"forum/backend/store"
+)
+
+const maxDetailsLength = 1000
+
+// Report flags a post or comment for the moderators, with one of
+// moderation.Reasons and optional details.
+func Report(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	idInt, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		http.Error(w, "ERROR: Invalid ID", http.StatusBadRequest)
+		return
+	}
+	isComment := r.FormValue("isComment") == "true"
+	reason := r.FormValue("reason")
+	details := strings.TrimSpace(r.FormValue("details"))
+
+	if !moderation.ValidReason(reason) {
+		http.Error(w, "ERROR: Choose one of the reasons: "+strings.Join(moderation.Reasons, ", "), http.StatusBadRequest)
+		return
+	}
+	if utf8.RuneCountInString(details) > maxDetailsLength {
+		http.Error(w, fmt.Sprintf("ERROR: Details can be at most %d characters", maxDetailsLength), http.StatusBadRequest)
+		return
+	}
+
+	user, ok := auth.Authorize(w, r, rbac.ContentReport)
+	if !ok {
+		return
+	}
+
+	err = moderation.Report(store.Get(), structs.Report{
+		ReporterID: user.ID,
+		TargetID:   idInt,
+		IsComment:  isComment,
+		Reason:     reason,
+		Details:    details,
+	})
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		http.Error(w, "ERROR: Post or comment not found", http.StatusNotFound)
+		return
+	case errors.Is(err, moderation.ErrOwnContent):
+		http.Error(w, "ERROR: You can't report your own post or comment", http.StatusBadRequest)
+		return
+	case errors.Is(err, store.ErrAlreadyReported):
+		http.Error(w, "ERROR: You have already reported this", http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, "ERROR: Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "Report successfully sent")
+}
@@ -1,5 +1,7 @@
 package structs
 
+import "time"
+
 type Post struct {
 	ID        int    `json:"id"`
 	UserID    int    `json:"userid"`
@@ -7,7 +9,34 @@ type Post struct {
 	Title     string `json:"title"`
 	Content   string `json:"content"`
 	LikeCount int    `json:"likecount"`
+	UpCount   int    `json:"upcount"`
+	DownCount int    `json:"downcount"`
 	PhotoPath string `json:"photopath"`
+	Tags      []Tag  `json:"tags"`
+	Snippet   string `json:"snippet,omitempty"`
+	// Hidden is set while enough reports keep the post out of listings.
+	Hidden bool `json:"hidden"`
+	// Shadow posts were written during a shadow ban and are only shown to
+	// their author.
+	Shadow bool `json:"shadow"`
+	// EditedAt is set once the post has been edited.
+	EditedAt time.Time `json:"editedat"`
+	// DeletedAt is set while the post is in the trash.
+	DeletedAt time.Time `json:"deletedat"`
+	DeletedBy int       `json:"deletedby"`
+	// ContentHTML is Content rendered from Markdown and sanitized.
+	ContentHTML string `json:"contenthtml"`
+	// Question posts can have one comment accepted as the answer.
+	Question   bool `json:"question"`
+	AcceptedID int  `json:"acceptedid"`
+}
+
+type Tag struct {
+	ID          int    `json:"id"`
+	Slug        string `json:"slug"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Color       string `json:"color"`
 }
 
 type Comment struct {
@@ -17,9 +46,350 @@ type Comment struct {
 	UserName  string `json:"username"`
 	Comment   string `json:"comment"`
 	LikeCount int    `json:"likecount"`
+	UpCount   int    `json:"upcount"`
+	DownCount int    `json:"downcount"`
+	Hidden    bool   `json:"hidden"`
+	Shadow    bool   `json:"shadow"`
+	// EditedAt is set once the comment has been edited.
+	EditedAt time.Time `json:"editedat"`
+	// DeletedAt is set while the comment is in the trash.
+	DeletedAt time.Time `json:"deletedat"`
+	DeletedBy int       `json:"deletedby"`
+	// ParentID is the comment this one replies to, 0 for top-level ones.
+	ParentID int       `json:"parentid"`
+	Replies  []Comment `json:"replies,omitempty"`
+	// MoreReplies counts the replies too deep in the thread to be included.
+	MoreReplies int `json:"morereplies,omitempty"`
+	// CommentHTML is Comment rendered from Markdown and sanitized.
+	CommentHTML string `json:"commenthtml"`
+}
+
+// DeletedText stands in for the author and text of deleted posts and
+// comments, so the thread around them still reads.
+const DeletedText = "[deleted]"
+
+// deletedHTML is DeletedText rendered.
+const deletedHTML = "<p>" + DeletedText + "</p>"
+
+// Placeholder is what the public sees of a deleted post.
+func (p Post) Placeholder() Post {
+	return Post{ID: p.ID, UserName: DeletedText, Title: DeletedText, Content: DeletedText, ContentHTML: deletedHTML,
+		DeletedAt: p.DeletedAt, Question: p.Question, AcceptedID: p.AcceptedID}
+}
+
+// Placeholder is what the public sees of a deleted comment.
+func (c Comment) Placeholder() Comment {
+	return Comment{ID: c.ID, PostId: c.PostId, UserName: DeletedText, Comment: DeletedText, CommentHTML: deletedHTML,
+		DeletedAt: c.DeletedAt, ParentID: c.ParentID, Replies: c.Replies, MoreReplies: c.MoreReplies}
+}
+
+// Trash is what was deleted from the user's posts and comments and hasn't
+// been purged yet, most recently deleted first.
+type Trash struct {
+	Posts    []Post    `json:"posts"`
+	Comments []Comment `json:"comments"`
+	// Retention is how long after deletion content is purged.
+	Retention time.Duration `json:"retention"`
+}
+
+// Revision is one version of a post or comment: what it said, who wrote
+// that version and when. Comments have no Title or Tags.
+type Revision struct {
+	ID         int       `json:"id"`
+	TargetID   int       `json:"targetid"`
+	IsComment  bool      `json:"iscomment"`
+	Title      string    `json:"title"`
+	Body       string    `json:"body"`
+	Tags       []string  `json:"tags"`
+	EditorID   int       `json:"editorid"`
+	EditorName string    `json:"editorname"`
+	CreatedAt  time.Time `json:"createdat"`
+}
+
+// RevisionHistory lists every version of a post or comment, the original
+// first and the current one last.
+type RevisionHistory struct {
+	TargetID  int        `json:"targetid"`
+	IsComment bool       `json:"iscomment"`
+	PostID    int        `json:"postid"`
+	Versions  []Revision `json:"versions"`
+}
+
+// Cursors are the opaque values to pass back as ?cursor= for the next
+// (older) and previous (newer) page of a listing. They are empty at either end.
+type Cursors struct {
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+type PostList struct {
+	Posts []Post `json:"posts"`
+	Cursors
+}
+
+type CommentList struct {
+	Comments []Comment `json:"comments"`
+	Cursors
 }
 
+// PostWithComments carries one page of the post's top-level comments, with
+// their replies nested in them; the cursors page through them.
 type PostWithComments struct {
 	Post     Post
 	Comments []Comment
+	// Accepted is the accepted answer of a question, shown above the other
+	// comments.
+	Accepted *Comment `json:",omitempty"`
+	Cursors
+}
+
+type User struct {
+	ID       int    `json:"id"`
+	Email    string `json:"email"`
+	UserName string `json:"username"`
+	Password string `json:"-"`
+	Role     string `json:"role"`
+	// EmailVerified is set once the user has opened the link emailed to
+	// them, or logged in through a provider that vouches for the address.
+	EmailVerified bool `json:"emailverified"`
+	// LockedUntil is set while logins are refused after too many failures.
+	LockedUntil time.Time `json:"lockeduntil"`
+	// Reputation is the sum of the user's reputation events.
+	Reputation int `json:"reputation"`
+}
+
+// Profile is what anyone may see of a user. JoinedAt is zero for accounts
+// created before it was recorded.
+type Profile struct {
+	ID          int       `json:"id"`
+	UserName    string    `json:"username"`
+	DisplayName string    `json:"displayname"`
+	Bio         string    `json:"bio"`
+	Website     string    `json:"website"`
+	Location    string    `json:"location"`
+	Avatar      string    `json:"avatar"`
+	Role        string    `json:"role"`
+	Reputation  int       `json:"reputation"`
+	JoinedAt    time.Time `json:"joinedat"`
+}
+
+// UserProfile is a user's public profile with their latest posts and
+// comments and the tags they are most active in.
+type UserProfile struct {
+	Profile
+	Posts    []Post        `json:"posts"`
+	Comments []Comment     `json:"comments"`
+	Tags     []TagActivity `json:"tags"`
+}
+
+// TagActivity is how many of a user's posts, and comments on posts, have
+// the tag.
+type TagActivity struct {
+	Tag
+	Posts    int `json:"posts"`
+	Comments int `json:"comments"`
+}
+
+// ReputationEvent is one entry in the reputation ledger: Points for UserID
+// because of what ActorID did to the post or comment TargetID.
+type ReputationEvent struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"userid"`
+	Points    int       `json:"points"`
+	Reason    string    `json:"reason"`
+	TargetID  int       `json:"targetid"`
+	IsComment bool      `json:"iscomment"`
+	ActorID   int       `json:"actorid"`
+	Note      string    `json:"note"`
+	CreatedAt time.Time `json:"createdat"`
+	// PostID is the post the target is or is on, 0 if there is none.
+	PostID int `json:"postid"`
+}
+
+// ReputationHistory is a page of a user's reputation ledger, newest first.
+type ReputationHistory struct {
+	Reputation int               `json:"reputation"`
+	Events     []ReputationEvent `json:"events"`
+	Cursors
+}
+
+// Locked reports whether the account is locked at the time.
+func (u User) Locked(now time.Time) bool {
+	return now.Before(u.LockedUntil)
+}
+
+// LoginFailure is an audit record of a failed login attempt.
+type LoginFailure struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"userid"`
+	Email     string    `json:"email"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"useragent"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"createdat"`
+}
+
+type Session struct {
+	ID         int       `json:"id"`
+	UserID     int       `json:"userid"`
+	UserAgent  string    `json:"useragent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"createdat"`
+	LastSeenAt time.Time `json:"lastseenat"`
+	ExpiresAt  time.Time `json:"expiresat"`
+	Current    bool      `json:"current"`
+}
+
+// Identity is an account at an OAuth provider that the user can log in with.
+type Identity struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"userid"`
+	Provider  string    `json:"provider"`
+	Subject   string    `json:"-"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"createdat"`
+}
+
+// TwoFactor is a user's TOTP authenticator. It is only checked at login
+// once Enabled; until then it is a setup waiting for its first code.
+type TwoFactor struct {
+	UserID    int       `json:"userid"`
+	Secret    string    `json:"-"`
+	Enabled   bool      `json:"enabled"`
+	LastStep  int64     `json:"-"`
+	CreatedAt time.Time `json:"createdat"`
+}
+
+type TwoFactorStatus struct {
+	Enabled bool `json:"enabled"`
+	// Required is set for roles that may not go without two-factor
+	// authentication.
+	Required          bool `json:"required"`
+	RecoveryCodesLeft int  `json:"recoverycodesleft"`
+}
+
+// TwoFactorSetup is what the user enters into their authenticator app.
+type TwoFactorSetup struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// Preview is Markdown rendered for the preview before posting.
+type Preview struct {
+	HTML string `json:"html"`
+}
+
+// LoginResult tells the login page what comes after a correct password.
+type LoginResult struct {
+	// Challenge is set when the user still has to enter a two-factor code.
+	Challenge string
+	// SetupTwoFactor is set when the user's role requires two-factor
+	// authentication and they haven't turned it on yet.
+	SetupTwoFactor bool
+}
+
+type LoginMethods struct {
+	Email         string     `json:"email"`
+	EmailVerified bool       `json:"emailverified"`
+	HasPassword   bool       `json:"haspassword"`
+	Identities    []Identity `json:"identities"`
+}
+
+// Ban keeps a user from logging in until it is lifted, or until ExpiresAt
+// for a suspension. A Shadow ban lets the user in, but hides what they post
+// from everyone else.
+type Ban struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"userid"`
+	Reason    string    `json:"reason"`
+	BannedBy  int       `json:"bannedby"`
+	CreatedAt time.Time `json:"createdat"`
+	ExpiresAt time.Time `json:"expiresat"`
+	Shadow    bool      `json:"shadow"`
+	LiftedAt  time.Time `json:"liftedat"`
+	LiftedBy  int       `json:"liftedby"`
+}
+
+// InForce reports whether the ban applies at the time.
+func (b Ban) InForce(now time.Time) bool {
+	return b.LiftedAt.IsZero() && (b.ExpiresAt.IsZero() || now.Before(b.ExpiresAt))
+}
+
+// Address ban kinds.
+const (
+	AddressIP     = "ip"
+	AddressDomain = "domain"
+)
+
+// AddressBan refuses registrations and logins from an IP address or CIDR
+// range, or for emails at a domain and its subdomains.
+type AddressBan struct {
+	ID        int       `json:"id"`
+	Kind      string    `json:"kind"`
+	Value     string    `json:"value"`
+	Reason    string    `json:"reason"`
+	CreatedBy int       `json:"createdby"`
+	CreatedAt time.Time `json:"createdat"`
+}
+
+// UserSummary is a row of the admin user list. Ban is the user's ban in
+// force, if Banned.
+type UserSummary struct {
+	User
+	Banned   bool `json:"banned"`
+	Ban      Ban  `json:"ban"`
+	Posts    int  `json:"posts"`
+	Comments int  `json:"comments"`
+}
+
+type UserList struct {
+	Users []UserSummary `json:"users"`
+	Cursors
+}
+
+type DayCount struct {
+	Day   string `json:"day"`
+	Count int    `json:"count"`
+}
+
+// SiteStats are the figures on the admin dashboard. ActiveUsers and the
+// daily counts only cover the last few days; the rest are totals.
+type SiteStats struct {
+	Users          int        `json:"users"`
+	ActiveUsers    int        `json:"activeusers"`
+	Posts          int        `json:"posts"`
+	Comments       int        `json:"comments"`
+	UpVotes        int        `json:"upvotes"`
+	DownVotes      int        `json:"downvotes"`
+	BannedUsers    int        `json:"bannedusers"`
+	PostsPerDay    []DayCount `json:"postsperday"`
+	CommentsPerDay []DayCount `json:"commentsperday"`
+}
+
+// Report flags a post or a comment (IsComment) for the moderators.
+type Report struct {
+	ID         int       `json:"id"`
+	ReporterID int       `json:"reporterid"`
+	TargetID   int       `json:"targetid"`
+	IsComment  bool      `json:"iscomment"`
+	Reason     string    `json:"reason"`
+	Details    string    `json:"details"`
+	CreatedAt  time.Time `json:"createdat"`
+	ResolvedAt time.Time `json:"resolvedat"`
+	ResolvedBy int       `json:"resolvedby"`
+	Resolution string    `json:"resolution"`
+}
+
+// ReportedItem is an entry of the moderation queue: a post or comment with
+// its open reports, oldest first.
+type ReportedItem struct {
+	TargetID   int      `json:"targetid"`
+	IsComment  bool     `json:"iscomment"`
+	PostID     int      `json:"postid"`
+	AuthorID   int      `json:"authorid"`
+	AuthorName string   `json:"authorname"`
+	Title      string   `json:"title"`
+	Text       string   `json:"text"`
+	Hidden     bool     `json:"hidden"`
+	Reports    []Report `json:"reports"`
 }
@@ -0,0 +1,127 @@
+package trash
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"forum/backend/auth"
+	"forum/backend/config"
+	"forum/backend/controllers/structs"
+	"forum/backend/rbac"
+	"forum/backend/store"
+)
+
+// GetTrash lists the user's deleted posts and comments that haven't been
+// purged yet.
+func GetTrash(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	repos := store.Get()
+
+	authenticated, userId, _ := auth.IsAuthenticated(r, repos.Sessions())
+	if !authenticated {
+		http.Error(w, "ERROR: You are not authorized to see the trash", http.StatusUnauthorized)
+		return
+	}
+
+	posts, err := repos.Posts().Trash(userId)
+	if err != nil {
+		http.Error(w, "ERROR: Query error", http.StatusInternalServerError)
+		return
+	}
+	comments, err := repos.Comments().Trash(userId)
+	if err != nil {
+		http.Error(w, "ERROR: Query error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(structs.Trash{Posts: posts, Comments: comments, Retention: config.Get().TrashRetention})
+	if err != nil {
+		http.Error(w, "ERROR: Failed to encode trash to JSON", http.StatusInternalServerError)
+		return
+	}
+}
+
+// Restore takes a post or comment back out of the trash. Authors can
+// restore what they deleted themselves; what a moderator removed only a
+// moderator can bring back.
+func Restore(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	idInt, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		http.Error(w, "ERROR: Invalid ID", http.StatusBadRequest)
+		return
+	}
+	isComment := r.FormValue("isComment") == "true"
+
+	own, anyone := rbac.PostDeleteOwn, rbac.PostDeleteAny
+	if isComment {
+		own, anyone = rbac.CommentDeleteOwn, rbac.CommentDeleteAny
+	}
+
+	user, ok := auth.Authorize(w, r, own)
+	if !ok {
+		return
+	}
+
+	repos := store.Get()
+	var authorID, deletedBy int
+	var deletedAt time.Time
+	if isComment {
+		comment, err := repos.Comments().ByID(idInt)
+		authorID, deletedBy, deletedAt = comment.UserId, comment.DeletedBy, comment.DeletedAt
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			http.Error(w, "ERROR: Query error", http.StatusInternalServerError)
+			return
+		}
+	} else {
+		post, err := repos.Posts().ByID(idInt)
+		authorID, deletedBy, deletedAt = post.UserID, post.DeletedBy, post.DeletedAt
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			http.Error(w, "ERROR: Query error", http.StatusInternalServerError)
+			return
+		}
+	}
+	if deletedAt.IsZero() {
+		http.Error(w, "ERROR: Nothing to restore", http.StatusNotFound)
+		return
+	}
+
+	ownerID := authorID
+	if deletedBy != authorID {
+		// Removed by a moderator.
+		ownerID = 0
+	}
+	if !auth.AuthorizeOwner(w, user, ownerID, own, anyone) {
+		return
+	}
+	if time.Since(deletedAt) > config.Get().TrashRetention {
+		http.Error(w, "ERROR: This was deleted too long ago to restore", http.StatusGone)
+		return
+	}
+
+	if isComment {
+		err = repos.Comments().Restore(idInt)
+	} else {
+		err = repos.Posts().Restore(idInt)
+	}
+	if err != nil {
+		http.Error(w, "ERROR: Unable to restore", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "Successfully restored")
+}
@@ -0,0 +1,246 @@
+package twofactor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"forum/backend/auth"
+	"forum/backend/controllers/structs"
+	"forum/backend/store"
+	"forum/backend/totp"
+)
+
+// issuer is the name authenticator apps show next to the account.
+const issuer = "Forum Ware"
+
+// GetTwoFactor tells whether the user has two-factor authentication on, and
+// whether their role requires it.
+func GetTwoFactor(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	repos := store.Get()
+
+	user, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	enabled, err := auth.HasTwoFactor(repos.TwoFactor(), user.ID)
+	if err != nil {
+		http.Error(w, "ERROR: Query error", http.StatusInternalServerError)
+		return
+	}
+
+	status := structs.TwoFactorStatus{Enabled: enabled, Required: auth.RequiresTwoFactor(user.Role)}
+	if enabled {
+		status.RecoveryCodesLeft, err = repos.TwoFactor().RecoveryCodesLeft(user.ID)
+		if err != nil {
+			http.Error(w, "ERROR: Query error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	writeJSON(w, status)
+}
+
+// SetupTwoFactor creates a new secret for the user's authenticator app. It
+// takes effect once EnableTwoFactor gets a code generated from it.
+func SetupTwoFactor(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	repos := store.Get()
+
+	user, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	enabled, err := auth.HasTwoFactor(repos.TwoFactor(), user.ID)
+	if err != nil {
+		http.Error(w, "ERROR: Query error", http.StatusInternalServerError)
+		return
+	}
+	if enabled {
+		http.Error(w, "ERROR: Two-factor authentication is already on", http.StatusConflict)
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		http.Error(w, "ERROR: Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	err = repos.TwoFactor().Begin(user.ID, secret, time.Now().UTC().Truncate(time.Second))
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, structs.TwoFactorSetup{Secret: secret, URI: totp.URI(issuer, user.Email, secret)})
+}
+
+// EnableTwoFactor turns two-factor authentication on once the user proves
+// their app is set up, and returns their recovery codes. This is the only
+// time the codes are shown.
+func EnableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	repos := store.Get()
+
+	user, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	tf, err := repos.TwoFactor().ByUser(user.ID)
+	if errors.Is(err, store.ErrNotFound) || (err == nil && tf.Enabled) {
+		http.Error(w, "ERROR: Start the setup first", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "ERROR: Query error", http.StatusInternalServerError)
+		return
+	}
+
+	step, valid := totp.Validate(tf.Secret, r.FormValue("code"), time.Now().UTC())
+	if !valid {
+		http.Error(w, "ERROR: Invalid code", http.StatusBadRequest)
+		return
+	}
+
+	codes, hashes, err := auth.NewRecoveryCodes()
+	if err != nil {
+		http.Error(w, "ERROR: Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	err = repos.TwoFactor().Enable(user.ID, step, hashes)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "ERROR: Start the setup first", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, codes)
+}
+
+// DisableTwoFactor turns two-factor authentication off. It takes a current
+// code, and is refused for roles that require two-factor authentication.
+func DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	repos := store.Get()
+
+	user, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	if auth.RequiresTwoFactor(user.Role) {
+		http.Error(w, "ERROR: Your role requires two-factor authentication", http.StatusForbidden)
+		return
+	}
+
+	if !checkCode(w, user.ID, r.FormValue("code")) {
+		return
+	}
+
+	err := repos.TwoFactor().Disable(user.ID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "Two-factor authentication turned off")
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes, used or not,
+// with new ones. It takes a current code.
+func RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	repos := store.Get()
+
+	user, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	if !checkCode(w, user.ID, r.FormValue("code")) {
+		return
+	}
+
+	codes, hashes, err := auth.NewRecoveryCodes()
+	if err != nil {
+		http.Error(w, "ERROR: Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	err = repos.TwoFactor().ReplaceRecoveryCodes(user.ID, hashes)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, codes)
+}
+
+func currentUser(w http.ResponseWriter, r *http.Request) (structs.User, bool) {
+	repos := store.Get()
+
+	authenticated, userId, _ := auth.IsAuthenticated(r, repos.Sessions())
+	if !authenticated {
+		http.Error(w, "ERROR: You are not logged in", http.StatusUnauthorized)
+		return structs.User{}, false
+	}
+
+	user, err := repos.Users().ByID(userId)
+	if err != nil {
+		http.Error(w, "ERROR: Query error", http.StatusInternalServerError)
+		return structs.User{}, false
+	}
+	return user, true
+}
+
+func checkCode(w http.ResponseWriter, userID int, code string) bool {
+	valid, err := auth.CheckSecondFactor(store.Get().TwoFactor(), userID, code)
+	if err != nil {
+		http.Error(w, "ERROR: Query error", http.StatusInternalServerError)
+		return false
+	}
+	if !valid {
+		http.Error(w, "ERROR: Invalid code", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, "ERROR: Failed to encode response to JSON", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,87 @@
+package unlockaccount
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"forum/backend/auth"
+	"forum/backend/rbac"
+	"forum/backend/store"
+	"forum/backend/tokens"
+)
+
+// UnlockAccount lifts a lock with the token from the email sent when the
+// account was locked.
+func UnlockAccount(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	token := r.FormValue("token")
+
+	userId, err := tokens.UserID(token)
+	if err != nil {
+		http.Error(w, "ERROR: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	repos := store.Get()
+
+	user, err := repos.Users().ByID(userId)
+	if err != nil || !user.Locked(time.Now().UTC()) {
+		http.Error(w, "ERROR: "+tokens.ErrInvalid.Error(), http.StatusBadRequest)
+		return
+	}
+	binding := strconv.FormatInt(user.LockedUntil.Unix(), 10)
+	if tokens.Verify(token, tokens.PurposeUnlockAccount, binding) != nil {
+		http.Error(w, "ERROR: "+tokens.ErrInvalid.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = auth.UnlockAccount(repos.Users(), user)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "Account successfully unlocked")
+}
+
+// UnlockUser lets an admin lift the lock on someone's account.
+func UnlockUser(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	repos := store.Get()
+
+	if _, ok := auth.Authorize(w, r, rbac.UserUnlock); !ok {
+		return
+	}
+
+	userId, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		http.Error(w, "ERROR: Invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	user, err := repos.Users().ByID(userId)
+	if err != nil {
+		http.Error(w, "ERROR: User not found", http.StatusNotFound)
+		return
+	}
+
+	err = auth.UnlockAccount(repos.Users(), user)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "Account successfully unlocked")
+}
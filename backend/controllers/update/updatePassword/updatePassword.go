@@ -0,0 +1,105 @@
+package updatepassword
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"forum/backend/auth"
+	"forum/backend/controllers/login"
+	"forum/backend/controllers/register"
+	"forum/backend/controllers/structs"
+	"forum/backend/store"
+)
+
+// UpdatePassword changes the user's password and logs the user out
+// everywhere else. Accounts created through an OAuth provider have none, and
+// can set one without the current password. Wrong current passwords count
+// as failed logins, so a stolen session can't be used to guess it.
+func UpdatePassword(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	repos := store.Get()
+
+	authenticated, userId, _ := auth.IsAuthenticated(r, repos.Sessions())
+	if !authenticated {
+		http.Error(w, "ERROR: You are not authorized to change the password", http.StatusUnauthorized)
+		return
+	}
+	session, _, err := auth.CurrentSession(r, repos.Sessions())
+	if err != nil {
+		http.Error(w, "ERROR: You are not authorized to change the password", http.StatusUnauthorized)
+		return
+	}
+
+	password := r.FormValue("password")
+	if password == "" {
+		http.Error(w, "ERROR: Please provide a new password", http.StatusBadRequest)
+		return
+	}
+
+	user, err := repos.Users().ByID(userId)
+	if err != nil {
+		http.Error(w, "ERROR: Invalid query", http.StatusBadRequest)
+		return
+	}
+
+	if user.Password != "" && !currentPasswordMatches(w, r, user) {
+		return
+	}
+
+	hashedPasswd, errHash := register.HashThePasswd(password)
+	if errHash != nil {
+		http.Error(w, "ERROR: Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	err = repos.Users().SetPassword(userId, hashedPasswd)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	// Whoever else knew the old password shouldn't stay logged in.
+	if err := repos.Sessions().DeleteOthers(userId, session.ID); err != nil {
+		log.Printf("Failed to end other sessions of user %d: %v", userId, err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "Password successfully updated")
+}
+
+// currentPasswordMatches checks the password the user typed in under the
+// login throttle, answering for them if it is wrong or they have to wait.
+func currentPasswordMatches(w http.ResponseWriter, r *http.Request, user structs.User) bool {
+	wait, err := auth.LoginRetryAfter(r, user.Email)
+	if err != nil {
+		http.Error(w, "ERROR: Internal server error", http.StatusInternalServerError)
+		return false
+	}
+	if wait > 0 {
+		if err := auth.RecordLoginFailure(r, user.Email, user, auth.ReasonThrottled); err != nil {
+			log.Printf("Failed to record login failure: %v", err)
+		}
+		w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+		http.Error(w, "ERROR: Too many failed attempts, please try again later", http.StatusTooManyRequests)
+		return false
+	}
+
+	if login.ArePasswordsMatching(user.Password, r.FormValue("currentpassword")) != nil {
+		if err := auth.RecordLoginFailure(r, user.Email, user, auth.ReasonBadPassword); err != nil {
+			log.Printf("Failed to record login failure: %v", err)
+		}
+		http.Error(w, "ERROR: Invalid password", http.StatusBadRequest)
+		return false
+	}
+
+	if err := auth.RecordLoginSuccess(user.Email); err != nil {
+		log.Printf("Failed to clear login failures: %v", err)
+	}
+	return true
+}
@@ -0,0 +1,126 @@
+package updateprofile
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"forum/backend/auth"
+	"forum/backend/avatar"
+	"forum/backend/store"
+)
+
+// Longest values accepted, in characters.
+const (
+	maxDisplayName = 50
+	maxBio         = 500
+	maxWebsite     = 200
+	maxLocation    = 100
+)
+
+// UpdateProfile sets what the user shows on their public profile. An
+// uploaded avatar replaces the current one; removeavatar=true drops it.
+func UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	repos := store.Get()
+
+	authenticated, userId, _ := auth.IsAuthenticated(r, repos.Sessions())
+	if !authenticated {
+		http.Error(w, "ERROR: You are not authorized to change the profile", http.StatusUnauthorized)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, avatar.MaxSize+64<<10)
+	err := r.ParseMultipartForm(avatar.MaxSize)
+	if err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		http.Error(w, "ERROR: The avatar must be under 2MB", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	profile, err := repos.Users().ProfileByID(userId)
+	if err != nil {
+		http.Error(w, "ERROR: Invalid query", http.StatusBadRequest)
+		return
+	}
+
+	profile.DisplayName = strings.TrimSpace(r.FormValue("displayname"))
+	profile.Bio = strings.TrimSpace(r.FormValue("bio"))
+	profile.Website = strings.TrimSpace(r.FormValue("website"))
+	profile.Location = strings.TrimSpace(r.FormValue("location"))
+	if msg := validate(profile.DisplayName, profile.Bio, profile.Website, profile.Location); msg != "" {
+		http.Error(w, "ERROR: "+msg, http.StatusBadRequest)
+		return
+	}
+
+	oldAvatar := profile.Avatar
+	if r.FormValue("removeavatar") == "true" {
+		profile.Avatar = ""
+	}
+	file, header, err := r.FormFile("avatar")
+	if err == nil {
+		defer file.Close()
+		if header.Size > avatar.MaxSize {
+			http.Error(w, "ERROR: The avatar must be under 2MB", http.StatusRequestEntityTooLarge)
+			return
+		}
+		profile.Avatar, err = avatar.Save(userId, file)
+		if errors.Is(err, avatar.ErrTooLarge) {
+			http.Error(w, "ERROR: The avatar must be under 2MB", http.StatusRequestEntityTooLarge)
+			return
+		}
+		if errors.Is(err, avatar.ErrUnsupported) {
+			http.Error(w, "ERROR: Unsupported file type. Only JPEG, PNG, and GIF are allowed.", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, "ERROR: Unable to save the avatar", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	err = repos.Users().UpdateProfile(profile)
+	if err != nil {
+		if profile.Avatar != oldAvatar {
+			avatar.Remove(profile.Avatar)
+		}
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if profile.Avatar != oldAvatar {
+		if err := avatar.Remove(oldAvatar); err != nil {
+			log.Printf("Failed to remove avatar %s: %v", oldAvatar, err)
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "Profile successfully updated")
+}
+
+// validate returns what is wrong with the profile, or "" if nothing is.
+func validate(displayName, bio, website, location string) string {
+	switch {
+	case utf8.RuneCountInString(displayName) > maxDisplayName:
+		return fmt.Sprintf("The display name can be at most %d characters", maxDisplayName)
+	case utf8.RuneCountInString(bio) > maxBio:
+		return fmt.Sprintf("The bio can be at most %d characters", maxBio)
+	case utf8.RuneCountInString(location) > maxLocation:
+		return fmt.Sprintf("The location can be at most %d characters", maxLocation)
+	case utf8.RuneCountInString(website) > maxWebsite:
+		return fmt.Sprintf("The website can be at most %d characters", maxWebsite)
+	}
+	if website != "" {
+		u, err := url.Parse(website)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return "The website must be an http or https address"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,82 @@
+package verifyemail
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"forum/backend/auth"
+	"forum/backend/mail"
+	"forum/backend/store"
+	"forum/backend/tokens"
+)
+
+// VerifyEmail marks the address as confirmed with the token from the
+// verification email.
+func VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	token := r.FormValue("token")
+
+	userId, err := tokens.UserID(token)
+	if err != nil {
+		http.Error(w, "ERROR: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	repos := store.Get()
+
+	user, err := repos.Users().ByID(userId)
+	if err != nil || tokens.Verify(token, tokens.PurposeVerifyEmail, user.Email) != nil {
+		http.Error(w, "ERROR: "+tokens.ErrInvalid.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = repos.Users().SetEmailVerified(userId)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "Email successfully verified")
+}
+
+// SendVerification emails the logged in user a new verification link.
+func SendVerification(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	repos := store.Get()
+
+	authenticated, userId, _ := auth.IsAuthenticated(r, repos.Sessions())
+	if !authenticated {
+		http.Error(w, "ERROR: You are not logged in", http.StatusUnauthorized)
+		return
+	}
+
+	user, err := repos.Users().ByID(userId)
+	if err != nil {
+		http.Error(w, "ERROR: Invalid query", http.StatusBadRequest)
+		return
+	}
+	if user.EmailVerified {
+		http.Error(w, "ERROR: Email is already verified", http.StatusConflict)
+		return
+	}
+
+	err = mail.SendVerification(user)
+	if err != nil {
+		log.Printf("Failed to send verification email to user %d: %v", userId, err)
+		http.Error(w, "ERROR: Unable to send email", http.StatusBadGateway)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "Verification email sent")
+}
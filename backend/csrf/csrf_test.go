@@ -0,0 +1,166 @@
+package csrf
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"forum/backend/auth"
+)
+
+const session = "session-token"
+
+// served is what the handler behind Protect answers with.
+const served = http.StatusNoContent
+
+func protected() http.Handler {
+	return Protect(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(served)
+	}))
+}
+
+func withCookie(r *http.Request) *http.Request {
+	r.AddCookie(&http.Cookie{Name: auth.SessionCookieName, Value: session})
+	return r
+}
+
+func formPost(target string, form url.Values) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, target, strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return r
+}
+
+// multipartBody is a form with the token field and a file of size bytes.
+func multipartBody(t *testing.T, token string, size int) (*bytes.Buffer, string) {
+	t.Helper()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	writer.WriteField(FieldName, token)
+	part, err := writer.CreateFormFile("avatar", "big.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(bytes.Repeat([]byte{'x'}, size))
+	writer.Close()
+	return &body, writer.FormDataContentType()
+}
+
+func TestBodyCappedBeforeFormToken(t *testing.T) {
+	body, contentType := multipartBody(t, Token(session), MaxBodySize+1)
+	r := withCookie(httptest.NewRequest(http.MethodPost, "/api/updateprofile", body))
+	r.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	protected().ServeHTTP(w, r)
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusRequestEntityTooLarge)
+	}
+}
+
+func TestMultipartFormToken(t *testing.T) {
+	body, contentType := multipartBody(t, Token(session), 1<<10)
+	r := withCookie(httptest.NewRequest(http.MethodPost, "/api/updateprofile", body))
+	r.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	protected().ServeHTTP(w, r)
+
+	if w.Code != served {
+		t.Fatalf("status = %d, want %d: %s", w.Code, served, strings.TrimSpace(w.Body.String()))
+	}
+}
+
+// A page on another site can make the browser post a form with the victim's
+// cookie, but it can't read the token to put in it.
+func TestCrossOriginFormPostRejected(t *testing.T) {
+	r := withCookie(formPost("http://example.com/deletepost", url.Values{"postid": {"1"}}))
+	r.Header.Set("Origin", "http://evil.example")
+	w := httptest.NewRecorder()
+	protected().ServeHTTP(w, r)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+}
+
+func TestFormToken(t *testing.T) {
+	r := withCookie(formPost("http://example.com/deletepost", url.Values{"postid": {"1"}, FieldName: {Token(session)}}))
+	w := httptest.NewRecorder()
+	protected().ServeHTTP(w, r)
+
+	if w.Code != served {
+		t.Fatalf("status = %d, want %d: %s", w.Code, served, strings.TrimSpace(w.Body.String()))
+	}
+}
+
+func TestRejectsMissingOrBadToken(t *testing.T) {
+	for name, token := range map[string]string{
+		"missing":       "",
+		"bad":           "not-the-token",
+		"other session": Token("other-session"),
+	} {
+		r := withCookie(httptest.NewRequest(http.MethodPost, "/api/createpost", nil))
+		if token != "" {
+			r.Header.Set(HeaderName, token)
+		}
+		w := httptest.NewRecorder()
+		protected().ServeHTTP(w, r)
+
+		if w.Code != http.StatusForbidden {
+			t.Errorf("%s token: status = %d, want %d", name, w.Code, http.StatusForbidden)
+		}
+	}
+}
+
+func TestHeaderToken(t *testing.T) {
+	r := withCookie(httptest.NewRequest(http.MethodPost, "/api/createpost", nil))
+	r.Header.Set(HeaderName, Token(session))
+	w := httptest.NewRecorder()
+	protected().ServeHTTP(w, r)
+
+	if w.Code != served {
+		t.Fatalf("status = %d, want %d", w.Code, served)
+	}
+}
+
+// Without a session there is nothing to forge, but a login posted from
+// another site is still refused. A request with neither Origin nor Referer
+// comes from a client other than a browser and is let through.
+func TestSessionlessCrossOrigin(t *testing.T) {
+	for _, test := range []struct {
+		header, value string
+		want          int
+	}{
+		{"Origin", "http://evil.example", http.StatusForbidden},
+		{"Origin", "null", http.StatusForbidden},
+		{"Referer", "http://evil.example/login", http.StatusForbidden},
+		{"Origin", "http://example.com", served},
+		{"Referer", "http://example.com/login", served},
+		{"", "", served},
+	} {
+		r := formPost("http://example.com/api/login", url.Values{"email": {"a"}, "password": {"b"}})
+		if test.header != "" {
+			r.Header.Set(test.header, test.value)
+		}
+		w := httptest.NewRecorder()
+		protected().ServeHTTP(w, r)
+
+		if w.Code != test.want {
+			t.Errorf("%s %q: status = %d, want %d", test.header, test.value, w.Code, test.want)
+		}
+	}
+}
+
+func TestSafeMethodsPass(t *testing.T) {
+	r := withCookie(httptest.NewRequest(http.MethodGet, "/api/posts", nil))
+	r.Header.Set("Origin", "http://evil.example")
+	w := httptest.NewRecorder()
+	protected().ServeHTTP(w, r)
+
+	if w.Code != served {
+		t.Fatalf("status = %d, want %d", w.Code, served)
+	}
+}
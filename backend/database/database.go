@@ -2,93 +2,130 @@ package database
 
 import (
 	"database/sql"
+	"errors"
 	"log"
-	"net/http"
+	"time"
 
+	"forum/backend/config"
+	"forum/backend/repository"
+	"forum/backend/store"
+
+	_ "github.com/lib/pq"
 	_ "github.com/mattn/go-sqlite3"
 )
 
-const databasePath = "./forum.db"
+const (
+	maxOpenConns    = 16
+	maxIdleConns    = 16
+	connMaxIdleTime = 5 * time.Minute
+)
+
+func Open(driver, dsn string) (*sql.DB, error) {
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(maxOpenConns)
+	db.SetMaxIdleConns(maxIdleConns)
+	db.SetConnMaxIdleTime(connMaxIdleTime)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if driver == config.DriverSQLite {
+		if err := checkSQLiteFeatures(db); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	return db, nil
+}
 
-func OpenDb(w http.ResponseWriter) (*sql.DB, error) {
-	db, errDb := sql.Open("sqlite3", databasePath)
-	if errDb != nil {
-		return nil, errDb
+// checkSQLiteFeatures fails early, with a hint, when go-sqlite3 was compiled
+// without the FTS5 module that the search migration needs.
+func checkSQLiteFeatures(db *sql.DB) error {
+	var fts5 bool
+	if err := db.QueryRow("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&fts5); err != nil {
+		return err
+	}
+	if !fts5 {
+		return errors.New("SQLite was built without FTS5; build with: go build -tags sqlite_fts5")
 	}
-	return db, errDb
+	return nil
 }
 
-func CreateDatabaseIfNotExists() {
-	db, err := sql.Open("sqlite3", databasePath)
+// OpenMemory returns a store backed by a private in-memory SQLite database
+// with the full schema applied. It is limited to one connection because every
+// new connection to ":memory:" would otherwise see its own empty database.
+func OpenMemory() (store.Store, error) {
+	db, err := sql.Open(config.DriverSQLite, "file::memory:?_txlock=immediate")
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+
+	if err := checkSQLiteFeatures(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	migrator, err := NewMigrator(db, config.DriverSQLite)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	if _, err := migrator.Up(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return repository.New(db, repository.SQLite), nil
+}
+
+// Connect opens the configured database, brings the schema up to date and
+// returns the store for it. It refuses to continue against a schema written
+// by a newer binary.
+func Connect(cfg config.Config) (store.Store, error) {
+	dialect, err := repository.DialectFor(cfg.DatabaseDriver)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := Open(cfg.DatabaseDriver, cfg.DatabaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	migrator, err := NewMigrator(db, cfg.DatabaseDriver)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	ran, err := migrator.Up()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	for _, migration := range ran {
+		log.Printf("Applied migration %04d_%s", migration.Version, migration.Name)
 	}
 
-	// Creating tables
-	createTables := `
-    CREATE TABLE IF NOT EXISTS USERS (
-        id INTEGER PRIMARY KEY AUTOINCREMENT,
-        Email TEXT NOT NULL UNIQUE,
-        UserName TEXT NOT NULL,
-        Password TEXT NOT NULL,
-		Role TEXT,
-		session_token TEXT
-    );
-    CREATE TABLE IF NOT EXISTS POSTS (
-		"ID" INTEGER UNIQUE,
-		"UserID" INTEGER,
-		"UserName" TEXT,
-		"Title" TEXT,
-		"Content" TEXT,
-		"LikeCount" INTEGER DEFAULT 0,
-		"PostDate" TEXT NOT NULL DEFAULT (datetime('now')),
-		"PhotoPath" TEXT,
-		PRIMARY KEY("ID" AUTOINCREMENT)
-    );
-    CREATE TABLE IF NOT EXISTS COMMENTS (
-        ID INTEGER PRIMARY KEY AUTOINCREMENT,
-        PostId INTEGER,
-        UserId INTEGER,
-		UserName TEXT,
-        Comment TEXT NOT NULL,
-		"LikeCount" INTEGER DEFAULT 0,
-        created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-        FOREIGN KEY(PostId) REFERENCES posts(ID),
-        FOREIGN KEY(UserId) REFERENCES users(ID)
-    );
-		CREATE TABLE IF NOT EXISTS USERLIKES (
-		ID INTEGER PRIMARY KEY AUTOINCREMENT,
-		UserID INTEGER,
-		PostID INTEGER,
-		IsComment BOOLEAN,
-		DeleteID INTEGER,
-		Liked BOOLEAN,
-		Disliked BOOLEAN,
-		UNIQUE(UserID, PostID, IsComment)
-	);
-	CREATE TABLE IF NOT EXISTS CATEGORIES (
-        ID INTEGER PRIMARY KEY AUTOINCREMENT,
-        USERID INTEGER,
-        PostID INTEGER,
-        GO INTEGER DEFAULT 0 CHECK(GO IN (0, 1)),
-        HTML INTEGER DEFAULT 0 CHECK(HTML IN (0, 1)),
-        CSS INTEGER DEFAULT 0 CHECK(CSS IN (0, 1)),
-        PHP INTEGER DEFAULT 0 CHECK(PHP IN (0, 1)),
-        PYTHON INTEGER DEFAULT 0 CHECK(PYTHON IN (0, 1)),
-        C INTEGER DEFAULT 0 CHECK(C IN (0, 1)),
-        "CPP" INTEGER DEFAULT 0 CHECK("CPP" IN (0, 1)),
-        "CSHARP" INTEGER DEFAULT 0 CHECK("CSHARP" IN (0, 1)),
-        JS INTEGER DEFAULT 0 CHECK(JS IN (0, 1)),
-        ASSEMBLY INTEGER DEFAULT 0 CHECK(ASSEMBLY IN (0, 1)),
-        REACT INTEGER DEFAULT 0 CHECK(REACT IN (0, 1)),
-        FLUTTER INTEGER DEFAULT 0 CHECK(FLUTTER IN (0, 1)),
-        RUST INTEGER DEFAULT 0 CHECK(RUST IN (0, 1)),
-        FOREIGN KEY(PostID) REFERENCES POSTS(ID),
-        FOREIGN KEY(USERID) REFERENCES USERS(ID)
-	);`
-
-	_, err = db.Exec(createTables)
+	return repository.New(db, dialect), nil
+}
+
+// Init connects the shared store used by every handler.
+func Init(cfg config.Config) {
+	st, err := Connect(cfg)
 	if err != nil {
 		log.Fatal(err)
 	}
+	store.Set(st)
+}
+
+func Close() error {
+	if store.Get() == nil {
+		return nil
+	}
+	return store.Get().Close()
 }
@@ -0,0 +1,193 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"forum/backend/config"
+)
+
+func openMigrator(t *testing.T) (*Migrator, *sql.DB) {
+	t.Helper()
+	db, err := sql.Open(config.DriverSQLite, "file::memory:?_txlock=immediate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := NewMigrator(db, config.DriverSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return migrator, db
+}
+
+// recorded returns the versions and names in schema_migrations, in order.
+func recorded(t *testing.T, db *sql.DB) []Migration {
+	t.Helper()
+	rows, err := db.Query("SELECT version, name FROM schema_migrations ORDER BY version")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	var migrations []Migration
+	for rows.Next() {
+		var migration Migration
+		if err := rows.Scan(&migration.Version, &migration.Name); err != nil {
+			t.Fatal(err)
+		}
+		migrations = append(migrations, migration)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return migrations
+}
+
+// userTables lists the tables left besides the bookkeeping ones.
+func userTables(t *testing.T, db *sql.DB) []string {
+	t.Helper()
+	rows, err := db.Query(`SELECT name FROM sqlite_master WHERE type = 'table'
+		AND name NOT IN ('schema_migrations', 'sqlite_sequence') ORDER BY name`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatal(err)
+		}
+		tables = append(tables, name)
+	}
+	return tables
+}
+
+func checkApplied(t *testing.T, migrator *Migrator, db *sql.DB, embedded []Migration) {
+	t.Helper()
+	got := recorded(t, db)
+	if len(got) != len(embedded) {
+		t.Fatalf("%d migrations recorded, want %d", len(got), len(embedded))
+	}
+	for i, migration := range embedded {
+		if got[i].Version != migration.Version || got[i].Name != migration.Name {
+			t.Errorf("recorded %04d_%s, want %04d_%s", got[i].Version, got[i].Name, migration.Version, migration.Name)
+		}
+	}
+
+	statuses, err := migrator.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, status := range statuses {
+		if !status.Applied || status.AppliedAt == "" {
+			t.Errorf("status of %04d_%s = %+v, want applied", status.Migration.Version, status.Migration.Name, status)
+		}
+	}
+	if current, err := migrator.CurrentVersion(); err != nil || current != embedded[len(embedded)-1].Version {
+		t.Errorf("CurrentVersion = %d, %v; want %d", current, err, embedded[len(embedded)-1].Version)
+	}
+}
+
+func TestMigrateUpDownUp(t *testing.T) {
+	migrator, db := openMigrator(t)
+	embedded, err := LoadMigrations(config.DriverSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ran, err := migrator.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ran) != len(embedded) {
+		t.Fatalf("Up ran %d migrations, want %d", len(ran), len(embedded))
+	}
+	checkApplied(t, migrator, db, embedded)
+	if ran, err := migrator.Up(); err != nil || len(ran) != 0 {
+		t.Fatalf("second Up ran %d migrations, %v; want none", len(ran), err)
+	}
+
+	// Roll everything back, newest first.
+	for i := len(embedded) - 1; i >= 0; i-- {
+		undone, err := migrator.Down()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if undone == nil || undone.Version != embedded[i].Version {
+			t.Fatalf("Down undid %+v, want %04d_%s", undone, embedded[i].Version, embedded[i].Name)
+		}
+	}
+	if undone, err := migrator.Down(); err != nil || undone != nil {
+		t.Fatalf("Down on an empty schema = %+v, %v; want nothing", undone, err)
+	}
+	if tables := userTables(t, db); len(tables) != 0 {
+		t.Errorf("tables left after rolling back: %v", tables)
+	}
+	statuses, err := migrator.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, status := range statuses {
+		if status.Applied {
+			t.Errorf("%04d_%s still applied", status.Migration.Version, status.Migration.Name)
+		}
+	}
+
+	if _, err := migrator.Up(); err != nil {
+		t.Fatalf("Up after rolling back: %v", err)
+	}
+	checkApplied(t, migrator, db, embedded)
+}
+
+func TestRefusesNewerSchema(t *testing.T) {
+	migrator, db := openMigrator(t)
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("INSERT INTO schema_migrations (version, name, applied_at) VALUES (9999, 'future', '2100-01-01T00:00:00Z')"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := migrator.Up(); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("Up error = %v, want ErrSchemaTooNew", err)
+	}
+	if _, err := migrator.Down(); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("Down error = %v, want ErrSchemaTooNew", err)
+	}
+}
+
+func TestConnectRefusesNewerSchema(t *testing.T) {
+	cfg := config.Config{
+		DatabaseDriver: config.DriverSQLite,
+		DatabaseURL:    "file:" + filepath.Join(t.TempDir(), "forum.db") + "?_txlock=immediate",
+	}
+	st, err := Connect(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st.Close()
+
+	db, err := Open(cfg.DatabaseDriver, cfg.DatabaseURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec("INSERT INTO schema_migrations (version, name, applied_at) VALUES (9999, 'future', '2100-01-01T00:00:00Z')")
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if st, err := Connect(cfg); !errors.Is(err, ErrSchemaTooNew) {
+		if err == nil {
+			st.Close()
+		}
+		t.Fatalf("Connect error = %v, want ErrSchemaTooNew", err)
+	}
+}
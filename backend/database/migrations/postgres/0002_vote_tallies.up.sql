@@ -0,0 +1,14 @@
+ALTER TABLE POSTS ADD COLUMN UpCount INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE POSTS ADD COLUMN DownCount INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE COMMENTS ADD COLUMN UpCount INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE COMMENTS ADD COLUMN DownCount INTEGER NOT NULL DEFAULT 0;
+
+UPDATE POSTS SET
+    UpCount = (SELECT COUNT(*) FROM USERLIKES WHERE USERLIKES.PostID = POSTS.ID AND USERLIKES.IsComment = FALSE AND USERLIKES.Liked = TRUE),
+    DownCount = (SELECT COUNT(*) FROM USERLIKES WHERE USERLIKES.PostID = POSTS.ID AND USERLIKES.IsComment = FALSE AND USERLIKES.Disliked = TRUE);
+UPDATE POSTS SET LikeCount = UpCount - DownCount;
+
+UPDATE COMMENTS SET
+    UpCount = (SELECT COUNT(*) FROM USERLIKES WHERE USERLIKES.PostID = COMMENTS.ID AND USERLIKES.IsComment = TRUE AND USERLIKES.Liked = TRUE),
+    DownCount = (SELECT COUNT(*) FROM USERLIKES WHERE USERLIKES.PostID = COMMENTS.ID AND USERLIKES.IsComment = TRUE AND USERLIKES.Disliked = TRUE);
+UPDATE COMMENTS SET LikeCount = UpCount - DownCount;
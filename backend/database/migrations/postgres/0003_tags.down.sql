@@ -0,0 +1,38 @@
+CREATE TABLE CATEGORIES (
+    ID SERIAL PRIMARY KEY,
+    USERID INTEGER,
+    PostID INTEGER,
+    GO INTEGER DEFAULT 0 CHECK(GO IN (0, 1)),
+    HTML INTEGER DEFAULT 0 CHECK(HTML IN (0, 1)),
+    CSS INTEGER DEFAULT 0 CHECK(CSS IN (0, 1)),
+    PHP INTEGER DEFAULT 0 CHECK(PHP IN (0, 1)),
+    PYTHON INTEGER DEFAULT 0 CHECK(PYTHON IN (0, 1)),
+    C INTEGER DEFAULT 0 CHECK(C IN (0, 1)),
+    CPP INTEGER DEFAULT 0 CHECK(CPP IN (0, 1)),
+    CSHARP INTEGER DEFAULT 0 CHECK(CSHARP IN (0, 1)),
+    JS INTEGER DEFAULT 0 CHECK(JS IN (0, 1)),
+    ASSEMBLY INTEGER DEFAULT 0 CHECK(ASSEMBLY IN (0, 1)),
+    REACT INTEGER DEFAULT 0 CHECK(REACT IN (0, 1)),
+    FLUTTER INTEGER DEFAULT 0 CHECK(FLUTTER IN (0, 1)),
+    RUST INTEGER DEFAULT 0 CHECK(RUST IN (0, 1))
+);
+
+INSERT INTO CATEGORIES (USERID, PostID, GO, HTML, CSS, PHP, PYTHON, C, CPP, CSHARP, JS, ASSEMBLY, REACT, FLUTTER, RUST)
+SELECT POSTS.UserID, POSTS.ID,
+    CASE WHEN EXISTS (SELECT 1 FROM post_tags JOIN tags ON tags.ID = post_tags.TagID WHERE post_tags.PostID = POSTS.ID AND tags.Slug = 'go') THEN 1 ELSE 0 END,
+    CASE WHEN EXISTS (SELECT 1 FROM post_tags JOIN tags ON tags.ID = post_tags.TagID WHERE post_tags.PostID = POSTS.ID AND tags.Slug = 'html') THEN 1 ELSE 0 END,
+    CASE WHEN EXISTS (SELECT 1 FROM post_tags JOIN tags ON tags.ID = post_tags.TagID WHERE post_tags.PostID = POSTS.ID AND tags.Slug = 'css') THEN 1 ELSE 0 END,
+    CASE WHEN EXISTS (SELECT 1 FROM post_tags JOIN tags ON tags.ID = post_tags.TagID WHERE post_tags.PostID = POSTS.ID AND tags.Slug = 'php') THEN 1 ELSE 0 END,
+    CASE WHEN EXISTS (SELECT 1 FROM post_tags JOIN tags ON tags.ID = post_tags.TagID WHERE post_tags.PostID = POSTS.ID AND tags.Slug = 'python') THEN 1 ELSE 0 END,
+    CASE WHEN EXISTS (SELECT 1 FROM post_tags JOIN tags ON tags.ID = post_tags.TagID WHERE post_tags.PostID = POSTS.ID AND tags.Slug = 'c') THEN 1 ELSE 0 END,
+    CASE WHEN EXISTS (SELECT 1 FROM post_tags JOIN tags ON tags.ID = post_tags.TagID WHERE post_tags.PostID = POSTS.ID AND tags.Slug = 'cpp') THEN 1 ELSE 0 END,
+    CASE WHEN EXISTS (SELECT 1 FROM post_tags JOIN tags ON tags.ID = post_tags.TagID WHERE post_tags.PostID = POSTS.ID AND tags.Slug = 'csharp') THEN 1 ELSE 0 END,
+    CASE WHEN EXISTS (SELECT 1 FROM post_tags JOIN tags ON tags.ID = post_tags.TagID WHERE post_tags.PostID = POSTS.ID AND tags.Slug = 'js') THEN 1 ELSE 0 END,
+    CASE WHEN EXISTS (SELECT 1 FROM post_tags JOIN tags ON tags.ID = post_tags.TagID WHERE post_tags.PostID = POSTS.ID AND tags.Slug = 'assembly') THEN 1 ELSE 0 END,
+    CASE WHEN EXISTS (SELECT 1 FROM post_tags JOIN tags ON tags.ID = post_tags.TagID WHERE post_tags.PostID = POSTS.ID AND tags.Slug = 'react') THEN 1 ELSE 0 END,
+    CASE WHEN EXISTS (SELECT 1 FROM post_tags JOIN tags ON tags.ID = post_tags.TagID WHERE post_tags.PostID = POSTS.ID AND tags.Slug = 'flutter') THEN 1 ELSE 0 END,
+    CASE WHEN EXISTS (SELECT 1 FROM post_tags JOIN tags ON tags.ID = post_tags.TagID WHERE post_tags.PostID = POSTS.ID AND tags.Slug = 'rust') THEN 1 ELSE 0 END
+FROM POSTS;
+
+DROP TABLE post_tags;
+DROP TABLE tags;
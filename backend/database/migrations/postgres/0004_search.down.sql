@@ -0,0 +1,5 @@
+DROP INDEX comments_search_vector;
+ALTER TABLE COMMENTS DROP COLUMN SearchVector;
+
+DROP INDEX posts_search_vector;
+ALTER TABLE POSTS DROP COLUMN SearchVector;
@@ -0,0 +1,12 @@
+ALTER TABLE POSTS ADD COLUMN SearchVector tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', coalesce(Title, '')), 'A') ||
+        setweight(to_tsvector('simple', coalesce(Content, '')), 'B')
+    ) STORED;
+
+CREATE INDEX posts_search_vector ON POSTS USING GIN (SearchVector);
+
+ALTER TABLE COMMENTS ADD COLUMN SearchVector tsvector
+    GENERATED ALWAYS AS (setweight(to_tsvector('simple', coalesce(Comment, '')), 'C')) STORED;
+
+CREATE INDEX comments_search_vector ON COMMENTS USING GIN (SearchVector);
@@ -0,0 +1,3 @@
+ALTER TABLE USERS ADD COLUMN session_token TEXT;
+
+DROP TABLE sessions;
@@ -0,0 +1,15 @@
+-- Existing single-token logins are dropped; users sign in again once.
+CREATE TABLE sessions (
+    ID SERIAL PRIMARY KEY,
+    UserID INTEGER NOT NULL REFERENCES USERS(ID),
+    TokenHash TEXT NOT NULL UNIQUE,
+    UserAgent TEXT NOT NULL DEFAULT '',
+    IP TEXT NOT NULL DEFAULT '',
+    CreatedAt TIMESTAMPTZ NOT NULL,
+    LastSeenAt TIMESTAMPTZ NOT NULL,
+    ExpiresAt TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX sessions_user ON sessions (UserID);
+
+ALTER TABLE USERS DROP COLUMN session_token;
@@ -0,0 +1,3 @@
+ALTER TABLE oauth_states DROP COLUMN LinkUserID;
+
+DROP TABLE user_identities;
@@ -0,0 +1,16 @@
+-- The accounts at OAuth providers each user can log in with. Accounts made
+-- by OAuth before this have none yet; they are linked on their next login.
+CREATE TABLE user_identities (
+    ID SERIAL PRIMARY KEY,
+    UserID INTEGER NOT NULL REFERENCES USERS(ID),
+    Provider TEXT NOT NULL,
+    Subject TEXT NOT NULL,
+    Email TEXT NOT NULL DEFAULT '',
+    CreatedAt TIMESTAMPTZ NOT NULL,
+    UNIQUE (Provider, Subject)
+);
+
+CREATE INDEX user_identities_user ON user_identities (UserID);
+
+-- Non-zero when the login links a provider to an existing account.
+ALTER TABLE oauth_states ADD COLUMN LinkUserID INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,20 @@
+-- TOTP secrets. A row with Enabled = FALSE is a setup that hasn't been
+-- confirmed with a code yet. LastStep is the newest time step a code was
+-- accepted for, so a code can't be used twice.
+CREATE TABLE two_factor (
+    UserID INTEGER PRIMARY KEY REFERENCES USERS(ID),
+    Secret TEXT NOT NULL,
+    Enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    LastStep INTEGER NOT NULL DEFAULT 0,
+    CreatedAt TIMESTAMPTZ NOT NULL
+);
+
+-- Single-use codes for when the authenticator is lost. Only hashes are kept.
+CREATE TABLE recovery_codes (
+    ID SERIAL PRIMARY KEY,
+    UserID INTEGER NOT NULL REFERENCES USERS(ID),
+    CodeHash TEXT NOT NULL,
+    UsedAt TIMESTAMPTZ
+);
+
+CREATE INDEX recovery_codes_user ON recovery_codes (UserID);
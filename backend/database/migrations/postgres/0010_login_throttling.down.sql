@@ -0,0 +1,5 @@
+ALTER TABLE USERS DROP COLUMN LockedUntil;
+
+DROP TABLE rate_limits;
+
+DROP TABLE login_failures;
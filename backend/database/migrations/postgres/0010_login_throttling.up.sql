@@ -0,0 +1,24 @@
+-- Failed logins, kept for auditing. UserID is 0 when the email matched no
+-- account.
+CREATE TABLE login_failures (
+    ID SERIAL PRIMARY KEY,
+    UserID INTEGER NOT NULL DEFAULT 0,
+    Email TEXT NOT NULL,
+    IP TEXT NOT NULL,
+    UserAgent TEXT NOT NULL DEFAULT '',
+    Reason TEXT NOT NULL,
+    CreatedAt TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX login_failures_user ON login_failures (UserID);
+
+-- Failure counters of the database-backed login limiter, keyed by IP or
+-- account.
+CREATE TABLE rate_limits (
+    LimitKey TEXT PRIMARY KEY,
+    Failures INTEGER NOT NULL,
+    LastFailure TIMESTAMPTZ NOT NULL
+);
+
+-- Set while the account is locked after too many failed logins.
+ALTER TABLE USERS ADD COLUMN LockedUntil TIMESTAMPTZ;
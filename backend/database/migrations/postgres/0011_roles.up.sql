@@ -0,0 +1,3 @@
+UPDATE USERS SET Role = LOWER(Role) WHERE LOWER(Role) IN ('user', 'moderator', 'admin');
+
+UPDATE USERS SET Role = 'user' WHERE Role IS NULL OR Role NOT IN ('user', 'moderator', 'admin');
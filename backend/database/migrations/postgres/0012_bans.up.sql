@@ -0,0 +1,14 @@
+-- Every ban ever placed, so the history stays after one is lifted. A user
+-- is banned while they have a row with no LiftedAt.
+CREATE TABLE bans (
+    ID SERIAL PRIMARY KEY,
+    UserID INTEGER NOT NULL,
+    Reason TEXT NOT NULL DEFAULT '',
+    BannedBy INTEGER NOT NULL,
+    CreatedAt TIMESTAMPTZ NOT NULL,
+    LiftedAt TIMESTAMPTZ,
+    LiftedBy INTEGER,
+    FOREIGN KEY(UserID) REFERENCES USERS(ID)
+);
+
+CREATE INDEX bans_user ON bans (UserID);
@@ -0,0 +1,24 @@
+-- Every earlier version of a post or comment (IsComment picks which TargetID
+-- is), with who wrote that version and when. Tags are the post's tag slugs,
+-- separated by spaces; comments have no Title or Tags.
+CREATE TABLE revisions (
+    ID SERIAL PRIMARY KEY,
+    TargetID INTEGER NOT NULL,
+    IsComment BOOLEAN NOT NULL,
+    Title TEXT NOT NULL DEFAULT '',
+    Body TEXT NOT NULL,
+    Tags TEXT NOT NULL DEFAULT '',
+    EditorID INTEGER NOT NULL,
+    CreatedAt TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX revisions_target ON revisions (TargetID, IsComment);
+
+-- Who made the current version and when, if it isn't the original.
+ALTER TABLE POSTS ADD COLUMN EditedAt TIMESTAMPTZ;
+
+ALTER TABLE POSTS ADD COLUMN EditedBy INTEGER;
+
+ALTER TABLE COMMENTS ADD COLUMN EditedAt TIMESTAMPTZ;
+
+ALTER TABLE COMMENTS ADD COLUMN EditedBy INTEGER;
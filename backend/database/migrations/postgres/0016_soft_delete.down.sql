@@ -0,0 +1,11 @@
+DROP INDEX comments_deleted;
+
+DROP INDEX posts_deleted;
+
+ALTER TABLE COMMENTS DROP COLUMN DeletedBy;
+
+ALTER TABLE COMMENTS DROP COLUMN DeletedAt;
+
+ALTER TABLE POSTS DROP COLUMN DeletedBy;
+
+ALTER TABLE POSTS DROP COLUMN DeletedAt;
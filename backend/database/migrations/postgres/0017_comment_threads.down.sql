@@ -0,0 +1,3 @@
+DROP INDEX comments_parent;
+
+ALTER TABLE COMMENTS DROP COLUMN ParentID;
@@ -0,0 +1,4 @@
+-- The comment a comment replies to; top-level comments have none.
+ALTER TABLE COMMENTS ADD COLUMN ParentID INTEGER;
+
+CREATE INDEX comments_parent ON COMMENTS (ParentID);
@@ -0,0 +1,7 @@
+-- Questions can have one of their comments accepted as the answer.
+ALTER TABLE POSTS ADD COLUMN IsQuestion BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE POSTS ADD COLUMN AcceptedCommentID INTEGER;
+
+-- What users have earned; an accepted answer earns its author points.
+ALTER TABLE USERS ADD COLUMN Reputation INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,6 @@
+-- Only the latest report of each user on each target is kept.
+DROP INDEX reports_open;
+
+DELETE FROM reports WHERE ID NOT IN (SELECT MAX(ID) FROM reports GROUP BY ReporterID, TargetID, IsComment);
+
+ALTER TABLE reports ADD UNIQUE (ReporterID, TargetID, IsComment);
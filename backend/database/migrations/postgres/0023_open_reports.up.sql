@@ -0,0 +1,6 @@
+-- A user can report the same thing again once their earlier report has been
+-- resolved, since it may have been edited since; only one report of theirs
+-- can be open at a time.
+ALTER TABLE reports DROP CONSTRAINT reports_reporterid_targetid_iscomment_key;
+
+CREATE UNIQUE INDEX reports_open ON reports (ReporterID, TargetID, IsComment) WHERE ResolvedAt IS NULL;
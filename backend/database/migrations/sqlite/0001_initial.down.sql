@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS CATEGORIES;
+DROP TABLE IF EXISTS USERLIKES;
+DROP TABLE IF EXISTS COMMENTS;
+DROP TABLE IF EXISTS POSTS;
+DROP TABLE IF EXISTS USERS;
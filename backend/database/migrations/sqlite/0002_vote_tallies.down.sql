@@ -0,0 +1,4 @@
+ALTER TABLE COMMENTS DROP COLUMN DownCount;
+ALTER TABLE COMMENTS DROP COLUMN UpCount;
+ALTER TABLE POSTS DROP COLUMN DownCount;
+ALTER TABLE POSTS DROP COLUMN UpCount;
@@ -0,0 +1,14 @@
+ALTER TABLE POSTS ADD COLUMN UpCount INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE POSTS ADD COLUMN DownCount INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE COMMENTS ADD COLUMN UpCount INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE COMMENTS ADD COLUMN DownCount INTEGER NOT NULL DEFAULT 0;
+
+UPDATE POSTS SET
+    UpCount = (SELECT COUNT(*) FROM USERLIKES WHERE USERLIKES.PostID = POSTS.ID AND USERLIKES.IsComment = 0 AND USERLIKES.Liked = 1),
+    DownCount = (SELECT COUNT(*) FROM USERLIKES WHERE USERLIKES.PostID = POSTS.ID AND USERLIKES.IsComment = 0 AND USERLIKES.Disliked = 1);
+UPDATE POSTS SET LikeCount = UpCount - DownCount;
+
+UPDATE COMMENTS SET
+    UpCount = (SELECT COUNT(*) FROM USERLIKES WHERE USERLIKES.PostID = COMMENTS.ID AND USERLIKES.IsComment = 1 AND USERLIKES.Liked = 1),
+    DownCount = (SELECT COUNT(*) FROM USERLIKES WHERE USERLIKES.PostID = COMMENTS.ID AND USERLIKES.IsComment = 1 AND USERLIKES.Disliked = 1);
+UPDATE COMMENTS SET LikeCount = UpCount - DownCount;
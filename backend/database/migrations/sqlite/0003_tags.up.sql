@@ -0,0 +1,54 @@
+CREATE TABLE tags (
+    ID INTEGER PRIMARY KEY AUTOINCREMENT,
+    Slug TEXT NOT NULL UNIQUE,
+    Name TEXT NOT NULL,
+    Description TEXT NOT NULL DEFAULT '',
+    Color TEXT NOT NULL DEFAULT '#006989',
+    CreatedBy INTEGER,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE post_tags (
+    PostID INTEGER NOT NULL,
+    TagID INTEGER NOT NULL,
+    PRIMARY KEY (PostID, TagID)
+);
+
+CREATE INDEX post_tags_tag ON post_tags (TagID);
+
+INSERT INTO tags (Slug, Name, Color) VALUES
+    ('go', 'Go', '#00ADD8'),
+    ('html', 'HTML', '#E34C26'),
+    ('css', 'CSS', '#563D7C'),
+    ('php', 'PHP', '#4F5D95'),
+    ('python', 'Python', '#3572A5'),
+    ('c', 'C', '#555555'),
+    ('cpp', 'C++', '#F34B7D'),
+    ('csharp', 'C#', '#178600'),
+    ('js', 'JavaScript', '#F1E05A'),
+    ('assembly', 'Assembly', '#6E4C13'),
+    ('react', 'React', '#61DAFB'),
+    ('flutter', 'Flutter', '#02569B'),
+    ('rust', 'Rust', '#DEA584');
+
+INSERT INTO post_tags (PostID, TagID)
+SELECT CATEGORIES.PostID, tags.ID
+FROM CATEGORIES
+JOIN tags ON
+    (tags.Slug = 'go' AND CATEGORIES.GO = 1)
+    OR (tags.Slug = 'html' AND CATEGORIES.HTML = 1)
+    OR (tags.Slug = 'css' AND CATEGORIES.CSS = 1)
+    OR (tags.Slug = 'php' AND CATEGORIES.PHP = 1)
+    OR (tags.Slug = 'python' AND CATEGORIES.PYTHON = 1)
+    OR (tags.Slug = 'c' AND CATEGORIES.C = 1)
+    OR (tags.Slug = 'cpp' AND CATEGORIES.CPP = 1)
+    OR (tags.Slug = 'csharp' AND CATEGORIES.CSHARP = 1)
+    OR (tags.Slug = 'js' AND CATEGORIES.JS = 1)
+    OR (tags.Slug = 'assembly' AND CATEGORIES.ASSEMBLY = 1)
+    OR (tags.Slug = 'react' AND CATEGORIES.REACT = 1)
+    OR (tags.Slug = 'flutter' AND CATEGORIES.FLUTTER = 1)
+    OR (tags.Slug = 'rust' AND CATEGORIES.RUST = 1)
+WHERE CATEGORIES.PostID IN (SELECT ID FROM POSTS)
+ON CONFLICT DO NOTHING;
+
+DROP TABLE CATEGORIES;
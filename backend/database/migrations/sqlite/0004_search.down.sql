@@ -0,0 +1,7 @@
+DROP TRIGGER comments_fts_delete;
+DROP TRIGGER comments_fts_update;
+DROP TRIGGER comments_fts_insert;
+DROP TRIGGER posts_fts_delete;
+DROP TRIGGER posts_fts_update;
+DROP TRIGGER posts_fts_insert;
+DROP TABLE posts_fts;
@@ -0,0 +1,40 @@
+CREATE VIRTUAL TABLE posts_fts USING fts5(
+    Title,
+    Content,
+    Comments,
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+INSERT INTO posts_fts (rowid, Title, Content, Comments)
+SELECT ID, Title, Content, COALESCE((SELECT group_concat(Comment, ' ') FROM COMMENTS WHERE COMMENTS.PostId = POSTS.ID), '')
+FROM POSTS;
+
+CREATE TRIGGER posts_fts_insert AFTER INSERT ON POSTS BEGIN
+    INSERT INTO posts_fts (rowid, Title, Content, Comments) VALUES (NEW.ID, NEW.Title, NEW.Content, '');
+END;
+
+CREATE TRIGGER posts_fts_update AFTER UPDATE OF Title, Content ON POSTS BEGIN
+    UPDATE posts_fts SET Title = NEW.Title, Content = NEW.Content WHERE rowid = NEW.ID;
+END;
+
+CREATE TRIGGER posts_fts_delete AFTER DELETE ON POSTS BEGIN
+    DELETE FROM posts_fts WHERE rowid = OLD.ID;
+END;
+
+CREATE TRIGGER comments_fts_insert AFTER INSERT ON COMMENTS BEGIN
+    UPDATE posts_fts
+    SET Comments = COALESCE((SELECT group_concat(Comment, ' ') FROM COMMENTS WHERE PostId = NEW.PostId), '')
+    WHERE rowid = NEW.PostId;
+END;
+
+CREATE TRIGGER comments_fts_update AFTER UPDATE OF Comment, PostId ON COMMENTS BEGIN
+    UPDATE posts_fts
+    SET Comments = COALESCE((SELECT group_concat(Comment, ' ') FROM COMMENTS WHERE PostId = posts_fts.rowid), '')
+    WHERE rowid IN (OLD.PostId, NEW.PostId);
+END;
+
+CREATE TRIGGER comments_fts_delete AFTER DELETE ON COMMENTS BEGIN
+    UPDATE posts_fts
+    SET Comments = COALESCE((SELECT group_concat(Comment, ' ') FROM COMMENTS WHERE PostId = OLD.PostId), '')
+    WHERE rowid = OLD.PostId;
+END;
@@ -0,0 +1,16 @@
+-- Existing single-token logins are dropped; users sign in again once.
+CREATE TABLE sessions (
+    ID INTEGER PRIMARY KEY AUTOINCREMENT,
+    UserID INTEGER NOT NULL,
+    TokenHash TEXT NOT NULL UNIQUE,
+    UserAgent TEXT NOT NULL DEFAULT '',
+    IP TEXT NOT NULL DEFAULT '',
+    CreatedAt TIMESTAMP NOT NULL,
+    LastSeenAt TIMESTAMP NOT NULL,
+    ExpiresAt TIMESTAMP NOT NULL,
+    FOREIGN KEY(UserID) REFERENCES USERS(ID)
+);
+
+CREATE INDEX sessions_user ON sessions (UserID);
+
+ALTER TABLE USERS DROP COLUMN session_token;
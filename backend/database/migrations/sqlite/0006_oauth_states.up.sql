@@ -0,0 +1,8 @@
+-- A row per login sent to an OAuth provider and not yet returned.
+CREATE TABLE oauth_states (
+    StateHash TEXT PRIMARY KEY,
+    Provider TEXT NOT NULL,
+    CodeVerifier TEXT NOT NULL,
+    Nonce TEXT NOT NULL,
+    ExpiresAt TIMESTAMP NOT NULL
+);
@@ -0,0 +1,4 @@
+ALTER TABLE USERS ADD COLUMN EmailVerified BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Providers only hand out addresses they have verified.
+UPDATE USERS SET EmailVerified = TRUE WHERE ID IN (SELECT UserID FROM user_identities);
@@ -0,0 +1,22 @@
+-- TOTP secrets. A row with Enabled = FALSE is a setup that hasn't been
+-- confirmed with a code yet. LastStep is the newest time step a code was
+-- accepted for, so a code can't be used twice.
+CREATE TABLE two_factor (
+    UserID INTEGER PRIMARY KEY,
+    Secret TEXT NOT NULL,
+    Enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    LastStep INTEGER NOT NULL DEFAULT 0,
+    CreatedAt TIMESTAMP NOT NULL,
+    FOREIGN KEY(UserID) REFERENCES USERS(ID)
+);
+
+-- Single-use codes for when the authenticator is lost. Only hashes are kept.
+CREATE TABLE recovery_codes (
+    ID INTEGER PRIMARY KEY AUTOINCREMENT,
+    UserID INTEGER NOT NULL,
+    CodeHash TEXT NOT NULL,
+    UsedAt TIMESTAMP,
+    FOREIGN KEY(UserID) REFERENCES USERS(ID)
+);
+
+CREATE INDEX recovery_codes_user ON recovery_codes (UserID);
@@ -0,0 +1,5 @@
+UPDATE USERS SET Role = 'User' WHERE Role = 'user';
+
+UPDATE USERS SET Role = 'Moderator' WHERE Role = 'moderator';
+
+UPDATE USERS SET Role = 'Admin' WHERE Role = 'admin';
@@ -0,0 +1,5 @@
+ALTER TABLE COMMENTS DROP COLUMN Hidden;
+
+ALTER TABLE POSTS DROP COLUMN Hidden;
+
+DROP TABLE reports;
@@ -0,0 +1,23 @@
+-- Reports of posts and comments (IsComment picks which TargetID is). Each
+-- user can report the same thing once; a report stays open until a
+-- moderator resolves it.
+CREATE TABLE reports (
+    ID INTEGER PRIMARY KEY AUTOINCREMENT,
+    ReporterID INTEGER NOT NULL,
+    TargetID INTEGER NOT NULL,
+    IsComment BOOLEAN NOT NULL,
+    Reason TEXT NOT NULL,
+    Details TEXT NOT NULL DEFAULT '',
+    CreatedAt TIMESTAMP NOT NULL,
+    ResolvedAt TIMESTAMP,
+    ResolvedBy INTEGER,
+    Resolution TEXT NOT NULL DEFAULT '',
+    UNIQUE (ReporterID, TargetID, IsComment)
+);
+
+CREATE INDEX reports_target ON reports (TargetID, IsComment);
+
+-- Hidden content is left out of listings until a moderator looks at it.
+ALTER TABLE POSTS ADD COLUMN Hidden BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE COMMENTS ADD COLUMN Hidden BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,9 @@
+DROP TABLE address_bans;
+
+ALTER TABLE COMMENTS DROP COLUMN Shadow;
+
+ALTER TABLE POSTS DROP COLUMN Shadow;
+
+ALTER TABLE bans DROP COLUMN Shadow;
+
+ALTER TABLE bans DROP COLUMN ExpiresAt;
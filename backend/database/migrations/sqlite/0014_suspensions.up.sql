@@ -0,0 +1,21 @@
+-- A ban with an ExpiresAt is a suspension and ends by itself. A shadow ban
+-- lets the user carry on, but what they post is only shown to them.
+ALTER TABLE bans ADD COLUMN ExpiresAt TIMESTAMP;
+
+ALTER TABLE bans ADD COLUMN Shadow BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE POSTS ADD COLUMN Shadow BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE COMMENTS ADD COLUMN Shadow BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Registrations and logins are refused from these addresses or CIDR ranges
+-- (Kind 'ip') and for emails at these domains (Kind 'domain').
+CREATE TABLE address_bans (
+    ID INTEGER PRIMARY KEY AUTOINCREMENT,
+    Kind TEXT NOT NULL,
+    Value TEXT NOT NULL,
+    Reason TEXT NOT NULL DEFAULT '',
+    CreatedBy INTEGER NOT NULL,
+    CreatedAt TIMESTAMP NOT NULL,
+    UNIQUE (Kind, Value)
+);
@@ -0,0 +1,9 @@
+ALTER TABLE COMMENTS DROP COLUMN EditedBy;
+
+ALTER TABLE COMMENTS DROP COLUMN EditedAt;
+
+ALTER TABLE POSTS DROP COLUMN EditedBy;
+
+ALTER TABLE POSTS DROP COLUMN EditedAt;
+
+DROP TABLE revisions;
@@ -0,0 +1,13 @@
+-- Deleted posts and comments stay in the trash, where they can be restored,
+-- until the purge job removes them for good.
+ALTER TABLE POSTS ADD COLUMN DeletedAt TIMESTAMP;
+
+ALTER TABLE POSTS ADD COLUMN DeletedBy INTEGER;
+
+ALTER TABLE COMMENTS ADD COLUMN DeletedAt TIMESTAMP;
+
+ALTER TABLE COMMENTS ADD COLUMN DeletedBy INTEGER;
+
+CREATE INDEX posts_deleted ON POSTS (DeletedAt);
+
+CREATE INDEX comments_deleted ON COMMENTS (DeletedAt);
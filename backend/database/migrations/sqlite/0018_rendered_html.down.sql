@@ -0,0 +1,3 @@
+ALTER TABLE COMMENTS DROP COLUMN CommentHTML;
+
+ALTER TABLE POSTS DROP COLUMN ContentHTML;
@@ -0,0 +1,6 @@
+-- The HTML rendered from the Markdown of posts and comments, kept so it
+-- isn't rendered again on every view. Rows from before are rendered on
+-- startup.
+ALTER TABLE POSTS ADD COLUMN ContentHTML TEXT;
+
+ALTER TABLE COMMENTS ADD COLUMN CommentHTML TEXT;
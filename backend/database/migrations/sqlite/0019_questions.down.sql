@@ -0,0 +1,5 @@
+ALTER TABLE USERS DROP COLUMN Reputation;
+
+ALTER TABLE POSTS DROP COLUMN AcceptedCommentID;
+
+ALTER TABLE POSTS DROP COLUMN IsQuestion;
@@ -0,0 +1,5 @@
+DROP TABLE reputation_events;
+
+-- Before the ledger only accepted answers earned reputation.
+UPDATE USERS SET Reputation = 15 * (SELECT COUNT(*) FROM POSTS INNER JOIN COMMENTS ON COMMENTS.ID = POSTS.AcceptedCommentID
+    WHERE COMMENTS.UserId = USERS.ID AND POSTS.UserID <> USERS.ID);
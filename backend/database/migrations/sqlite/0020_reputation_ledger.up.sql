@@ -0,0 +1,38 @@
+-- Every change to a user's reputation: Points for UserID because of what
+-- ActorID did to the post or comment TargetID. USERS.Reputation is the sum.
+CREATE TABLE reputation_events (
+    ID INTEGER PRIMARY KEY AUTOINCREMENT,
+    UserID INTEGER NOT NULL,
+    Points INTEGER NOT NULL,
+    Reason TEXT NOT NULL,
+    TargetID INTEGER NOT NULL DEFAULT 0,
+    IsComment BOOLEAN NOT NULL DEFAULT FALSE,
+    ActorID INTEGER NOT NULL DEFAULT 0,
+    Note TEXT NOT NULL DEFAULT '',
+    CreatedAt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX reputation_events_user ON reputation_events (UserID, ID);
+
+CREATE INDEX reputation_events_target ON reputation_events (TargetID, IsComment);
+
+-- The votes and accepted answers so far, with the points they earned when
+-- the ledger was added. Votes on your own posts and comments earn nothing.
+INSERT INTO reputation_events (UserID, Points, Reason, TargetID, IsComment, ActorID)
+SELECT POSTS.UserID, CASE WHEN USERLIKES.Liked THEN 10 ELSE -2 END, CASE WHEN USERLIKES.Liked THEN 'upvote' ELSE 'downvote' END,
+    POSTS.ID, FALSE, USERLIKES.UserID
+FROM USERLIKES INNER JOIN POSTS ON POSTS.ID = USERLIKES.PostID
+WHERE NOT USERLIKES.IsComment AND (USERLIKES.Liked OR USERLIKES.Disliked) AND POSTS.UserID <> USERLIKES.UserID;
+
+INSERT INTO reputation_events (UserID, Points, Reason, TargetID, IsComment, ActorID)
+SELECT COMMENTS.UserId, CASE WHEN USERLIKES.Liked THEN 10 ELSE -2 END, CASE WHEN USERLIKES.Liked THEN 'upvote' ELSE 'downvote' END,
+    COMMENTS.ID, TRUE, USERLIKES.UserID
+FROM USERLIKES INNER JOIN COMMENTS ON COMMENTS.ID = USERLIKES.PostID
+WHERE USERLIKES.IsComment AND (USERLIKES.Liked OR USERLIKES.Disliked) AND COMMENTS.UserId <> USERLIKES.UserID AND COMMENTS.UserId <> 0;
+
+INSERT INTO reputation_events (UserID, Points, Reason, TargetID, IsComment, ActorID)
+SELECT COMMENTS.UserId, 15, 'accepted', COMMENTS.ID, TRUE, POSTS.UserID
+FROM POSTS INNER JOIN COMMENTS ON COMMENTS.ID = POSTS.AcceptedCommentID
+WHERE COMMENTS.UserId <> POSTS.UserID AND COMMENTS.UserId <> 0;
+
+UPDATE USERS SET Reputation = COALESCE((SELECT SUM(Points) FROM reputation_events WHERE reputation_events.UserID = USERS.ID), 0);
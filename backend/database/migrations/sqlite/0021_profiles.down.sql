@@ -0,0 +1,11 @@
+ALTER TABLE USERS DROP COLUMN CreatedAt;
+
+ALTER TABLE USERS DROP COLUMN Avatar;
+
+ALTER TABLE USERS DROP COLUMN Location;
+
+ALTER TABLE USERS DROP COLUMN Website;
+
+ALTER TABLE USERS DROP COLUMN Bio;
+
+ALTER TABLE USERS DROP COLUMN DisplayName;
@@ -0,0 +1,14 @@
+-- What users tell others about themselves on their public profile. Avatar
+-- is the path of an uploaded image under /uploads/.
+ALTER TABLE USERS ADD COLUMN DisplayName TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE USERS ADD COLUMN Bio TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE USERS ADD COLUMN Website TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE USERS ADD COLUMN Location TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE USERS ADD COLUMN Avatar TEXT NOT NULL DEFAULT '';
+
+-- When the account was created; unknown for accounts older than this.
+ALTER TABLE USERS ADD COLUMN CreatedAt TIMESTAMP;
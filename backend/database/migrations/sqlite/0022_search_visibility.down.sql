@@ -0,0 +1,24 @@
+DROP TRIGGER comments_fts_insert;
+DROP TRIGGER comments_fts_update;
+DROP TRIGGER comments_fts_delete;
+
+CREATE TRIGGER comments_fts_insert AFTER INSERT ON COMMENTS BEGIN
+    UPDATE posts_fts
+    SET Comments = COALESCE((SELECT group_concat(Comment, ' ') FROM COMMENTS WHERE PostId = NEW.PostId), '')
+    WHERE rowid = NEW.PostId;
+END;
+
+CREATE TRIGGER comments_fts_update AFTER UPDATE OF Comment, PostId ON COMMENTS BEGIN
+    UPDATE posts_fts
+    SET Comments = COALESCE((SELECT group_concat(Comment, ' ') FROM COMMENTS WHERE PostId = posts_fts.rowid), '')
+    WHERE rowid IN (OLD.PostId, NEW.PostId);
+END;
+
+CREATE TRIGGER comments_fts_delete AFTER DELETE ON COMMENTS BEGIN
+    UPDATE posts_fts
+    SET Comments = COALESCE((SELECT group_concat(Comment, ' ') FROM COMMENTS WHERE PostId = OLD.PostId), '')
+    WHERE rowid = OLD.PostId;
+END;
+
+UPDATE posts_fts
+SET Comments = COALESCE((SELECT group_concat(Comment, ' ') FROM COMMENTS WHERE PostId = posts_fts.rowid), '');
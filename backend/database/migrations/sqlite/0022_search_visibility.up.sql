@@ -0,0 +1,31 @@
+-- Only comments the public sees are indexed with their post, so search
+-- never matches or quotes comments that are in the trash, hidden by reports
+-- or written during a shadow ban.
+DROP TRIGGER comments_fts_insert;
+DROP TRIGGER comments_fts_update;
+DROP TRIGGER comments_fts_delete;
+
+CREATE TRIGGER comments_fts_insert AFTER INSERT ON COMMENTS BEGIN
+    UPDATE posts_fts
+    SET Comments = COALESCE((SELECT group_concat(Comment, ' ') FROM COMMENTS
+        WHERE PostId = NEW.PostId AND Hidden = FALSE AND Shadow = FALSE AND DeletedAt IS NULL), '')
+    WHERE rowid = NEW.PostId;
+END;
+
+CREATE TRIGGER comments_fts_update AFTER UPDATE OF Comment, PostId, Hidden, Shadow, DeletedAt ON COMMENTS BEGIN
+    UPDATE posts_fts
+    SET Comments = COALESCE((SELECT group_concat(Comment, ' ') FROM COMMENTS
+        WHERE PostId = posts_fts.rowid AND Hidden = FALSE AND Shadow = FALSE AND DeletedAt IS NULL), '')
+    WHERE rowid IN (OLD.PostId, NEW.PostId);
+END;
+
+CREATE TRIGGER comments_fts_delete AFTER DELETE ON COMMENTS BEGIN
+    UPDATE posts_fts
+    SET Comments = COALESCE((SELECT group_concat(Comment, ' ') FROM COMMENTS
+        WHERE PostId = OLD.PostId AND Hidden = FALSE AND Shadow = FALSE AND DeletedAt IS NULL), '')
+    WHERE rowid = OLD.PostId;
+END;
+
+UPDATE posts_fts
+SET Comments = COALESCE((SELECT group_concat(Comment, ' ') FROM COMMENTS
+    WHERE PostId = posts_fts.rowid AND Hidden = FALSE AND Shadow = FALSE AND DeletedAt IS NULL), '');
@@ -0,0 +1,24 @@
+-- Only the latest report of each user on each target is kept.
+CREATE TABLE reports_old (
+    ID INTEGER PRIMARY KEY AUTOINCREMENT,
+    ReporterID INTEGER NOT NULL,
+    TargetID INTEGER NOT NULL,
+    IsComment BOOLEAN NOT NULL,
+    Reason TEXT NOT NULL,
+    Details TEXT NOT NULL DEFAULT '',
+    CreatedAt TIMESTAMP NOT NULL,
+    ResolvedAt TIMESTAMP,
+    ResolvedBy INTEGER,
+    Resolution TEXT NOT NULL DEFAULT '',
+    UNIQUE (ReporterID, TargetID, IsComment)
+);
+
+INSERT INTO reports_old (ID, ReporterID, TargetID, IsComment, Reason, Details, CreatedAt, ResolvedAt, ResolvedBy, Resolution)
+SELECT ID, ReporterID, TargetID, IsComment, Reason, Details, CreatedAt, ResolvedAt, ResolvedBy, Resolution FROM reports
+WHERE ID IN (SELECT MAX(ID) FROM reports GROUP BY ReporterID, TargetID, IsComment);
+
+DROP TABLE reports;
+
+ALTER TABLE reports_old RENAME TO reports;
+
+CREATE INDEX reports_target ON reports (TargetID, IsComment);
@@ -0,0 +1,27 @@
+-- A user can report the same thing again once their earlier report has been
+-- resolved, since it may have been edited since; only one report of theirs
+-- can be open at a time. SQLite can't drop a table constraint, so the table
+-- is rebuilt without it.
+CREATE TABLE reports_new (
+    ID INTEGER PRIMARY KEY AUTOINCREMENT,
+    ReporterID INTEGER NOT NULL,
+    TargetID INTEGER NOT NULL,
+    IsComment BOOLEAN NOT NULL,
+    Reason TEXT NOT NULL,
+    Details TEXT NOT NULL DEFAULT '',
+    CreatedAt TIMESTAMP NOT NULL,
+    ResolvedAt TIMESTAMP,
+    ResolvedBy INTEGER,
+    Resolution TEXT NOT NULL DEFAULT ''
+);
+
+INSERT INTO reports_new (ID, ReporterID, TargetID, IsComment, Reason, Details, CreatedAt, ResolvedAt, ResolvedBy, Resolution)
+SELECT ID, ReporterID, TargetID, IsComment, Reason, Details, CreatedAt, ResolvedAt, ResolvedBy, Resolution FROM reports;
+
+DROP TABLE reports;
+
+ALTER TABLE reports_new RENAME TO reports;
+
+CREATE INDEX reports_target ON reports (TargetID, IsComment);
+
+CREATE UNIQUE INDEX reports_open ON reports (ReporterID, TargetID, IsComment) WHERE ResolvedAt IS NULL;
@@ -0,0 +1,55 @@
+package diff
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestLines(t *testing.T) {
+	for name, tc := range map[string]struct {
+		a, b string
+		want []Line
+	}{
+		"empty to text":    {"", "one\ntwo", []Line{{Insert, "one"}, {Insert, "two"}}},
+		"text to empty":    {"one\ntwo", "", []Line{{Delete, "one"}, {Delete, "two"}}},
+		"both empty":       {"", "", nil},
+		"crlf and lf":      {"one\r\ntwo\r\n", "one\ntwo\n", []Line{{Equal, "one"}, {Equal, "two"}}},
+		"trailing newline": {"one\ntwo\n", "one\ntwo", []Line{{Equal, "one"}, {Equal, "two"}}},
+		"changed middle": {"head\nold\nolder\ntail", "head\nnew\ntail",
+			[]Line{{Equal, "head"}, {Delete, "old"}, {Delete, "older"}, {Insert, "new"}, {Equal, "tail"}}},
+		"common run": {"gone\nkept\nalso kept", "kept\nalso kept\nadded",
+			[]Line{{Delete, "gone"}, {Equal, "kept"}, {Equal, "also kept"}, {Insert, "added"}}},
+		"blank lines": {"one\n\ntwo", "one\ntwo", []Line{{Equal, "one"}, {Delete, ""}, {Equal, "two"}}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if got := Lines(tc.a, tc.b); !slices.Equal(got, tc.want) {
+				t.Errorf("Lines(%q, %q) = %v, want %v", tc.a, tc.b, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestChanged(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want bool
+	}{
+		{"same\ntext", "same\ntext", false},
+		{"", "", false},
+		{"same\r\ntext\n", "same\ntext", false},
+		{"same", "same text", true},
+		{"", "new", true},
+	} {
+		if got := Changed(Lines(tc.a, tc.b)); got != tc.want {
+			t.Errorf("Changed(Lines(%q, %q)) = %v, want %v", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestOpString(t *testing.T) {
+	for op, want := range map[Op]string{Equal: "equal", Insert: "insert", Delete: "delete"} {
+		if got := op.String(); got != want {
+			t.Errorf("%d.String() = %q, want %q", op, got, want)
+		}
+	}
+}
@@ -3,44 +3,104 @@ package handlers
 import (
 	"net/http"
 
+	acceptanswer "forum/backend/controllers/acceptAnswer"
+	"forum/backend/controllers/admin"
 	createcomment "forum/backend/controllers/create/createComment"
 	createpost "forum/backend/controllers/create/createPost"
+	createtag "forum/backend/controllers/create/createTag"
 	deleteaccount "forum/backend/controllers/delete/deleteAccount"
 	deletecomment "forum/backend/controllers/delete/deleteComment"
+	deleteidentity "forum/backend/controllers/delete/deleteIdentity"
 	deletepost "forum/backend/controllers/delete/deletePost"
+	deletesession "forum/backend/controllers/delete/deleteSession"
+	editcomment "forum/backend/controllers/edit/editComment"
+	editpost "forum/backend/controllers/edit/editPost"
 	getallposts "forum/backend/controllers/get/getAllPosts"
+	getloginmethods "forum/backend/controllers/get/getLoginMethods"
 	getmycomments "forum/backend/controllers/get/getMyComments"
 	getmyposts "forum/backend/controllers/get/getMyPosts"
 	getmyvotedposts "forum/backend/controllers/get/getMyVotedPosts"
 	getpostandcomments "forum/backend/controllers/get/getPostAndComments"
+	getprofile "forum/backend/controllers/get/getProfile"
+	getreplies "forum/backend/controllers/get/getReplies"
+	getreputation "forum/backend/controllers/get/getReputation"
+	getrevisions "forum/backend/controllers/get/getRevisions"
 	getsearchedposts "forum/backend/controllers/get/getSearchedPosts"
+	getsessions "forum/backend/controllers/get/getSessions"
+	gettags "forum/backend/controllers/get/getTags"
 	"forum/backend/controllers/login"
 	"forum/backend/controllers/logout"
+	passwordreset "forum/backend/controllers/passwordReset"
+	"forum/backend/controllers/preview"
 	"forum/backend/controllers/register"
+	"forum/backend/controllers/report"
+	"forum/backend/controllers/trash"
+	twofactor "forum/backend/controllers/twoFactor"
+	unlockaccount "forum/backend/controllers/unlockAccount"
+	updatepassword "forum/backend/controllers/update/updatePassword"
+	updateprofile "forum/backend/controllers/update/updateProfile"
+	verifyemail "forum/backend/controllers/verifyEmail"
 	downvote "forum/backend/controllers/votes/downVote"
 	upvote "forum/backend/controllers/votes/upVote"
+	adminpage "forum/frontend/pages/adminPage"
 	createpostpage "forum/frontend/pages/createPostPage"
 	deleteaccountpage "forum/frontend/pages/deleteAccountPage"
 	loginpage "forum/frontend/pages/loginPage"
 	mainpage "forum/frontend/pages/mainPage"
+	passwordresetpage "forum/frontend/pages/passwordResetPage"
 	postpage "forum/frontend/pages/postPage"
 	mycommentspage "forum/frontend/pages/profile/myCommentsPage"
 	mypostspage "forum/frontend/pages/profile/myPostsPage"
 	myvotedpostspage "forum/frontend/pages/profile/myVotedPostsPage"
+	reputationpage "forum/frontend/pages/profile/reputationPage"
+	sessionspage "forum/frontend/pages/profile/sessionsPage"
+	settingspage "forum/frontend/pages/profile/settingsPage"
+	trashpage "forum/frontend/pages/profile/trashPage"
 	registerpage "forum/frontend/pages/registerPage"
+	revisionspage "forum/frontend/pages/revisionsPage"
 	searchedpostspage "forum/frontend/pages/searchedPostsPage"
+	tagspage "forum/frontend/pages/tagsPage"
+	unlockaccountpage "forum/frontend/pages/unlockAccountPage"
+	userpage "forum/frontend/pages/userPage"
+	verifyemailpage "forum/frontend/pages/verifyEmailPage"
 )
 
 func ImportHandlers() {
 	// API
 	http.HandleFunc("/api/register", register.Register)
 	http.HandleFunc("/api/login", login.Login)
+	http.HandleFunc("/api/logintwofactor", login.LoginTwoFactor)
 	http.HandleFunc("/api/logout", logout.Logout)
+	http.HandleFunc("/api/logouteverywhere", logout.LogoutEverywhere)
+	http.HandleFunc("/api/sessions", getsessions.GetSessions)
+	http.HandleFunc("/api/deletesession", deletesession.DeleteSession)
+	http.HandleFunc("/api/loginmethods", getloginmethods.GetLoginMethods)
+	http.HandleFunc("/api/deleteidentity", deleteidentity.DeleteIdentity)
+	http.HandleFunc("/api/updatepassword", updatepassword.UpdatePassword)
+	http.HandleFunc("/api/updateprofile", updateprofile.UpdateProfile)
+	http.HandleFunc("/api/twofactor", twofactor.GetTwoFactor)
+	http.HandleFunc("/api/setuptwofactor", twofactor.SetupTwoFactor)
+	http.HandleFunc("/api/enabletwofactor", twofactor.EnableTwoFactor)
+	http.HandleFunc("/api/disabletwofactor", twofactor.DisableTwoFactor)
+	http.HandleFunc("/api/regeneraterecoverycodes", twofactor.RegenerateRecoveryCodes)
+	http.HandleFunc("/api/verifyemail", verifyemail.VerifyEmail)
+	http.HandleFunc("/api/sendverification", verifyemail.SendVerification)
+	http.HandleFunc("/api/unlockaccount", unlockaccount.UnlockAccount)
+	http.HandleFunc("/api/unlockuser", unlockaccount.UnlockUser)
+	http.HandleFunc("/api/requestpasswordreset", passwordreset.RequestPasswordReset)
+	http.HandleFunc("/api/resetpassword", passwordreset.ResetPassword)
 	http.HandleFunc("/api/createpost", createpost.CreatePost)
 	http.HandleFunc("/api/createcomment", createcomment.CreateComment)
 	http.HandleFunc("/api/deleteaccount", deleteaccount.DeleteAccount)
 	http.HandleFunc("/api/deletepost", deletepost.DeletePost)
 	http.HandleFunc("/api/deletecomment", deletecomment.DeleteComment)
+	http.HandleFunc("/api/editpost", editpost.EditPost)
+	http.HandleFunc("/api/editcomment", editcomment.EditComment)
+	http.HandleFunc("/api/acceptanswer", acceptanswer.AcceptAnswer)
+	http.HandleFunc("/api/reputation", getreputation.GetReputation)
+	http.HandleFunc("/api/profile", getprofile.GetProfile)
+	http.HandleFunc("/api/revisions", getrevisions.GetRevisions)
+	http.HandleFunc("/api/replies", getreplies.GetReplies)
 	http.HandleFunc("/api/upvote", upvote.UpVote)
 	http.HandleFunc("/api/downvote", downvote.DownVote)
 	http.HandleFunc("/api/allposts", getallposts.GetAllPosts)
@@ -49,29 +109,107 @@ func ImportHandlers() {
 	http.HandleFunc("/api/mycomments", getmycomments.GetMyComments)
 	http.HandleFunc("/api/myvotedposts", getmyvotedposts.GetMyVotedPosts)
 	http.HandleFunc("/api/searchedposts", getsearchedposts.GetSearchedPosts)
+	http.HandleFunc("/api/tags", gettags.GetTags)
+	http.HandleFunc("/api/createtag", createtag.CreateTag)
+	http.HandleFunc("/api/updatetag", admin.UpdateTag)
+	http.HandleFunc("/api/deletetag", admin.DeleteTag)
+	http.HandleFunc("/api/adminusers", admin.GetUsers)
+	http.HandleFunc("/api/setrole", admin.SetRole)
+	http.HandleFunc("/api/banuser", admin.BanUser)
+	http.HandleFunc("/api/unbanuser", admin.UnbanUser)
+	http.HandleFunc("/api/revokesessions", admin.RevokeSessions)
+	http.HandleFunc("/api/adjustreputation", admin.AdjustReputation)
+	http.HandleFunc("/api/adminposts", admin.GetPosts)
+	http.HandleFunc("/api/admincomments", admin.GetComments)
+	http.HandleFunc("/api/deleteposts", admin.DeletePosts)
+	http.HandleFunc("/api/deletecomments", admin.DeleteComments)
+	http.HandleFunc("/api/restoreposts", admin.RestorePosts)
+	http.HandleFunc("/api/restorecomments", admin.RestoreComments)
+	http.HandleFunc("/api/trash", trash.GetTrash)
+	http.HandleFunc("/api/restore", trash.Restore)
+	http.HandleFunc("/api/preview", preview.Preview)
+	http.HandleFunc("/api/stats", admin.GetStats)
+	http.HandleFunc("/api/userbans", admin.GetUserBans)
+	http.HandleFunc("/api/addressbans", admin.GetAddressBans)
+	http.HandleFunc("/api/banaddress", admin.BanAddress)
+	http.HandleFunc("/api/deleteaddressban", admin.DeleteAddressBan)
+	http.HandleFunc("/api/report", report.Report)
+	http.HandleFunc("/api/reports", admin.GetReports)
+	http.HandleFunc("/api/resolvereport", admin.ResolveReport)
 
 	// Front-end
 	http.HandleFunc("/", mainpage.MainPage)
 	http.HandleFunc("/register", registerpage.RegisterPage)
 	http.HandleFunc("/login", loginpage.LoginPage)
+	http.HandleFunc("/twofactor", loginpage.TwoFactorLogin)
 	http.HandleFunc("/createpost", createpostpage.CreatePostPage)
 	http.HandleFunc("/post", postpage.PostPage)
+	http.HandleFunc("/user/", userpage.UserPage)
 	http.HandleFunc("/createcomment", postpage.PostPageCreateComment)
 	http.HandleFunc("/upvote", postpage.PostPageUpVote)
 	http.HandleFunc("/downvote", postpage.PostPageDownVote)
+	http.HandleFunc("/report", postpage.PostPageReport)
+	http.HandleFunc("/editpost", postpage.PostPageEditPost)
+	http.HandleFunc("/editcomment", postpage.PostPageEditComment)
+	http.HandleFunc("/acceptanswer", postpage.PostPageAcceptAnswer)
+	http.HandleFunc("/revisions", revisionspage.RevisionsPage)
 	http.HandleFunc("/deleteaccount", deleteaccountpage.DeleteAccountPage)
 	http.HandleFunc("/myposts", mypostspage.MyPostsPage)
 	http.HandleFunc("/deletepost", mypostspage.DeleteMyPost)
 	http.HandleFunc("/mycomments", mycommentspage.MyCommentsPage)
 	http.HandleFunc("/deletecomment", mycommentspage.DeleteMyComment)
 	http.HandleFunc("/myvotedposts", myvotedpostspage.MyVotedPostsPage)
+	http.HandleFunc("/sessions", sessionspage.SessionsPage)
+	http.HandleFunc("/deletesession", sessionspage.DeleteSession)
+	http.HandleFunc("/logouteverywhere", sessionspage.LogoutEverywhere)
+	http.HandleFunc("/trash", trashpage.TrashPage)
+	http.HandleFunc("/restore", trashpage.Restore)
+	http.HandleFunc("/reputation", reputationpage.ReputationPage)
+	http.HandleFunc("/settings", settingspage.SettingsPage)
+	http.HandleFunc("/settings/password", settingspage.UpdatePassword)
+	http.HandleFunc("/settings/profile", settingspage.ProfilePage)
+	http.HandleFunc("/settings/profile/update", settingspage.UpdateProfile)
+	http.HandleFunc("/settings/unlink", settingspage.UnlinkLogin)
+	http.HandleFunc("/settings/sendverification", settingspage.SendVerification)
+	http.HandleFunc("/settings/twofactor", settingspage.TwoFactorPage)
+	http.HandleFunc("/settings/twofactor/setup", settingspage.SetupTwoFactor)
+	http.HandleFunc("/settings/twofactor/enable", settingspage.EnableTwoFactor)
+	http.HandleFunc("/settings/twofactor/disable", settingspage.DisableTwoFactor)
+	http.HandleFunc("/settings/twofactor/recoverycodes", settingspage.RegenerateRecoveryCodes)
+	http.HandleFunc("/verifyemail", verifyemailpage.VerifyEmailPage)
+	http.HandleFunc("/unlockaccount", unlockaccountpage.UnlockAccountPage)
+	http.HandleFunc("/forgotpassword", passwordresetpage.ForgotPasswordPage)
+	http.HandleFunc("/resetpassword", passwordresetpage.ResetPasswordPage)
 	http.HandleFunc("/search", searchedpostspage.SearchedPostsPage)
-	http.HandleFunc("/login/google", login.HandleGoogleLogin)
-	http.HandleFunc("/callback/google", login.HandleGoogleCallback)
-	http.HandleFunc("/login/github", login.HandleGitHubLogin)
-	http.HandleFunc("/callback/github", login.HandleGitHubCallback)
-	http.HandleFunc("/login/facebook", login.HandleFacebookLogin)
-	http.HandleFunc("/callback/facebook", login.HandleFacebookCallback)
+	http.HandleFunc("/tags", tagspage.TagsPage)
+	http.HandleFunc("/admin", adminpage.Dashboard)
+	http.HandleFunc("/admin/reports", adminpage.ReportsPage)
+	http.HandleFunc("/admin/resolvereport", adminpage.ResolveReport)
+	http.HandleFunc("/admin/users", adminpage.UsersPage)
+	http.HandleFunc("/admin/setrole", adminpage.SetRole)
+	http.HandleFunc("/admin/ban", adminpage.BanUser)
+	http.HandleFunc("/admin/unban", adminpage.UnbanUser)
+	http.HandleFunc("/admin/unlock", adminpage.UnlockUser)
+	http.HandleFunc("/admin/adjustreputation", adminpage.AdjustReputation)
+	http.HandleFunc("/admin/revokesessions", adminpage.RevokeSessions)
+	http.HandleFunc("/admin/banhistory", adminpage.BanHistoryPage)
+	http.HandleFunc("/admin/bans", adminpage.BansPage)
+	http.HandleFunc("/admin/banaddress", adminpage.BanAddress)
+	http.HandleFunc("/admin/deleteaddressban", adminpage.DeleteAddressBan)
+	http.HandleFunc("/admin/posts", adminpage.PostsPage)
+	http.HandleFunc("/admin/deleteposts", adminpage.DeletePosts)
+	http.HandleFunc("/admin/comments", adminpage.CommentsPage)
+	http.HandleFunc("/admin/deletecomments", adminpage.DeleteComments)
+	http.HandleFunc("/admin/restore", adminpage.Restore)
+	http.HandleFunc("/admin/restoreposts", adminpage.RestorePosts)
+	http.HandleFunc("/admin/restorecomments", adminpage.RestoreComments)
+	http.HandleFunc("/admin/tags", adminpage.TagsPage)
+	http.HandleFunc("/admin/createtag", adminpage.CreateTag)
+	http.HandleFunc("/admin/updatetag", adminpage.UpdateTag)
+	http.HandleFunc("/admin/deletetag", adminpage.DeleteTag)
+	http.HandleFunc("/login/{provider}", login.HandleProviderLogin)
+	http.HandleFunc("/callback/{provider}", login.HandleProviderCallback)
+	http.HandleFunc("/link/{provider}", login.HandleProviderLink)
 
 	http.Handle("/uploads/", http.StripPrefix("/uploads/", http.FileServer(http.Dir("./uploads"))))
 
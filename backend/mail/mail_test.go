@@ -0,0 +1,139 @@
+package mail
+
+import (
+	"encoding/base64"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+)
+
+// delivery is what the fake SMTP server was told during one session.
+type delivery struct {
+	auth string
+	from string
+	to   []string
+	data string
+}
+
+// fakeSMTP is a local SMTP stand-in that speaks enough of the protocol for
+// net/smtp and reports each session on the returned channel.
+func fakeSMTP(t *testing.T) (string, <-chan delivery) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	deliveries := make(chan delivery, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		text := textproto.NewConn(conn)
+		var d delivery
+
+		text.PrintfLine("220 localhost ESMTP")
+		for {
+			line, err := text.ReadLine()
+			if err != nil {
+				return
+			}
+			verb, arg, _ := strings.Cut(line, " ")
+			switch strings.ToUpper(verb) {
+			case "EHLO", "HELO":
+				text.PrintfLine("250-localhost")
+				text.PrintfLine("250 AUTH PLAIN")
+			case "AUTH":
+				d.auth = arg
+				text.PrintfLine("235 Authenticated")
+			case "MAIL":
+				d.from = arg
+				text.PrintfLine("250 OK")
+			case "RCPT":
+				d.to = append(d.to, arg)
+				text.PrintfLine("250 OK")
+			case "DATA":
+				text.PrintfLine("354 Go ahead")
+				data, err := text.ReadDotBytes()
+				if err != nil {
+					return
+				}
+				d.data = string(data)
+				text.PrintfLine("250 Queued")
+			case "QUIT":
+				text.PrintfLine("221 Bye")
+				deliveries <- d
+				return
+			default:
+				text.PrintfLine("502 Not implemented")
+			}
+		}
+	}()
+	return listener.Addr().String(), deliveries
+}
+
+func TestSMTPSend(t *testing.T) {
+	addr, deliveries := fakeSMTP(t)
+	mailer := &SMTP{Addr: addr, Username: "forum", Password: "hunter2", From: "Forum <noreply@example.com>"}
+
+	err := mailer.Send(Message{To: "ada@example.com", Subject: "Welcome", Body: "Hello Ada,\nwelcome."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := <-deliveries
+
+	if d.from != "FROM:<noreply@example.com>" {
+		t.Errorf("MAIL %s, want FROM:<noreply@example.com>", d.from)
+	}
+	if len(d.to) != 1 || d.to[0] != "TO:<ada@example.com>" {
+		t.Errorf("RCPT %v, want TO:<ada@example.com>", d.to)
+	}
+	credentials, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(d.auth, "PLAIN "))
+	if string(credentials) != "\x00forum\x00hunter2" {
+		t.Errorf("AUTH sent %q, want the configured credentials", credentials)
+	}
+
+	header, body, _ := strings.Cut(d.data, "\n\n")
+	for _, want := range []string{"From: Forum <noreply@example.com>", "To: ada@example.com", "Subject: Welcome"} {
+		if !strings.Contains(header, want+"\n") {
+			t.Errorf("header lacks %q:\n%s", want, header)
+		}
+	}
+	if body != "Hello Ada,\nwelcome.\n" {
+		t.Errorf("body = %q", body)
+	}
+}
+
+func TestSMTPSubjectCannotAddHeaders(t *testing.T) {
+	addr, deliveries := fakeSMTP(t)
+	mailer := &SMTP{Addr: addr, From: "noreply@example.com"}
+
+	err := mailer.Send(Message{To: "ada@example.com", Subject: "Hi\r\nBcc: eve@example.com", Body: "Body"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := <-deliveries
+
+	header, _, _ := strings.Cut(d.data, "\n\n")
+	for _, line := range strings.Split(header, "\n") {
+		if strings.HasPrefix(line, "Bcc:") {
+			t.Fatalf("subject added a header line %q", line)
+		}
+	}
+	if len(d.to) != 1 {
+		t.Errorf("RCPT %v, want ada only", d.to)
+	}
+}
+
+func TestSMTPRefusesLineBreaksInAddress(t *testing.T) {
+	addr, _ := fakeSMTP(t)
+	mailer := &SMTP{Addr: addr, From: "noreply@example.com"}
+	err := mailer.Send(Message{To: "ada@example.com\r\nRCPT TO:<eve@example.com>", Subject: "Hi", Body: "Body"})
+	if err == nil {
+		t.Fatal("Send accepted a recipient with a line break")
+	}
+}
@@ -0,0 +1,158 @@
+package mail
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"forum/backend/config"
+	"forum/backend/controllers/structs"
+	"forum/backend/tokens"
+)
+
+const (
+	verifyEmailTTL   = 48 * time.Hour
+	resetPasswordTTL = time.Hour
+)
+
+// SendVerification emails the user a link that confirms their address.
+func SendVerification(user structs.User) error {
+	token := tokens.Sign(tokens.PurposeVerifyEmail, user.ID, user.Email, verifyEmailTTL)
+	link := config.Get().BaseURL + "/verifyemail?token=" + url.QueryEscape(token)
+	return Get().Send(verificationMessage(user.Email, user.UserName, link))
+}
+
+// SendPasswordReset emails the user a link to choose a new password. The
+// link stops working once the password has changed.
+func SendPasswordReset(user structs.User) error {
+	token := tokens.Sign(tokens.PurposeResetPassword, user.ID, user.Password, resetPasswordTTL)
+	link := config.Get().BaseURL + "/resetpassword?token=" + url.QueryEscape(token)
+	return Get().Send(passwordResetMessage(user.Email, user.UserName, link))
+}
+
+// SendAccountExists tells the user that someone tried to register with
+// their address, with a link to choose a new password in case it was them.
+func SendAccountExists(user structs.User) error {
+	token := tokens.Sign(tokens.PurposeResetPassword, user.ID, user.Password, resetPasswordTTL)
+	link := config.Get().BaseURL + "/resetpassword?token=" + url.QueryEscape(token)
+	return Get().Send(accountExistsMessage(user.Email, user.UserName, link))
+}
+
+// SendUnlock tells the user their account was locked after too many failed
+// logins, with a link that unlocks it. The link only works for this lock.
+func SendUnlock(user structs.User) error {
+	binding := strconv.FormatInt(user.LockedUntil.Unix(), 10)
+	token := tokens.Sign(tokens.PurposeUnlockAccount, user.ID, binding, time.Until(user.LockedUntil))
+	link := config.Get().BaseURL + "/unlockaccount?token=" + url.QueryEscape(token)
+	return Get().Send(unlockMessage(user.Email, user.UserName, user.LockedUntil, link))
+}
+
+// SendWarning tells the user that a moderator removed their post or comment
+// (what), passing on the moderator's note.
+func SendWarning(user structs.User, what, note string) error {
+	return Get().Send(warningMessage(user.Email, user.UserName, what, note))
+}
+
+// SendReportResolved tells the user who reported a post or comment (what)
+// what the moderators did about it.
+func SendReportResolved(user structs.User, what, outcome string) error {
+	return Get().Send(reportResolvedMessage(user.Email, user.UserName, what, outcome))
+}
+
+func verificationMessage(to, userName, link string) Message {
+	return Message{
+		To:      to,
+		Subject: "Confirm your email address",
+		Body: fmt.Sprintf(`Hi %s,
+
+please confirm your email address for Forum Ware by opening this link:
+
+%s
+
+If you didn't create an account, you can ignore this email.
+`, userName, link),
+	}
+}
+
+func passwordResetMessage(to, userName, link string) Message {
+	return Message{
+		To:      to,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(`Hi %s,
+
+someone asked to reset the password of your Forum Ware account. To choose a
+new password, open this link within the next hour:
+
+%s
+
+If it wasn't you, you can ignore this email; your password stays the same.
+`, userName, link),
+	}
+}
+
+func accountExistsMessage(to, userName, link string) Message {
+	return Message{
+		To:      to,
+		Subject: "You already have an account",
+		Body: fmt.Sprintf(`Hi %s,
+
+someone tried to sign up to Forum Ware with this email address, but it
+already belongs to your account. If it was you and you've forgotten your
+password, open this link within the next hour to choose a new one:
+
+%s
+
+If it wasn't you, you can ignore this email; nothing has changed.
+`, userName, link),
+	}
+}
+
+func unlockMessage(to, userName string, lockedUntil time.Time, link string) Message {
+	return Message{
+		To:      to,
+		Subject: "Your account has been locked",
+		Body: fmt.Sprintf(`Hi %s,
+
+there have been too many failed attempts to log in to your Forum Ware
+account, so logins are blocked until %s. If that was you, open this
+link to unlock your account now:
+
+%s
+
+If it wasn't you, someone may be guessing your password. Consider changing
+it once the account is unlocked.
+`, userName, lockedUntil.UTC().Format("2006-01-02 15:04 MST"), link),
+	}
+}
+
+func warningMessage(to, userName, what, note string) Message {
+	if note == "" {
+		note = "(no note)"
+	}
+	return Message{
+		To:      to,
+		Subject: "Your " + what + " has been removed",
+		Body: fmt.Sprintf(`Hi %s,
+
+a moderator has removed one of your %ss on Forum Ware after other users
+reported it. Their note:
+
+%s
+
+Please keep to the forum rules; further breaches may get your account banned.
+`, userName, what, note),
+	}
+}
+
+func reportResolvedMessage(to, userName, what, outcome string) Message {
+	return Message{
+		To:      to,
+		Subject: "Your report has been reviewed",
+		Body: fmt.Sprintf(`Hi %s,
+
+thank you for reporting a %s on Forum Ware. A moderator has looked at it:
+%s.
+`, userName, what, outcome),
+	}
+}
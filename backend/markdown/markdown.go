@@ -0,0 +1,70 @@
+// Package markdown turns the Markdown of posts and comments into HTML:
+// CommonMark with GitHub's tables, task lists, strikethrough and autolinks,
+// and fenced code blocks highlighted by their language. The HTML is
+// sanitized, so it can be shown as it is.
+package markdown
+
+import (
+	"bytes"
+	"html"
+	"regexp"
+
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	highlighting "github.com/yuin/goldmark-highlighting/v2"
+	"github.com/yuin/goldmark/extension"
+
+	"forum/backend/store"
+)
+
+// Style is the chroma style highlight.css was generated from.
+const Style = "github"
+
+var converter = goldmark.New(
+	goldmark.WithExtensions(
+		extension.GFM,
+		highlighting.NewHighlighting(
+			highlighting.WithStyle(Style),
+			// Colors come from highlight.css rather than inline styles,
+			// which the sanitizer would strip.
+			highlighting.WithFormatOptions(chromahtml.WithClasses(true)),
+		),
+	),
+)
+
+var policy = newPolicy()
+
+// newPolicy is the allowlist: bluemonday's policy for user generated content,
+// plus the classes of highlighted code and the checkboxes of task lists.
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^[a-zA-Z0-9_ -]+$`)).OnElements("pre", "code", "span")
+	p.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	p.AllowAttrs("checked", "disabled").OnElements("input")
+	p.AllowStyles("text-align").MatchingEnum("left", "center", "right").OnElements("th", "td")
+	return p
+}
+
+// Render returns the HTML for the Markdown source. Raw HTML in the source
+// is dropped.
+func Render(source string) string {
+	var buf bytes.Buffer
+	if err := converter.Convert([]byte(source), &buf); err != nil {
+		// Converting into a buffer doesn't fail; show the text if it ever
+		// does.
+		return "<p>" + html.EscapeString(source) + "</p>"
+	}
+	return string(policy.SanitizeBytes(buf.Bytes()))
+}
+
+// RenderStored renders the posts and comments that have no HTML yet, or
+// all of them after the renderer has changed.
+func RenderStored(repos store.Store, all bool) (posts, comments int, err error) {
+	posts, err = repos.Posts().Rerender(Render, all)
+	if err != nil {
+		return 0, 0, err
+	}
+	comments, err = repos.Comments().Rerender(Render, all)
+	return posts, comments, err
+}
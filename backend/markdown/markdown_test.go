@@ -0,0 +1,71 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderDropsUnsafeInput(t *testing.T) {
+	for name, tc := range map[string]struct {
+		source string
+		banned []string
+	}{
+		"script":          {"<script>alert(1)</script>\n\nhi", []string{"<script", "alert"}},
+		"javascript link": {"[click](javascript:alert(1))", []string{"javascript:", "href"}},
+		"event handler":   {`<img src=x onerror=alert(1)>`, []string{"<img", "onerror"}},
+		"raw html":        {`<b onclick="steal()">bold</b> <iframe src="https://evil.example"></iframe>`, []string{"<b", "onclick", "<iframe"}},
+		"style":           {`<p style="position:fixed">covered</p>`, []string{"style", "position"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			out := Render(tc.source)
+			for _, banned := range tc.banned {
+				if strings.Contains(out, banned) {
+					t.Errorf("Render(%q) = %q, contains %q", tc.source, out, banned)
+				}
+			}
+		})
+	}
+}
+
+// The renderer already leaves raw HTML out; the allowlist must hold on its
+// own should that ever change.
+func TestPolicyStripsWhatItDoesNotAllow(t *testing.T) {
+	out := policy.Sanitize(`<a href="javascript:alert(1)" onclick="x">a</a>` +
+		`<span class="chroma" onmouseover="x">s</span>` +
+		`<span class="a;b" style="color:red">c</span>` +
+		`<input type="text" value="x" onfocus="x">` +
+		`<script>alert(1)</script><iframe src="x"></iframe>`)
+	for _, banned := range []string{"javascript:", "onclick", "onmouseover", "onfocus", "a;b", "style", `type="text"`, "<script", "<iframe"} {
+		if strings.Contains(out, banned) {
+			t.Errorf("sanitized HTML %q contains %q", out, banned)
+		}
+	}
+	if !strings.Contains(out, `<span class="chroma">s</span>`) {
+		t.Errorf("sanitized HTML %q lost the highlighting class", out)
+	}
+}
+
+func TestRenderKeepsHighlightingAndTaskLists(t *testing.T) {
+	out := Render("```go\nfunc main() {}\n```")
+	for _, want := range []string{`<pre class="chroma">`, `<span class="kd">func</span>`, `<span class="nf">main</span>`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("highlighted code %q lacks %q", out, want)
+		}
+	}
+
+	out = Render("- [x] done\n- [ ] todo")
+	for _, want := range []string{`<input checked="" disabled="" type="checkbox"> done`, `<input disabled="" type="checkbox"> todo`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("task list %q lacks %q", out, want)
+		}
+	}
+}
+
+func TestRenderLinks(t *testing.T) {
+	out := Render("<https://example.com> and [docs](https://example.com/docs)")
+	for _, want := range []string{`<a href="https://example.com" rel="nofollow">`, `<a href="https://example.com/docs" rel="nofollow">docs</a>`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Render = %q, lacks %q", out, want)
+		}
+	}
+}
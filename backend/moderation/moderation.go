@@ -0,0 +1,170 @@
+// Package moderation handles reports of posts and comments: it hides what
+// enough users have reported and carries out what the moderators decide.
+package moderation
+
+import (
+	"errors"
+	"log"
+	"slices"
+	"time"
+
+	"forum/backend/auth"
+	"forum/backend/config"
+	"forum/backend/controllers/structs"
+	"forum/backend/mail"
+	"forum/backend/rbac"
+	"forum/backend/reputation"
+	"forum/backend/store"
+)
+
+// Reasons a post or comment can be reported for.
+var Reasons = []string{"spam", "abuse", "off-topic", "other"}
+
+// What a moderator can do about a report. Everything but dismissing removes
+// the content.
+const (
+	ActionDismiss = "dismiss"
+	ActionDelete  = "delete"
+	ActionWarn    = "warn"
+	ActionBan     = "ban"
+)
+
+var Actions = []string{ActionDismiss, ActionDelete, ActionWarn, ActionBan}
+
+var (
+	ErrOwnContent = errors.New("you can't report your own post or comment")
+	ErrOutranked  = errors.New("you can only ban users below your role")
+)
+
+var outcomes = map[string]string{
+	ActionDismiss: "it doesn't break the forum rules, so it stays up",
+	ActionDelete:  "it has been removed",
+	ActionWarn:    "it has been removed and its author warned",
+	ActionBan:     "it has been removed and its author banned",
+}
+
+func ValidReason(reason string) bool {
+	return slices.Contains(Reasons, reason)
+}
+
+func ValidAction(action string) bool {
+	return slices.Contains(Actions, action)
+}
+
+// Report records the report and hides the post or comment once
+// ReportHideThreshold different users are waiting on a decision about it.
+// It returns store.ErrNotFound if the target doesn't exist.
+func Report(repos store.Store, report structs.Report) error {
+	authorID, hidden, deleted, err := lookup(repos, report.TargetID, report.IsComment)
+	if err != nil {
+		return err
+	}
+	if deleted {
+		return store.ErrNotFound
+	}
+	if authorID == report.ReporterID {
+		return ErrOwnContent
+	}
+
+	report.CreatedAt = time.Now().UTC().Truncate(time.Second)
+	if _, err := repos.Reports().Create(report); err != nil {
+		return err
+	}
+
+	open, err := repos.Reports().OpenCount(report.TargetID, report.IsComment)
+	if err != nil || hidden || open < config.Get().ReportHideThreshold {
+		return err
+	}
+	log.Printf("Hiding %s %d after %d reports", kind(report.IsComment), report.TargetID, open)
+	return setHidden(repos, report.TargetID, report.IsComment, true)
+}
+
+// Resolve carries out the moderator's decision on a reported post or
+// comment, closes its open reports and emails each reporter the outcome.
+// note goes to the author with a warning, and is the reason of a ban.
+// Content whose author has deleted their account has nobody left to warn
+// or ban; it is only removed.
+func Resolve(repos store.Store, moderator structs.User, targetID int, isComment bool, action, note string) error {
+	authorID, _, _, err := lookup(repos, targetID, isComment)
+	if err != nil {
+		return err
+	}
+	open, err := repos.Reports().OpenCount(targetID, isComment)
+	if err != nil {
+		return err
+	}
+	if open == 0 {
+		return store.ErrNotFound
+	}
+	var author structs.User
+	if authorID != 0 {
+		if author, err = repos.Users().ByID(authorID); err != nil {
+			return err
+		}
+	}
+	if action == ActionBan && authorID != 0 && !auth.RoleOf(moderator).Outranks(rbac.ParseRole(author.Role)) {
+		return ErrOutranked
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	what := kind(isComment)
+	var outcome store.Outcome
+	if action != ActionDismiss {
+		outcome.Remove = true
+		if authorID != 0 {
+			outcome.Penalty = &structs.ReputationEvent{UserID: authorID, Points: reputation.Removed,
+				Reason: reputation.ReasonRemoved, TargetID: targetID, IsComment: isComment, ActorID: moderator.ID, Note: action, CreatedAt: now}
+		}
+	}
+	if action == ActionBan && authorID != 0 {
+		if note == "" {
+			note = "Reported " + what
+		}
+		outcome.Ban = &structs.Ban{UserID: authorID, BannedBy: moderator.ID, Reason: note, CreatedAt: now}
+	}
+	reports, err := repos.Reports().Resolve(targetID, isComment, moderator.ID, action, outcome, now)
+	if err != nil {
+		return err
+	}
+
+	if action == ActionWarn && authorID != 0 {
+		if err := mail.SendWarning(author, what, note); err != nil {
+			log.Printf("Failed to send warning to user %d: %v", author.ID, err)
+		}
+	}
+
+	for _, report := range reports {
+		reporter, err := repos.Users().ByID(report.ReporterID)
+		if err != nil {
+			log.Printf("Failed to load reporter %d: %v", report.ReporterID, err)
+			continue
+		}
+		if err := mail.SendReportResolved(reporter, what, outcomes[action]); err != nil {
+			log.Printf("Failed to send report outcome to user %d: %v", reporter.ID, err)
+		}
+	}
+	return nil
+}
+
+func kind(isComment bool) string {
+	if isComment {
+		return "comment"
+	}
+	return "post"
+}
+
+func lookup(repos store.Store, id int, isComment bool) (int, bool, bool, error) {
+	if isComment {
+		comment, err := repos.Comments().ByID(id)
+		return comment.UserId, comment.Hidden, !comment.DeletedAt.IsZero(), err
+	}
+	post, err := repos.Posts().ByID(id)
+	return post.UserID, post.Hidden, !post.DeletedAt.IsZero(), err
+}
+
+func setHidden(repos store.Store, id int, isComment, hidden bool) error {
+	if isComment {
+		return repos.Comments().SetHidden(id, hidden)
+	}
+	return repos.Posts().SetHidden(id, hidden)
+}
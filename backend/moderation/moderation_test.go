@@ -0,0 +1,80 @@
+package moderation
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"forum/backend/controllers/structs"
+	"forum/backend/database"
+	"forum/backend/mail"
+	"forum/backend/store"
+)
+
+type sentMail struct {
+	messages []mail.Message
+}
+
+func (s *sentMail) Send(msg mail.Message) error {
+	s.messages = append(s.messages, msg)
+	return nil
+}
+
+// A comment kept for its replies after its author deleted their account can
+// still be decided on; there is just nobody to warn or ban.
+func TestResolveAuthorlessComment(t *testing.T) {
+	st, err := database.OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sent := &sentMail{}
+	mail.Set(sent)
+
+	createUser := func(name, role string) int {
+		id, err := st.Users().Create(structs.User{Email: name + "@example.com", UserName: name, Password: "hash", Role: role})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+	leaving := createUser("leaving", "user")
+	reporter := createUser("reporter", "user")
+	moderator := structs.User{ID: createUser("moderator", "moderator"), Role: "moderator"}
+
+	postID, err := st.Posts().Create(structs.Post{UserID: reporter, UserName: "reporter", Title: "Post", Content: "Content"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	commentID, err := st.Comments().Create(structs.Comment{PostId: postID, UserId: leaving, UserName: "leaving", Comment: "Rude"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.Comments().Create(structs.Comment{PostId: postID, ParentID: commentID, UserId: reporter, UserName: "reporter", Comment: "Reply"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.Users().Delete(leaving); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, action := range Actions {
+		report := structs.Report{ReporterID: reporter, TargetID: commentID, IsComment: true, Reason: "abuse", CreatedAt: time.Now()}
+		if _, err := st.Reports().Create(report); err != nil {
+			t.Fatal(err)
+		}
+		sent.messages = nil
+
+		if err := Resolve(st, moderator, commentID, true, action, ""); err != nil {
+			t.Fatalf("Resolve %s: %v", action, err)
+		}
+		if open, err := st.Reports().OpenCount(commentID, true); err != nil || open != 0 {
+			t.Errorf("%s: OpenCount = %d, %v; want 0", action, open, err)
+		}
+		if len(sent.messages) != 1 || sent.messages[0].To != "reporter@example.com" {
+			t.Errorf("%s: sent %+v, want only the outcome to the reporter", action, sent.messages)
+		}
+	}
+
+	if err := Resolve(st, moderator, commentID, true, ActionDelete, ""); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Resolve without open reports error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,54 @@
+package oauth
+
+import "context"
+
+type Facebook struct {
+	client
+}
+
+func NewFacebook(clientID, clientSecret string) *Facebook {
+	return &Facebook{client{
+		ClientID:      clientID,
+		ClientSecret:  clientSecret,
+		AuthEndpoint:  "https://www.facebook.com/v12.0/dialog/oauth",
+		TokenEndpoint: "https://graph.facebook.com/v12.0/oauth/access_token",
+		Scopes:        []string{"email"},
+	}}
+}
+
+func (f *Facebook) Name() string {
+	return "facebook"
+}
+
+func (f *Facebook) DisplayName() string {
+	return "Facebook"
+}
+
+func (f *Facebook) AuthURL(redirectURI, state, nonce, codeChallenge string) (string, error) {
+	return f.authURL(redirectURI, state, codeChallenge, nil)
+}
+
+func (f *Facebook) Exchange(ctx context.Context, redirectURI, code, codeVerifier, nonce string) (Identity, error) {
+	token, err := f.exchange(ctx, redirectURI, code, codeVerifier)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	var user struct {
+		ID    string `json:"id"`
+		Email string `json:"email"`
+		Name  string `json:"name"`
+	}
+	if err := getJSON(ctx, "https://graph.facebook.com/me?fields=id,email,name", token.AccessToken, &user); err != nil {
+		return Identity{}, err
+	}
+
+	// Facebook only hands out confirmed email addresses.
+	return Identity{
+		Provider:      f.Name(),
+		Subject:       user.ID,
+		Email:         user.Email,
+		EmailVerified: user.Email != "",
+		Name:          user.Name,
+	}, nil
+}
@@ -0,0 +1,128 @@
+package oauth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"forum/backend/auth"
+	"forum/backend/config"
+	"forum/backend/store"
+)
+
+const (
+	stateTTL        = 10 * time.Minute
+	stateCookieName = "oauth_state"
+)
+
+var ErrInvalidState = errors.New("invalid or expired login state")
+
+// RedirectURI is where the provider sends the browser back to. It has to be
+// registered with the provider exactly like this.
+func RedirectURI(p Provider) string {
+	return config.Get().BaseURL + "/callback/" + p.Name()
+}
+
+// Begin starts a login with the provider and returns the URL to send the
+// browser to. The state, PKCE verifier and nonce are kept server-side; the
+// state is also put in a cookie so only this browser can finish the login.
+// A non-zero linkUserID makes the login link the provider account to that
+// user instead.
+func Begin(w http.ResponseWriter, states store.OAuthStateRepo, p Provider, linkUserID int) (string, error) {
+	state, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	verifier, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	nonce, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	err = states.Create(auth.HashToken(state), store.OAuthState{
+		Provider:     p.Name(),
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		ExpiresAt:    now.Add(stateTTL),
+		LinkUserID:   linkUserID,
+	}, now)
+	if err != nil {
+		return "", err
+	}
+
+	authURL, err := p.AuthURL(RedirectURI(p), state, nonce, codeChallenge(verifier))
+	if err != nil {
+		return "", err
+	}
+
+	// Lax whatever the session cookie uses: the provider's redirect back
+	// is a cross-site navigation.
+	http.SetCookie(w, &http.Cookie{
+		Name:     stateCookieName,
+		Value:    state,
+		Path:     "/callback/",
+		MaxAge:   int(stateTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   config.Get().CookieSecure,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return authURL, nil
+}
+
+// Complete finishes the login the provider redirected back from and returns
+// who logged in, and the user to link them to if Begin was given one. It
+// returns ErrInvalidState if the callback doesn't belong to a login this
+// browser started.
+func Complete(w http.ResponseWriter, r *http.Request, states store.OAuthStateRepo, p Provider) (Identity, int, error) {
+	http.SetCookie(w, &http.Cookie{Name: stateCookieName, Path: "/callback/", MaxAge: -1, HttpOnly: true})
+
+	query := r.URL.Query()
+	if providerErr := query.Get("error"); providerErr != "" {
+		return Identity{}, 0, fmt.Errorf("login failed at %s: %s", p.DisplayName(), providerErr)
+	}
+
+	state := query.Get("state")
+	cookie, err := r.Cookie(stateCookieName)
+	if err != nil || state == "" || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(state)) != 1 {
+		return Identity{}, 0, ErrInvalidState
+	}
+
+	saved, err := states.Take(auth.HashToken(state), time.Now().UTC())
+	if errors.Is(err, store.ErrNotFound) || (err == nil && saved.Provider != p.Name()) {
+		return Identity{}, 0, ErrInvalidState
+	}
+	if err != nil {
+		return Identity{}, 0, err
+	}
+
+	code := query.Get("code")
+	if code == "" {
+		return Identity{}, 0, fmt.Errorf("no code in response")
+	}
+
+	identity, err := p.Exchange(r.Context(), RedirectURI(p), code, saved.CodeVerifier, saved.Nonce)
+	return identity, saved.LinkUserID, err
+}
+
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
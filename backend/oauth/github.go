@@ -0,0 +1,67 @@
+package oauth
+
+import (
+	"context"
+	"strconv"
+)
+
+type GitHub struct {
+	client
+}
+
+func NewGitHub(clientID, clientSecret string) *GitHub {
+	return &GitHub{client{
+		ClientID:      clientID,
+		ClientSecret:  clientSecret,
+		AuthEndpoint:  "https://github.com/login/oauth/authorize",
+		TokenEndpoint: "https://github.com/login/oauth/access_token",
+		Scopes:        []string{"read:user", "user:email"},
+	}}
+}
+
+func (g *GitHub) Name() string {
+	return "github"
+}
+
+func (g *GitHub) DisplayName() string {
+	return "GitHub"
+}
+
+func (g *GitHub) AuthURL(redirectURI, state, nonce, codeChallenge string) (string, error) {
+	return g.authURL(redirectURI, state, codeChallenge, nil)
+}
+
+func (g *GitHub) Exchange(ctx context.Context, redirectURI, code, codeVerifier, nonce string) (Identity, error) {
+	token, err := g.exchange(ctx, redirectURI, code, codeVerifier)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	var user struct {
+		ID    int64  `json:"id"`
+		Login string `json:"login"`
+		Name  string `json:"name"`
+	}
+	if err := getJSON(ctx, "https://api.github.com/user", token.AccessToken, &user); err != nil {
+		return Identity{}, err
+	}
+
+	// The profile email is optional and may be unverified, so use the
+	// primary verified address instead.
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := getJSON(ctx, "https://api.github.com/user/emails", token.AccessToken, &emails); err != nil {
+		return Identity{}, err
+	}
+
+	identity := Identity{Provider: g.Name(), Subject: strconv.FormatInt(user.ID, 10), Name: user.Login}
+	for _, e := range emails {
+		if e.Verified && (e.Primary || identity.Email == "") {
+			identity.Email, identity.EmailVerified = e.Email, true
+		}
+	}
+	return identity, nil
+}
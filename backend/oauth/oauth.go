@@ -0,0 +1,138 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Identity is who the provider says logged in. Subject is the provider's
+// stable ID for the account; the email may change.
+type Identity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// Provider is one way of logging in through a third party.
+type Provider interface {
+	// Name is used in the /login/{name} and /callback/{name} paths.
+	Name() string
+	DisplayName() string
+	// AuthURL is where the browser is sent to log in. The provider returns
+	// to redirectURI with the state and a code.
+	AuthURL(redirectURI, state, nonce, codeChallenge string) (string, error)
+	// Exchange trades the code for the identity of the user who logged in.
+	Exchange(ctx context.Context, redirectURI, code, codeVerifier, nonce string) (Identity, error)
+}
+
+var ErrEmailNotVerified = errors.New("the provider has not verified this email address")
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+var (
+	registry = make(map[string]Provider)
+	order    []string
+)
+
+// Register makes the provider available for login. Registering a name twice
+// replaces the earlier provider.
+func Register(p Provider) {
+	if _, ok := registry[p.Name()]; !ok {
+		order = append(order, p.Name())
+	}
+	registry[p.Name()] = p
+}
+
+func Get(name string) (Provider, bool) {
+	p, ok := registry[name]
+	return p, ok
+}
+
+// Providers lists the registered providers in registration order.
+func Providers() []Provider {
+	providers := make([]Provider, 0, len(order))
+	for _, name := range order {
+		providers = append(providers, registry[name])
+	}
+	return providers
+}
+
+type Button struct {
+	Name        string
+	DisplayName string
+	Icon        string
+}
+
+var icons = map[string]string{
+	"google": "/frontend/static/icons/google.svg",
+	"github": "/frontend/static/icons/github.svg",
+}
+
+// Buttons describes the "Continue with ..." buttons of the login and
+// register pages.
+func Buttons() []Button {
+	var buttons []Button
+	for _, p := range Providers() {
+		buttons = append(buttons, Button{Name: p.Name(), DisplayName: p.DisplayName(), Icon: icons[p.Name()]})
+	}
+	return buttons
+}
+
+// LoadProviders registers every provider whose client ID is set in the
+// environment. Google, GitHub and Facebook have fixed settings; any number
+// of other OpenID Connect providers can be listed in OIDC_PROVIDERS, each
+// configured with OIDC_<NAME>_ISSUER, _CLIENT_ID, _CLIENT_SECRET and
+// optionally _DISPLAY_NAME and _SCOPES.
+func LoadProviders() {
+	if id := os.Getenv("GOOGLE_CLIENT_ID"); id != "" {
+		Register(NewOIDC("google", "Google", "https://accounts.google.com", id, os.Getenv("GOOGLE_CLIENT_SECRET"), nil))
+	}
+	if id := os.Getenv("GITHUB_CLIENT_ID"); id != "" {
+		Register(NewGitHub(id, os.Getenv("GITHUB_CLIENT_SECRET")))
+	}
+	if id := os.Getenv("FACEBOOK_CLIENT_ID"); id != "" {
+		Register(NewFacebook(id, os.Getenv("FACEBOOK_CLIENT_SECRET")))
+	}
+
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		issuer, clientID := os.Getenv(prefix+"ISSUER"), os.Getenv(prefix+"CLIENT_ID")
+		if issuer == "" || clientID == "" {
+			log.Printf("Skipping OIDC provider %q: %sISSUER and %sCLIENT_ID are required", name, prefix, prefix)
+			continue
+		}
+		displayName := os.Getenv(prefix + "DISPLAY_NAME")
+		if displayName == "" {
+			displayName = name
+		}
+		var scopes []string
+		if value := os.Getenv(prefix + "SCOPES"); value != "" {
+			scopes = strings.Fields(value)
+		}
+		Register(NewOIDC(name, displayName, issuer, clientID, os.Getenv(prefix+"CLIENT_SECRET"), scopes))
+	}
+}
+
+func decodeError(resp *http.Response, what string) error {
+	var body struct {
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	decodeJSON(resp, &body)
+	if body.Error != "" {
+		return fmt.Errorf("%s: %s %s", what, body.Error, body.ErrorDescription)
+	}
+	return fmt.Errorf("%s: %s", what, resp.Status)
+}
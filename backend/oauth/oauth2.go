@@ -0,0 +1,121 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// client holds the OAuth 2.0 settings shared by every provider and does the
+// authorization code flow with PKCE.
+type client struct {
+	ClientID      string
+	ClientSecret  string
+	AuthEndpoint  string
+	TokenEndpoint string
+	Scopes        []string
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	IDToken     string `json:"id_token"`
+	TokenType   string `json:"token_type"`
+}
+
+func (c client) authURL(redirectURI, state, codeChallenge string, extra url.Values) (string, error) {
+	u, err := url.Parse(c.AuthEndpoint)
+	if err != nil {
+		return "", err
+	}
+
+	q := u.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", c.ClientID)
+	q.Set("redirect_uri", redirectURI)
+	q.Set("scope", strings.Join(c.Scopes, " "))
+	q.Set("state", state)
+	q.Set("code_challenge", codeChallenge)
+	q.Set("code_challenge_method", "S256")
+	for key, values := range extra {
+		q[key] = values
+	}
+	u.RawQuery = q.Encode()
+
+	return u.String(), nil
+}
+
+func (c client) exchange(ctx context.Context, redirectURI, code, codeVerifier string) (tokenResponse, error) {
+	data := url.Values{}
+	data.Set("grant_type", "authorization_code")
+	data.Set("code", code)
+	data.Set("redirect_uri", redirectURI)
+	data.Set("client_id", c.ClientID)
+	data.Set("client_secret", c.ClientSecret)
+	data.Set("code_verifier", codeVerifier)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.TokenEndpoint, strings.NewReader(data.Encode()))
+	if err != nil {
+		return tokenResponse{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return tokenResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return tokenResponse{}, decodeError(resp, "token request failed")
+	}
+
+	// GitHub reports errors with a 200 and an "error" field.
+	var token struct {
+		tokenResponse
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := decodeJSON(resp, &token); err != nil {
+		return tokenResponse{}, err
+	}
+	if token.Error != "" {
+		return tokenResponse{}, fmt.Errorf("token request failed: %s %s", token.Error, token.ErrorDescription)
+	}
+	if token.AccessToken == "" {
+		return tokenResponse{}, fmt.Errorf("no access token in response")
+	}
+	return token.tokenResponse, nil
+}
+
+// getJSON fetches an API endpoint with the access token and decodes the
+// response into v.
+func getJSON(ctx context.Context, endpoint, accessToken string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return decodeError(resp, "GET "+endpoint)
+	}
+	return decodeJSON(resp, v)
+}
+
+func decodeJSON(resp *http.Response, v any) error {
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
@@ -0,0 +1,437 @@
+package oauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// clockSkew is how far our clock and the issuer's may disagree.
+const clockSkew = time.Minute
+
+// keysRefetch is how often at most an unknown key ID sends us back to the
+// issuer for its keys, so that forged tokens can't make us fetch on every
+// request.
+const keysRefetch = 5 * time.Minute
+
+var errInvalidIDToken = errors.New("invalid ID token")
+
+// OIDC is an OpenID Connect provider. Its endpoints and signing keys are
+// discovered from the issuer URL on first use.
+type OIDC struct {
+	name        string
+	displayName string
+	issuer      string
+	clientID    string
+	secret      string
+	scopes      []string
+
+	mu          sync.Mutex
+	discovery   *discovery
+	keys        map[string]crypto.PublicKey
+	keysFetched time.Time
+}
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+func NewOIDC(name, displayName, issuer, clientID, clientSecret string, scopes []string) *OIDC {
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "email", "profile"}
+	}
+	return &OIDC{
+		name:        name,
+		displayName: displayName,
+		issuer:      strings.TrimRight(issuer, "/"),
+		clientID:    clientID,
+		secret:      clientSecret,
+		scopes:      scopes,
+	}
+}
+
+func (p *OIDC) Name() string {
+	return p.name
+}
+
+func (p *OIDC) DisplayName() string {
+	return p.displayName
+}
+
+func (p *OIDC) AuthURL(redirectURI, state, nonce, codeChallenge string) (string, error) {
+	c, err := p.client(context.Background())
+	if err != nil {
+		return "", err
+	}
+	return c.authURL(redirectURI, state, codeChallenge, url.Values{"nonce": {nonce}})
+}
+
+func (p *OIDC) Exchange(ctx context.Context, redirectURI, code, codeVerifier, nonce string) (Identity, error) {
+	d, err := p.discover(ctx)
+	if err != nil {
+		return Identity{}, err
+	}
+	c, err := p.client(ctx)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	token, err := c.exchange(ctx, redirectURI, code, codeVerifier)
+	if err != nil {
+		return Identity{}, err
+	}
+	if token.IDToken == "" {
+		return Identity{}, fmt.Errorf("no ID token in response")
+	}
+
+	claims, err := p.verify(ctx, token.IDToken, nonce)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	// Some issuers leave the profile out of the ID token and only serve it
+	// from the userinfo endpoint.
+	if claims.Email == "" && d.UserinfoEndpoint != "" {
+		var info idTokenClaims
+		if err := getJSON(ctx, d.UserinfoEndpoint, token.AccessToken, &info); err != nil {
+			return Identity{}, err
+		}
+		if info.Subject != claims.Subject {
+			return Identity{}, fmt.Errorf("userinfo is for a different subject")
+		}
+		claims.Email, claims.EmailVerified = info.Email, info.EmailVerified
+		if claims.Name == "" {
+			claims.Name = info.Name
+		}
+		if claims.PreferredUsername == "" {
+			claims.PreferredUsername = info.PreferredUsername
+		}
+	}
+
+	name := claims.Name
+	if name == "" {
+		name = claims.PreferredUsername
+	}
+	if name == "" {
+		name, _, _ = strings.Cut(claims.Email, "@")
+	}
+
+	return Identity{
+		Provider:      p.name,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: bool(claims.EmailVerified),
+		Name:          name,
+	}, nil
+}
+
+func (p *OIDC) client(ctx context.Context) (client, error) {
+	d, err := p.discover(ctx)
+	if err != nil {
+		return client{}, err
+	}
+	return client{
+		ClientID:      p.clientID,
+		ClientSecret:  p.secret,
+		AuthEndpoint:  d.AuthorizationEndpoint,
+		TokenEndpoint: d.TokenEndpoint,
+		Scopes:        p.scopes,
+	}, nil
+}
+
+// discover fetches the issuer's configuration once; a failed attempt is
+// retried on the next login.
+func (p *OIDC) discover(ctx context.Context) (*discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	var d discovery
+	if err := getJSON(ctx, p.issuer+"/.well-known/openid-configuration", "", &d); err != nil {
+		return nil, fmt.Errorf("%s discovery: %w", p.name, err)
+	}
+	if strings.TrimRight(d.Issuer, "/") != p.issuer {
+		return nil, fmt.Errorf("%s discovery: issuer is %q, expected %q", p.name, d.Issuer, p.issuer)
+	}
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		return nil, fmt.Errorf("%s discovery: endpoints missing", p.name)
+	}
+
+	p.discovery = &d
+	return p.discovery, nil
+}
+
+type idTokenClaims struct {
+	Issuer            string       `json:"iss"`
+	Subject           string       `json:"sub"`
+	Audience          audience     `json:"aud"`
+	AuthorizedParty   string       `json:"azp"`
+	Expiry            float64      `json:"exp"`
+	IssuedAt          float64      `json:"iat"`
+	Nonce             string       `json:"nonce"`
+	Email             string       `json:"email"`
+	EmailVerified     flexibleBool `json:"email_verified"`
+	Name              string       `json:"name"`
+	PreferredUsername string       `json:"preferred_username"`
+}
+
+// audience is a single string or a list of them.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*a = audience{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*a = many
+	return nil
+}
+
+func (a audience) contains(s string) bool {
+	for _, v := range a {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// flexibleBool accepts "true" as well as true; some issuers quote it.
+type flexibleBool bool
+
+func (b *flexibleBool) UnmarshalJSON(data []byte) error {
+	switch string(data) {
+	case `true`, `"true"`:
+		*b = true
+	default:
+		*b = false
+	}
+	return nil
+}
+
+func (p *OIDC) verify(ctx context.Context, raw, nonce string) (idTokenClaims, error) {
+	var claims idTokenClaims
+
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return claims, errInvalidIDToken
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return claims, errInvalidIDToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return claims, errInvalidIDToken
+	}
+
+	key, err := p.key(ctx, header.Kid)
+	if err != nil {
+		return claims, err
+	}
+	if err := verifySignature(header.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return claims, err
+	}
+
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return claims, errInvalidIDToken
+	}
+
+	now := time.Now()
+	switch {
+	case strings.TrimRight(claims.Issuer, "/") != p.issuer:
+		return claims, fmt.Errorf("%w: wrong issuer %q", errInvalidIDToken, claims.Issuer)
+	case !claims.Audience.contains(p.clientID):
+		return claims, fmt.Errorf("%w: not issued for this client", errInvalidIDToken)
+	case len(claims.Audience) > 1 && claims.AuthorizedParty != p.clientID:
+		return claims, fmt.Errorf("%w: wrong authorized party", errInvalidIDToken)
+	case time.Unix(int64(claims.Expiry), 0).Add(clockSkew).Before(now):
+		return claims, fmt.Errorf("%w: expired", errInvalidIDToken)
+	case time.Unix(int64(claims.IssuedAt), 0).Add(-clockSkew).After(now):
+		return claims, fmt.Errorf("%w: issued in the future", errInvalidIDToken)
+	case claims.Nonce != nonce:
+		return claims, fmt.Errorf("%w: nonce mismatch", errInvalidIDToken)
+	case claims.Subject == "":
+		return claims, fmt.Errorf("%w: no subject", errInvalidIDToken)
+	}
+
+	return claims, nil
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// key returns the issuer's signing key with the ID. The key set is fetched
+// again when the ID is unknown, since issuers rotate their keys.
+func (p *OIDC) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	d, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	if time.Since(p.keysFetched) < keysRefetch {
+		return nil, fmt.Errorf("%w: unknown signing key %q", errInvalidIDToken, kid)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := getJSON(ctx, d.JWKSURI, "", &set); err != nil {
+		return nil, fmt.Errorf("%s keys: %w", p.name, err)
+	}
+
+	p.keysFetched = time.Now()
+	p.keys = make(map[string]crypto.PublicKey)
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		if key, err := jwk.publicKey(); err == nil {
+			p.keys[jwk.Kid] = key
+		}
+	}
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%w: unknown signing key %q", errInvalidIDToken, kid)
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// ecdsaCurves is the one curve each ECDSA algorithm may be used with.
+var ecdsaCurves = map[string]elliptic.Curve{
+	"ES256": elliptic.P256(),
+	"ES384": elliptic.P384(),
+	"ES512": elliptic.P521(),
+}
+
+func verifySignature(alg string, key crypto.PublicKey, signed string, signature []byte) error {
+	var hash crypto.Hash
+	switch alg {
+	case "RS256", "ES256":
+		hash = crypto.SHA256
+	case "RS384", "ES384":
+		hash = crypto.SHA384
+	case "RS512", "ES512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("%w: unsupported algorithm %q", errInvalidIDToken, alg)
+	}
+	h := hash.New()
+	h.Write([]byte(signed))
+	digest := h.Sum(nil)
+
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		if alg[0] != 'R' || rsa.VerifyPKCS1v15(key, hash, digest, signature) != nil {
+			return fmt.Errorf("%w: bad signature", errInvalidIDToken)
+		}
+	case *ecdsa.PublicKey:
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if key.Curve != ecdsaCurves[alg] || len(signature) != 2*size {
+			return fmt.Errorf("%w: bad signature", errInvalidIDToken)
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(key, digest, r, s) {
+			return fmt.Errorf("%w: bad signature", errInvalidIDToken)
+		}
+	default:
+		return fmt.Errorf("%w: unsupported key", errInvalidIDToken)
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package oauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const (
+	testClientID = "forum"
+	testNonce    = "nonce-123"
+	testKeyID    = "key-1"
+)
+
+// fakeIssuer is a local OpenID Connect provider. Its token endpoint answers
+// every code with an ID token holding claims, signed by signer.
+type fakeIssuer struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	signer *rsa.PrivateKey
+	claims map[string]any
+
+	keyFetches atomic.Int32
+}
+
+func newFakeIssuer(t *testing.T) *fakeIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeIssuer{key: key, signer: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(discovery{
+			Issuer:                f.server.URL,
+			AuthorizationEndpoint: f.server.URL + "/authorize",
+			TokenEndpoint:         f.server.URL + "/token",
+			JWKSURI:               f.server.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		f.keyFetches.Add(1)
+		json.NewEncoder(w).Encode(map[string]any{"keys": []jsonWebKey{{
+			Kty: "RSA",
+			Kid: testKeyID,
+			Use: "sig",
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(tokenResponse{AccessToken: "access", IDToken: f.idToken(t), TokenType: "Bearer"})
+	})
+	f.server = httptest.NewServer(mux)
+	t.Cleanup(f.server.Close)
+
+	now := time.Now().Unix()
+	f.claims = map[string]any{
+		"iss":            f.server.URL,
+		"sub":            "subject-1",
+		"aud":            testClientID,
+		"exp":            now + 300,
+		"iat":            now,
+		"nonce":          testNonce,
+		"email":          "ada@example.com",
+		"email_verified": true,
+		"name":           "Ada",
+	}
+	return f
+}
+
+func (f *fakeIssuer) idToken(t *testing.T) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": testKeyID, "typ": "JWT"})
+	payload, err := json.Marshal(f.claims)
+	if err != nil {
+		t.Error(err)
+	}
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, f.signer, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Error(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func (f *fakeIssuer) exchange() (Identity, error) {
+	p := NewOIDC("test", "Test", f.server.URL, testClientID, "secret", nil)
+	return p.Exchange(context.Background(), "http://localhost/callback", "code", "verifier", testNonce)
+}
+
+func TestOIDCExchange(t *testing.T) {
+	f := newFakeIssuer(t)
+
+	identity, err := f.exchange()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Identity{Provider: "test", Subject: "subject-1", Email: "ada@example.com", EmailVerified: true, Name: "Ada"}
+	if identity != want {
+		t.Fatalf("Exchange = %+v, want %+v", identity, want)
+	}
+}
+
+func TestOIDCRejectsBadIDTokens(t *testing.T) {
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, spoil := range map[string]func(f *fakeIssuer){
+		"wrong issuer":      func(f *fakeIssuer) { f.claims["iss"] = "https://evil.example" },
+		"wrong audience":    func(f *fakeIssuer) { f.claims["aud"] = "someone-else" },
+		"wrong nonce":       func(f *fakeIssuer) { f.claims["nonce"] = "replayed" },
+		"expired":           func(f *fakeIssuer) { f.claims["exp"] = time.Now().Add(-time.Hour).Unix() },
+		"no subject":        func(f *fakeIssuer) { delete(f.claims, "sub") },
+		"foreign signature": func(f *fakeIssuer) { f.signer = otherKey },
+		"shared audience without azp": func(f *fakeIssuer) {
+			f.claims["aud"] = []string{testClientID, "someone-else"}
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			f := newFakeIssuer(t)
+			spoil(f)
+			if _, err := f.exchange(); !errors.Is(err, errInvalidIDToken) {
+				t.Fatalf("Exchange error = %v, want errInvalidIDToken", err)
+			}
+		})
+	}
+}
+
+func TestOIDCRefetchesKeysRarely(t *testing.T) {
+	f := newFakeIssuer(t)
+	p := NewOIDC("test", "Test", f.server.URL, testClientID, "secret", nil)
+	ctx := context.Background()
+
+	for range 3 {
+		if _, err := p.key(ctx, "forged"); !errors.Is(err, errInvalidIDToken) {
+			t.Fatalf("key error = %v, want errInvalidIDToken", err)
+		}
+	}
+	if _, err := p.key(ctx, testKeyID); err != nil {
+		t.Fatal(err)
+	}
+	if n := f.keyFetches.Load(); n != 1 {
+		t.Fatalf("keys fetched %d times, want once", n)
+	}
+
+	// After a while an unknown key may be a rotated one, worth a look.
+	p.keysFetched = p.keysFetched.Add(-keysRefetch)
+	p.key(ctx, "rotated")
+	if n := f.keyFetches.Load(); n != 2 {
+		t.Fatalf("keys fetched %d times, want twice", n)
+	}
+}
+
+func TestVerifySignatureMatchesCurve(t *testing.T) {
+	sign := func(t *testing.T, curve elliptic.Curve, hash crypto.Hash) (crypto.PublicKey, []byte) {
+		key, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h := hash.New()
+		h.Write([]byte("signed"))
+		r, s, err := ecdsa.Sign(rand.Reader, key, h.Sum(nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		size := (curve.Params().BitSize + 7) / 8
+		signature := make([]byte, 2*size)
+		r.FillBytes(signature[:size])
+		s.FillBytes(signature[size:])
+		return &key.PublicKey, signature
+	}
+
+	for _, tc := range []struct {
+		alg   string
+		curve elliptic.Curve
+		hash  crypto.Hash
+		ok    bool
+	}{
+		{"ES256", elliptic.P256(), crypto.SHA256, true},
+		{"ES384", elliptic.P384(), crypto.SHA384, true},
+		{"ES512", elliptic.P521(), crypto.SHA512, true},
+		{"ES384", elliptic.P256(), crypto.SHA384, false},
+		{"ES256", elliptic.P384(), crypto.SHA256, false},
+		{"ES512", elliptic.P384(), crypto.SHA512, false},
+		{"RS256", elliptic.P256(), crypto.SHA256, false},
+	} {
+		key, signature := sign(t, tc.curve, tc.hash)
+		err := verifySignature(tc.alg, key, "signed", signature)
+		if (err == nil) != tc.ok {
+			t.Errorf("%s with %s: error = %v, want ok %v", tc.alg, tc.curve.Params().Name, err, tc.ok)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+// Package pagination reads the limit and cursor parameters of the listing
+// endpoints and builds the cursors returned with each page.
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"forum/backend/controllers/structs"
+	"forum/backend/store"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// A cursor is "after:<id>", "before:<id>" or "offset:<n>", base64url encoded
+// so clients treat it as opaque.
+const (
+	afterPrefix  = "after:"
+	beforePrefix = "before:"
+	offsetPrefix = "offset:"
+)
+
+// FromRequest reads ?limit= and ?cursor=. A missing or out of range limit
+// falls back to DefaultLimit or is capped at MaxLimit.
+func FromRequest(r *http.Request) (store.Page, error) {
+	page := store.Page{Limit: DefaultLimit}
+
+	if limit, err := strconv.Atoi(r.FormValue("limit")); err == nil && limit > 0 {
+		page.Limit = min(limit, MaxLimit)
+	}
+
+	cursor := r.FormValue("cursor")
+	if cursor == "" {
+		return page, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return page, ErrInvalidCursor
+	}
+	kind, value, found := strings.Cut(string(raw), ":")
+	n, err := strconv.Atoi(value)
+	if !found || err != nil || n < 0 {
+		return page, ErrInvalidCursor
+	}
+
+	switch kind + ":" {
+	case afterPrefix:
+		page.After = n
+	case beforePrefix:
+		page.Before = n
+	case offsetPrefix:
+		page.Offset = n
+	default:
+		return page, ErrInvalidCursor
+	}
+	return page, nil
+}
+
+// Keyset builds the cursors for a page of a listing paged by row ID. ids are
+// the IDs of the rows on the page, in listing order; more is what the
+// repository reported.
+func Keyset(page store.Page, ids []int, more bool) structs.Cursors {
+	var cursors structs.Cursors
+
+	if len(ids) == 0 {
+		return cursors
+	}
+
+	first, last := ids[0], ids[len(ids)-1]
+	if page.Before != 0 {
+		cursors.NextCursor = encode(afterPrefix, last)
+		if more {
+			cursors.PrevCursor = encode(beforePrefix, first)
+		}
+		return cursors
+	}
+
+	if more {
+		cursors.NextCursor = encode(afterPrefix, last)
+	}
+	if page.After != 0 {
+		cursors.PrevCursor = encode(beforePrefix, first)
+	}
+	return cursors
+}
+
+// Offset builds the cursors for a page of a listing paged by offset.
+func Offset(page store.Page, count int, more bool) structs.Cursors {
+	var cursors structs.Cursors
+	if more {
+		cursors.NextCursor = encode(offsetPrefix, page.Offset+count)
+	}
+	if page.Offset > 0 {
+		cursors.PrevCursor = encode(offsetPrefix, max(page.Offset-page.Limit, 0))
+	}
+	return cursors
+}
+
+func PostIDs(posts []structs.Post) []int {
+	ids := make([]int, len(posts))
+	for i, post := range posts {
+		ids[i] = post.ID
+	}
+	return ids
+}
+
+func CommentIDs(comments []structs.Comment) []int {
+	ids := make([]int, len(comments))
+	for i, comment := range comments {
+		ids[i] = comment.ID
+	}
+	return ids
+}
+
+func EventIDs(events []structs.ReputationEvent) []int {
+	ids := make([]int, len(events))
+	for i, event := range events {
+		ids[i] = event.ID
+	}
+	return ids
+}
+
+func encode(prefix string, n int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(prefix + strconv.Itoa(n)))
+}
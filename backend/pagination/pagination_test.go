@@ -0,0 +1,84 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"forum/backend/store"
+)
+
+func fromCursor(t *testing.T, cursor string) (store.Page, error) {
+	t.Helper()
+	return FromRequest(httptest.NewRequest("GET", "/api/posts?cursor="+url.QueryEscape(cursor), nil))
+}
+
+func TestKeysetCursorsRoundTrip(t *testing.T) {
+	cursors := Keyset(store.Page{Limit: 3, After: 10}, []int{9, 7, 4}, true)
+	next, err := fromCursor(t, cursors.NextCursor)
+	if err != nil || next != (store.Page{Limit: DefaultLimit, After: 4}) {
+		t.Errorf("next page = %+v, %v; want after 4", next, err)
+	}
+	prev, err := fromCursor(t, cursors.PrevCursor)
+	if err != nil || prev != (store.Page{Limit: DefaultLimit, Before: 9}) {
+		t.Errorf("previous page = %+v, %v; want before 9", prev, err)
+	}
+
+	// The first page has no way back, the last no way on.
+	if cursors := Keyset(store.Page{Limit: 3}, []int{9, 7, 4}, false); cursors.NextCursor != "" || cursors.PrevCursor != "" {
+		t.Errorf("single page cursors = %+v, want none", cursors)
+	}
+	if cursors := Keyset(store.Page{Limit: 3, After: 4}, nil, false); cursors.NextCursor != "" || cursors.PrevCursor != "" {
+		t.Errorf("empty page cursors = %+v, want none", cursors)
+	}
+}
+
+func TestOffsetCursorsRoundTrip(t *testing.T) {
+	cursors := Offset(store.Page{Limit: 20, Offset: 10}, 20, true)
+	next, err := fromCursor(t, cursors.NextCursor)
+	if err != nil || next.Offset != 30 {
+		t.Errorf("next page = %+v, %v; want offset 30", next, err)
+	}
+	prev, err := fromCursor(t, cursors.PrevCursor)
+	if err != nil || prev.Offset != 0 {
+		t.Errorf("previous page = %+v, %v; want offset 0", prev, err)
+	}
+}
+
+func TestBadCursorsRejected(t *testing.T) {
+	raw := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+	for name, cursor := range map[string]string{
+		"not base64":     "!!!",
+		"padded base64":  base64.URLEncoding.EncodeToString([]byte("after:3")),
+		"plain text":     "after:3",
+		"unknown kind":   raw("sideways:3"),
+		"no kind":        raw("3"),
+		"negative":       raw("after:-1"),
+		"not a number":   raw("before:three"),
+		"trailing bytes": raw("offset:3;drop"),
+		"empty value":    raw("after:"),
+		"overflow":       raw("after:99999999999999999999"),
+	} {
+		if page, err := fromCursor(t, cursor); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%s: FromRequest = %+v, %v; want ErrInvalidCursor", name, page, err)
+		}
+	}
+}
+
+func TestLimit(t *testing.T) {
+	for query, want := range map[string]int{
+		"":            DefaultLimit,
+		"?limit=5":    5,
+		"?limit=0":    DefaultLimit,
+		"?limit=-3":   DefaultLimit,
+		"?limit=many": DefaultLimit,
+		"?limit=1000": MaxLimit,
+	} {
+		page, err := FromRequest(httptest.NewRequest("GET", "/api/posts"+query, nil))
+		if err != nil || page.Limit != want {
+			t.Errorf("%q: limit %d, %v; want %d", query, page.Limit, err, want)
+		}
+	}
+}
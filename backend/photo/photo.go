@@ -0,0 +1,83 @@
+// Package photo stores the images attached to posts.
+package photo
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// dir is served under /uploads/.
+const dir = "./uploads"
+
+var ErrUnsupported = errors.New("only JPEG, PNG and GIF photos are allowed")
+
+// saved matches the names Save gives photos.
+var saved = regexp.MustCompile(`^photo-[0-9a-f]{32}\.(jpg|png|gif)$`)
+
+var extensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// Save stores the image under a random name and returns its path. The name
+// sent with the upload is never used, so one upload can't replace another's
+// file, and the type is taken from the content.
+func Save(image io.Reader) (string, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(image, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", err
+	}
+	head = head[:n]
+	ext, ok := extensions[http.DetectContentType(head)]
+	if !ok {
+		return "", ErrUnsupported
+	}
+
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	name := "photo-" + hex.EncodeToString(random) + ext
+
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return "", err
+	}
+	file, err := os.Create(filepath.Join(dir, name))
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	_, err = file.Write(head)
+	if err == nil {
+		_, err = io.Copy(file, image)
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+	return "/uploads/" + name, nil
+}
+
+// Remove deletes the photo at the path Save returned. Other paths, such as
+// those of photos uploaded under the name they were sent with, are left
+// alone, since they may be someone else's file.
+func Remove(path string) error {
+	name := strings.TrimPrefix(path, "/uploads/")
+	if name == path || !saved.MatchString(name) {
+		return nil
+	}
+	err := os.Remove(filepath.Join(dir, name))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
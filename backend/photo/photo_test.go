@@ -0,0 +1,81 @@
+package photo
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+// inTempDir runs the test from an empty directory, so uploads land there.
+func inTempDir(t *testing.T) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func TestSaveNamesEveryPhoto(t *testing.T) {
+	inTempDir(t)
+
+	image := []byte("GIF89a")
+	first, err := Save(bytes.NewReader(image))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Save(bytes.NewReader(image))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == second || !strings.HasPrefix(first, "/uploads/photo-") || !strings.HasSuffix(first, ".gif") {
+		t.Fatalf("Save = %q and %q, want two distinct /uploads/photo-*.gif paths", first, second)
+	}
+	if _, err := os.Stat("." + first); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSaveRefusesOtherTypes(t *testing.T) {
+	inTempDir(t)
+
+	if _, err := Save(bytes.NewReader([]byte("<html>hi</html>"))); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("Save(html) error = %v, want ErrUnsupported", err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Fatalf("%d files left in %s, want none", len(entries), dir)
+	}
+}
+
+func TestRemoveOnlySavedPhotos(t *testing.T) {
+	inTempDir(t)
+
+	path, err := Save(bytes.NewReader([]byte("GIF89a")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dir+"/bye.png", []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, other := range []string{"/uploads/bye.png", "/uploads/avatars/avatar-1-00.png", "/uploads/../photo.go"} {
+		if err := Remove(other); err != nil {
+			t.Errorf("Remove(%q): %v", other, err)
+		}
+	}
+	if _, err := os.Stat(dir + "/bye.png"); err != nil {
+		t.Errorf("a photo Save didn't name was removed: %v", err)
+	}
+
+	if err := Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat("." + path); !os.IsNotExist(err) {
+		t.Errorf("Stat after Remove error = %v, want the photo gone", err)
+	}
+}
@@ -0,0 +1,51 @@
+// Package purge removes deleted posts and comments for good once they have
+// been in the trash for longer than the retention period.
+package purge
+
+import (
+	"log"
+	"time"
+
+	"forum/backend/photo"
+	"forum/backend/store"
+)
+
+// Once purges what was deleted before now minus retention, along with the
+// uploaded photos only those posts showed.
+func Once(repos store.Store, retention time.Duration, now time.Time) (int, int, error) {
+	before := now.Add(-retention)
+	posts, photos, err := repos.Posts().Purge(before)
+	if err != nil {
+		return 0, 0, err
+	}
+	comments, err := repos.Comments().Purge(before)
+	if err != nil {
+		return posts, 0, err
+	}
+
+	RemovePhotos(photos)
+	return posts, comments, nil
+}
+
+// RemovePhotos deletes the uploaded photos, which no post may show anymore.
+// Only files the server named itself are removed.
+func RemovePhotos(photos []string) {
+	for _, path := range photos {
+		if err := photo.Remove(path); err != nil {
+			log.Printf("Failed to remove photo %s: %v", path, err)
+		}
+	}
+}
+
+// Run purges every interval until the process exits.
+func Run(repos store.Store, retention, interval time.Duration) {
+	for {
+		posts, comments, err := Once(repos, retention, time.Now().UTC())
+		if err != nil {
+			log.Printf("Failed to purge the trash: %v", err)
+		} else if posts > 0 || comments > 0 {
+			log.Printf("Purged %d posts and %d comments from the trash", posts, comments)
+		}
+		time.Sleep(interval)
+	}
+}
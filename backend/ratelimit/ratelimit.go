@@ -0,0 +1,164 @@
+// Package ratelimit counts failures per key, such as an IP address or an
+// account, and tells how long a key has to wait before its next attempt.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+
+	"forum/backend/store"
+)
+
+// Limiter counts failures in a row per key. A count is forgotten once the
+// key has gone the limiter's window without failing.
+type Limiter interface {
+	Failures(key string, now time.Time) (int, time.Time, error)
+	// Fail counts a failure and returns the new count.
+	Fail(key string, now time.Time) (int, error)
+	Reset(key string) error
+}
+
+// Policy lets the first Free failures through and then makes each attempt
+// wait twice as long as the last, starting at Base and capped at Max.
+type Policy struct {
+	Free int
+	Base time.Duration
+	Max  time.Duration
+}
+
+// RetryAfter is how long to wait after the given failures, the last of
+// which was at last. Zero means an attempt may be made now.
+func (p Policy) RetryAfter(failures int, last, now time.Time) time.Duration {
+	if failures <= p.Free {
+		return 0
+	}
+	delay := p.Max
+	if shift := failures - p.Free - 1; shift < 30 {
+		delay = min(p.Base<<shift, p.Max)
+	}
+	return max(last.Add(delay).Sub(now), 0)
+}
+
+// Memory keeps the counts in this process. It is enough for a single
+// instance; counts are lost on restart.
+type Memory struct {
+	window    time.Duration
+	mu        sync.Mutex
+	entries   map[string]entry
+	lastSweep time.Time
+}
+
+type entry struct {
+	failures int
+	last     time.Time
+}
+
+func NewMemory(window time.Duration) *Memory {
+	return &Memory{window: window, entries: make(map[string]entry)}
+}
+
+func (m *Memory) Failures(key string, now time.Time) (int, time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.entries[key]
+	if !ok || now.Sub(e.last) > m.window {
+		return 0, time.Time{}, nil
+	}
+	return e.failures, e.last, nil
+}
+
+func (m *Memory) Fail(key string, now time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sweep(now)
+
+	e := m.entries[key]
+	if now.Sub(e.last) > m.window {
+		e.failures = 0
+	}
+	e.failures++
+	e.last = now
+	m.entries[key] = e
+	return e.failures, nil
+}
+
+func (m *Memory) Reset(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.entries, key)
+	return nil
+}
+
+// sweep drops forgotten counts, at most once per window, so that an attack
+// from many addresses doesn't grow the map forever.
+func (m *Memory) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < m.window {
+		return
+	}
+	for key, e := range m.entries {
+		if now.Sub(e.last) > m.window {
+			delete(m.entries, key)
+		}
+	}
+	m.lastSweep = now
+}
+
+// Database keeps the counts in the database, so that every instance behind
+// a load balancer sees the same ones.
+type Database struct {
+	repo      store.RateLimitRepo
+	window    time.Duration
+	mu        sync.Mutex
+	lastPrune time.Time
+}
+
+func NewDatabase(repo store.RateLimitRepo, window time.Duration) *Database {
+	return &Database{repo: repo, window: window}
+}
+
+func (d *Database) Failures(key string, now time.Time) (int, time.Time, error) {
+	failures, last, err := d.repo.Failures(key)
+	if err != nil || now.Sub(last) > d.window {
+		return 0, time.Time{}, err
+	}
+	return failures, last, nil
+}
+
+func (d *Database) Fail(key string, now time.Time) (int, error) {
+	if err := d.prune(now); err != nil {
+		return 0, err
+	}
+	return d.repo.AddFailure(key, now, now.Add(-d.window))
+}
+
+func (d *Database) Reset(key string) error {
+	return d.repo.Reset(key)
+}
+
+func (d *Database) prune(now time.Time) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if now.Sub(d.lastPrune) < d.window {
+		return nil
+	}
+	if _, err := d.repo.DeleteBefore(now.Add(-d.window)); err != nil {
+		return err
+	}
+	d.lastPrune = now
+	return nil
+}
+
+var current Limiter
+
+// Set installs the limiter used for logins; it is called once at startup.
+func Set(l Limiter) {
+	current = l
+}
+
+func Get() Limiter {
+	return current
+}
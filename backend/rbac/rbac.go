@@ -0,0 +1,123 @@
+// Package rbac decides what each role may do. Handlers ask for a named
+// permission rather than checking roles themselves.
+package rbac
+
+import (
+	"slices"
+	"strings"
+)
+
+type Role string
+
+const (
+	// Guest is anyone who isn't logged in.
+	Guest     Role = "guest"
+	User      Role = "user"
+	Moderator Role = "moderator"
+	Admin     Role = "admin"
+)
+
+// Assignable are the roles a user account can have.
+var Assignable = []Role{User, Moderator, Admin}
+
+type Permission string
+
+const (
+	PostCreate       Permission = "post.create"
+	PostDeleteOwn    Permission = "post.delete.own"
+	PostDeleteAny    Permission = "post.delete.any"
+	PostEditOwn      Permission = "post.edit.own"
+	PostEditAny      Permission = "post.edit.any"
+	CommentCreate    Permission = "comment.create"
+	CommentDeleteOwn Permission = "comment.delete.own"
+	CommentDeleteAny Permission = "comment.delete.any"
+	CommentEditOwn   Permission = "comment.edit.own"
+	CommentEditAny   Permission = "comment.edit.any"
+	AnswerAcceptAny  Permission = "answer.accept.any"
+	Vote             Permission = "vote"
+	VoteDown         Permission = "vote.down"
+	ContentReport    Permission = "content.report"
+	ReportResolve    Permission = "report.resolve"
+	TagCreate        Permission = "tag.create"
+	TagEdit          Permission = "tag.edit"
+	TagDelete        Permission = "tag.delete"
+	UserBan          Permission = "user.ban"
+	UserUnlock       Permission = "user.unlock"
+	UserSessions     Permission = "user.sessions"
+	UserSetRole      Permission = "user.role"
+	UserReputation   Permission = "user.reputation"
+	AdminAccess      Permission = "admin.access"
+)
+
+// Each role has its own grants and those of the roles before it.
+var grants = map[Role][]Permission{
+	Guest: {},
+	User: {
+		PostCreate, PostDeleteOwn, PostEditOwn,
+		CommentCreate, CommentDeleteOwn, CommentEditOwn,
+		Vote, ContentReport,
+	},
+	Moderator: {
+		PostDeleteAny, PostEditAny,
+		CommentDeleteAny, CommentEditAny,
+		VoteDown, AnswerAcceptAny,
+		UserBan, UserUnlock, UserSessions, UserReputation,
+		ReportResolve, AdminAccess,
+	},
+	Admin: {
+		TagCreate, TagEdit, TagDelete,
+		UserSetRole,
+	},
+}
+
+var hierarchy = []Role{Guest, User, Moderator, Admin}
+
+// ParseRole reads a role as stored in USERS.Role. Accounts with a missing
+// or unknown role are regular users.
+func ParseRole(s string) Role {
+	role, ok := Lookup(s)
+	if !ok {
+		return User
+	}
+	return role
+}
+
+// Lookup finds the assignable role with the name, ignoring case.
+func Lookup(s string) (Role, bool) {
+	for _, role := range Assignable {
+		if strings.EqualFold(s, string(role)) {
+			return role, true
+		}
+	}
+	return "", false
+}
+
+func Can(role Role, p Permission) bool {
+	i := slices.Index(hierarchy, role)
+	if i < 0 {
+		return false
+	}
+	for _, r := range hierarchy[:i+1] {
+		if slices.Contains(grants[r], p) {
+			return true
+		}
+	}
+	return false
+}
+
+// Outranks reports whether the role is above the other one. Moderators
+// can only act on accounts below their own.
+func (r Role) Outranks(other Role) bool {
+	return slices.Index(hierarchy, r) > slices.Index(hierarchy, other)
+}
+
+// Elevated reports whether the permission goes beyond what every user
+// has. Using one requires two-factor authentication.
+func Elevated(p Permission) bool {
+	return !Can(User, p)
+}
+
+// Privileged reports whether the role has any elevated permissions.
+func (r Role) Privileged() bool {
+	return r == Moderator || r == Admin
+}
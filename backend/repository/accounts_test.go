@@ -0,0 +1,224 @@
+package repository_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"forum/backend/controllers/structs"
+	"forum/backend/store"
+)
+
+func TestSessionsExpireAndRevoke(t *testing.T) {
+	st := openStore(t)
+	userID := createUser(t, st, "user")
+	now := time.Now().UTC().Truncate(time.Second)
+	session := structs.Session{UserID: userID, UserAgent: "test", IP: "127.0.0.1", CreatedAt: now, LastSeenAt: now, ExpiresAt: now.Add(time.Hour)}
+	id, err := st.Sessions().Create(session, "hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found, name, err := st.Sessions().ByTokenHash("hash", now); err != nil || found.ID != id || name != "user" {
+		t.Fatalf("ByTokenHash = %+v %q, %v; want session %d of \"user\"", found, name, err, id)
+	}
+	if _, _, err := st.Sessions().ByTokenHash("hash", now.Add(2*time.Hour)); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("ByTokenHash after expiry error = %v, want ErrNotFound", err)
+	}
+
+	if err := st.Sessions().Delete(id, userID+1); !errors.Is(err, store.ErrNotFound) {
+		t.Fatalf("Delete by another user error = %v, want ErrNotFound", err)
+	}
+	if sessions, err := st.Sessions().ByUser(userID, now); err != nil || len(sessions) != 1 {
+		t.Fatalf("ByUser = %d sessions, %v; want 1", len(sessions), err)
+	}
+	if err := st.Sessions().Delete(id, userID); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := st.Sessions().ByTokenHash("hash", now); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("ByTokenHash after Delete error = %v, want ErrNotFound", err)
+	}
+
+	kept, err := st.Sessions().Create(session, "kept")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, hash := range []string{"phone", "laptop"} {
+		if _, err := st.Sessions().Create(session, hash); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := st.Sessions().DeleteOthers(userID, kept); err != nil {
+		t.Fatal(err)
+	}
+	if sessions, err := st.Sessions().ByUser(userID, now); err != nil || len(sessions) != 1 || sessions[0].ID != kept {
+		t.Errorf("ByUser after DeleteOthers = %+v, %v; want only session %d", sessions, err, kept)
+	}
+}
+
+func TestOAuthStateTakenOnce(t *testing.T) {
+	st := openStore(t)
+	now := time.Now()
+	state := store.OAuthState{Provider: "google", CodeVerifier: "verifier", Nonce: "nonce", ExpiresAt: now.Add(time.Minute)}
+	if err := st.OAuthStates().Create("hash", state, now); err != nil {
+		t.Fatal(err)
+	}
+
+	taken, err := st.OAuthStates().Take("hash", now)
+	if err != nil || taken.Nonce != "nonce" || taken.CodeVerifier != "verifier" {
+		t.Fatalf("Take = %+v, %v; want the state", taken, err)
+	}
+	if _, err := st.OAuthStates().Take("hash", now); err == nil {
+		t.Fatal("state was taken twice")
+	}
+
+	if err := st.OAuthStates().Create("late", state, now); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.OAuthStates().Take("late", now.Add(2*time.Minute)); err == nil {
+		t.Fatal("expired state was taken")
+	}
+}
+
+func TestUnlinkKeepsALoginMethod(t *testing.T) {
+	st := openStore(t)
+	identity := structs.Identity{Provider: "github", Subject: "42", Email: "octo@example.com", CreatedAt: time.Now()}
+	userID, err := st.Users().FindOrCreateByIdentity(identity, "octo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, err := st.Users().FindOrCreateByIdentity(identity, "octo"); err != nil || again != userID {
+		t.Fatalf("second login = user %d, %v; want %d", again, err, userID)
+	}
+	if owner, err := st.Identities().UserID("github", "42"); err != nil || owner != userID {
+		t.Fatalf("UserID = %d, %v; want %d", owner, err, userID)
+	}
+	if _, err := st.Identities().UserID("github", "43"); !errors.Is(err, store.ErrNotFound) {
+		t.Fatalf("UserID of an unknown account error = %v, want ErrNotFound", err)
+	}
+
+	identities, err := st.Identities().ByUser(userID)
+	if err != nil || len(identities) != 1 {
+		t.Fatalf("ByUser = %+v, %v; want the one identity", identities, err)
+	}
+	if err := st.Identities().Unlink(userID, identities[0].ID); !errors.Is(err, store.ErrLastLoginMethod) {
+		t.Fatalf("Unlink of the only login error = %v, want ErrLastLoginMethod", err)
+	}
+
+	other := createUser(t, st, "other")
+	identity.UserID = other
+	if err := st.Identities().Link(identity); !errors.Is(err, store.ErrIdentityTaken) {
+		t.Fatalf("Link to another user error = %v, want ErrIdentityTaken", err)
+	}
+}
+
+// Signing in with a provider takes over an account with the same email only
+// as far as the provider vouches for it: whatever a stranger could have set
+// up on an unverified account is dropped, a verified account keeps its
+// password.
+func TestProviderLoginTakesUnverifiedAccount(t *testing.T) {
+	st := openStore(t)
+	now := time.Now().UTC().Truncate(time.Second)
+	squatted := createUser(t, st, "squatted")
+	if _, err := st.Sessions().Create(structs.Session{UserID: squatted, CreatedAt: now, LastSeenAt: now, ExpiresAt: now.Add(time.Hour)}, "hash"); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.TwoFactor().Begin(squatted, "secret", now); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.TwoFactor().Enable(squatted, 1, []string{"code"}); err != nil {
+		t.Fatal(err)
+	}
+	planted := structs.Identity{UserID: squatted, Provider: "github", Subject: "attacker", CreatedAt: now}
+	if err := st.Identities().Link(planted); err != nil {
+		t.Fatal(err)
+	}
+
+	identity := structs.Identity{Provider: "google", Subject: "owner", Email: "squatted@example.com", CreatedAt: now}
+	userID, err := st.Users().FindOrCreateByIdentity(identity, "owner")
+	if err != nil || userID != squatted {
+		t.Fatalf("FindOrCreateByIdentity = %d, %v; want user %d", userID, err, squatted)
+	}
+	user, err := st.Users().ByID(squatted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Password != "" || !user.EmailVerified {
+		t.Errorf("password %q, verified %v; want no password and verified", user.Password, user.EmailVerified)
+	}
+	if sessions, err := st.Sessions().ByUser(squatted, now); err != nil || len(sessions) != 0 {
+		t.Errorf("%d sessions left, %v; want none", len(sessions), err)
+	}
+	if _, err := st.TwoFactor().ByUser(squatted); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("two-factor error = %v, want ErrNotFound", err)
+	}
+	if identities, err := st.Identities().ByUser(squatted); err != nil || len(identities) != 1 || identities[0].Subject != "owner" {
+		t.Errorf("identities = %+v, %v; want only the owner's", identities, err)
+	}
+
+	verified := createUser(t, st, "verified")
+	if err := st.Users().SetEmailVerified(verified); err != nil {
+		t.Fatal(err)
+	}
+	identity = structs.Identity{Provider: "google", Subject: "verified", Email: "verified@example.com", CreatedAt: now}
+	if userID, err := st.Users().FindOrCreateByIdentity(identity, "verified"); err != nil || userID != verified {
+		t.Fatalf("FindOrCreateByIdentity = %d, %v; want user %d", userID, err, verified)
+	}
+	if user, err := st.Users().ByID(verified); err != nil || user.Password != "hash" {
+		t.Errorf("verified user's password = %q, %v; want it kept", user.Password, err)
+	}
+}
+
+func TestTwoFactorStepsOnlyMoveForward(t *testing.T) {
+	st := openStore(t)
+	userID := createUser(t, st, "user")
+	if err := st.TwoFactor().Begin(userID, "secret", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.TwoFactor().Enable(userID, 100, []string{"code-a", "code-b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, step := range []struct {
+		step int64
+		ok   bool
+	}{{100, false}, {99, false}, {101, true}, {101, false}} {
+		if ok, err := st.TwoFactor().UseStep(userID, step.step); err != nil || ok != step.ok {
+			t.Errorf("UseStep(%d) = %v, %v; want %v", step.step, ok, err, step.ok)
+		}
+	}
+
+	if ok, err := st.TwoFactor().UseRecoveryCode(userID, "code-a", time.Now()); err != nil || !ok {
+		t.Fatalf("UseRecoveryCode = %v, %v; want true", ok, err)
+	}
+	if ok, err := st.TwoFactor().UseRecoveryCode(userID, "code-a", time.Now()); err != nil || ok {
+		t.Fatalf("second UseRecoveryCode = %v, %v; want false", ok, err)
+	}
+	if left, err := st.TwoFactor().RecoveryCodesLeft(userID); err != nil || left != 1 {
+		t.Errorf("RecoveryCodesLeft = %d, %v; want 1", left, err)
+	}
+}
+
+func TestRateLimitCounts(t *testing.T) {
+	st := openStore(t)
+	now := time.Now().UTC().Truncate(time.Second)
+	window := now.Add(-time.Minute)
+
+	for want := 1; want <= 3; want++ {
+		count, err := st.RateLimits().AddFailure("ip:1", now, window)
+		if err != nil || count != want {
+			t.Fatalf("AddFailure = %d, %v; want %d", count, err, want)
+		}
+	}
+	// A failure after the window has passed starts the count over.
+	later := now.Add(2 * time.Minute)
+	if count, err := st.RateLimits().AddFailure("ip:1", later, later.Add(-time.Minute)); err != nil || count != 1 {
+		t.Fatalf("AddFailure after the window = %d, %v; want 1", count, err)
+	}
+
+	if err := st.RateLimits().Reset("ip:1"); err != nil {
+		t.Fatal(err)
+	}
+	if count, _, err := st.RateLimits().Failures("ip:1"); err != nil || count != 0 {
+		t.Fatalf("Failures after Reset = %d, %v; want 0", count, err)
+	}
+}
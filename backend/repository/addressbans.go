@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+
+	"forum/backend/controllers/structs"
+	"forum/backend/store"
+)
+
+type AddressBanRepo struct {
+	db *conn
+}
+
+func (r *AddressBanRepo) All() ([]structs.AddressBan, error) {
+	rows, err := r.db.Query(`SELECT ID, Kind, Value, Reason, CreatedBy, CreatedAt FROM address_bans ORDER BY Kind, Value`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bans []structs.AddressBan
+	for rows.Next() {
+		var ban structs.AddressBan
+		if err := rows.Scan(&ban.ID, &ban.Kind, &ban.Value, &ban.Reason, &ban.CreatedBy, &ban.CreatedAt); err != nil {
+			return nil, err
+		}
+		bans = append(bans, ban)
+	}
+
+	return bans, rows.Err()
+}
+
+func (r *AddressBanRepo) Create(ban structs.AddressBan) (int, error) {
+	var id int
+	err := r.db.QueryRow(`INSERT INTO address_bans (Kind, Value, Reason, CreatedBy, CreatedAt) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (Kind, Value) DO NOTHING RETURNING ID`,
+		ban.Kind, ban.Value, ban.Reason, ban.CreatedBy, ban.CreatedAt.UTC()).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, store.ErrAddressBanned
+	}
+	return id, err
+}
+
+func (r *AddressBanRepo) Delete(id int) error {
+	result, err := r.db.Exec(`DELETE FROM address_bans WHERE ID = ?`, id)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
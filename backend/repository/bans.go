@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"forum/backend/controllers/structs"
+	"forum/backend/store"
+)
+
+type BanRepo struct {
+	db *conn
+}
+
+const banColumns = "ID, UserID, Reason, BannedBy, CreatedAt, ExpiresAt, Shadow, LiftedAt, LiftedBy"
+
+// inForce is the condition for a ban to apply at the time bound to it.
+const inForce = "LiftedAt IS NULL AND (ExpiresAt IS NULL OR ExpiresAt > ?)"
+
+func (r *BanRepo) Active(userID int, now time.Time) (structs.Ban, error) {
+	ban, err := scanBan(r.db.QueryRow("SELECT "+banColumns+" FROM bans WHERE UserID = ? AND "+inForce, userID, now.UTC()))
+	if errors.Is(err, sql.ErrNoRows) {
+		return structs.Ban{}, store.ErrNotFound
+	}
+	return ban, err
+}
+
+func (r *BanRepo) Ban(ban structs.Ban) (int, error) {
+	var id int
+	err := r.db.withTx(func(tx *tx) error {
+		var err error
+		id, err = placeBan(tx, ban)
+		return err
+	})
+	return id, err
+}
+
+// placeBan adds the ban, or returns store.ErrAlreadyBanned if the user has
+// one in force.
+func placeBan(tx *tx, ban structs.Ban) (int, error) {
+	var banned bool
+	err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM bans WHERE UserID = ? AND "+inForce+")", ban.UserID, ban.CreatedAt.UTC()).Scan(&banned)
+	if err != nil {
+		return 0, err
+	}
+	if banned {
+		return 0, store.ErrAlreadyBanned
+	}
+	var id int
+	err = tx.QueryRow(`INSERT INTO bans (UserID, Reason, BannedBy, CreatedAt, ExpiresAt, Shadow) VALUES (?, ?, ?, ?, ?, ?) RETURNING ID`,
+		ban.UserID, ban.Reason, ban.BannedBy, ban.CreatedAt.UTC(), nullTime(ban.ExpiresAt), ban.Shadow).Scan(&id)
+	return id, err
+}
+
+func (r *BanRepo) Lift(userID, liftedBy int, now time.Time) error {
+	result, err := r.db.Exec("UPDATE bans SET LiftedAt = ?, LiftedBy = ? WHERE UserID = ? AND "+inForce,
+		now.UTC(), liftedBy, userID, now.UTC())
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (r *BanRepo) ByUser(userID int) ([]structs.Ban, error) {
+	rows, err := r.db.Query("SELECT "+banColumns+" FROM bans WHERE UserID = ? ORDER BY ID DESC", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bans []structs.Ban
+	for rows.Next() {
+		ban, err := scanBan(rows)
+		if err != nil {
+			return nil, err
+		}
+		bans = append(bans, ban)
+	}
+
+	return bans, rows.Err()
+}
+
+func scanBan(row interface{ Scan(...any) error }) (structs.Ban, error) {
+	var ban structs.Ban
+	var expiresAt, liftedAt sql.NullTime
+	var liftedBy sql.NullInt64
+	err := row.Scan(&ban.ID, &ban.UserID, &ban.Reason, &ban.BannedBy, &ban.CreatedAt, &expiresAt, &ban.Shadow, &liftedAt, &liftedBy)
+	ban.ExpiresAt = expiresAt.Time
+	ban.LiftedAt = liftedAt.Time
+	ban.LiftedBy = int(liftedBy.Int64)
+	return ban, err
+}
+
+// nullTime stores the zero time as NULL.
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t.UTC(), Valid: !t.IsZero()}
+}
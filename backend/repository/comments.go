@@ -0,0 +1,321 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"forum/backend/controllers/structs"
+	"forum/backend/store"
+)
+
+type CommentRepo struct {
+	db *conn
+}
+
+const commentColumns = "COMMENTS.ID, COMMENTS.PostId, COMMENTS.UserId, COMMENTS.Comment, COMMENTS.UserName, COMMENTS.LikeCount, COMMENTS.UpCount, COMMENTS.DownCount, COMMENTS.Hidden, COMMENTS.Shadow, COMMENTS.EditedAt, COMMENTS.DeletedAt, COMMENTS.DeletedBy, COMMENTS.ParentID, COMMENTS.CommentHTML"
+
+// ByPost lists a post's top-level comments, deleted ones included.
+func (r *CommentRepo) ByPost(postID int, audience store.Audience, sort store.CommentSort, page store.Page) ([]structs.Comment, bool, error) {
+	where, args := visible("COMMENTS", "COMMENTS.UserId", audience)
+	where = append([]string{"COMMENTS.PostId = ?", "COMMENTS.ParentID IS NULL"}, where...)
+	args = append([]any{postID}, args...)
+	if sort != store.SortTop {
+		return r.paged(where, args, sort == store.SortNewest, page)
+	}
+
+	comments, err := r.list("SELECT "+commentColumns+" FROM COMMENTS WHERE "+strings.Join(where, " AND ")+
+		" ORDER BY "+commentOrder(sort)+" LIMIT ? OFFSET ?", append(args, page.Limit+1, page.Offset)...)
+	if err != nil {
+		return nil, false, err
+	}
+	comments, more := pageOf(comments, page)
+	return comments, more, nil
+}
+
+func (r *CommentRepo) Replies(parentIDs []int, audience store.Audience, sort store.CommentSort) ([]structs.Comment, error) {
+	if len(parentIDs) == 0 {
+		return nil, nil
+	}
+	parents, args := inList("COMMENTS.ParentID", parentIDs)
+	where, visibleArgs := visible("COMMENTS", "COMMENTS.UserId", audience)
+	return r.list("SELECT "+commentColumns+" FROM COMMENTS WHERE "+strings.Join(append([]string{parents}, where...), " AND ")+
+		" ORDER BY "+commentOrder(sort), append(args, visibleArgs...)...)
+}
+
+func (r *CommentRepo) ReplyCounts(parentIDs []int, audience store.Audience) (map[int]int, error) {
+	counts := map[int]int{}
+	if len(parentIDs) == 0 {
+		return counts, nil
+	}
+	parents, args := inList("COMMENTS.ParentID", parentIDs)
+	where, visibleArgs := visible("COMMENTS", "COMMENTS.UserId", audience)
+	rows, err := r.db.Query("SELECT COMMENTS.ParentID, COUNT(*) FROM COMMENTS WHERE "+strings.Join(append([]string{parents}, where...), " AND ")+
+		" GROUP BY COMMENTS.ParentID", append(args, visibleArgs...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var parentID, count int
+		if err := rows.Scan(&parentID, &count); err != nil {
+			return nil, err
+		}
+		counts[parentID] = count
+	}
+	return counts, rows.Err()
+}
+
+func commentOrder(sort store.CommentSort) string {
+	switch sort {
+	case store.SortNewest:
+		return "COMMENTS.ID DESC"
+	case store.SortTop:
+		return "COMMENTS.LikeCount DESC, COMMENTS.ID"
+	}
+	return "COMMENTS.ID"
+}
+
+func (r *CommentRepo) All(page store.Page) ([]structs.Comment, bool, error) {
+	return r.paged(nil, nil, true, page)
+}
+
+func (r *CommentRepo) ByUser(userID int, page store.Page) ([]structs.Comment, bool, error) {
+	return r.paged([]string{"COMMENTS.UserId = ?", "COMMENTS.DeletedAt IS NULL"}, []any{userID}, true, page)
+}
+
+func (r *CommentRepo) ByAuthor(userID int, audience store.Audience, page store.Page) ([]structs.Comment, bool, error) {
+	where, args := visible("COMMENTS", "COMMENTS.UserId", audience)
+	posts, postArgs := visible("POSTS", "POSTS.UserID", audience)
+	where = append([]string{"COMMENTS.UserId = ?", "COMMENTS.DeletedAt IS NULL",
+		"COMMENTS.PostId IN (SELECT POSTS.ID FROM POSTS WHERE " + strings.Join(append(posts, "POSTS.DeletedAt IS NULL"), " AND ") + ")"}, where...)
+	args = append(append([]any{userID}, postArgs...), args...)
+	return r.paged(where, args, true, page)
+}
+
+func (r *CommentRepo) ByID(id int) (structs.Comment, error) {
+	comments, err := r.list("SELECT "+commentColumns+" FROM COMMENTS WHERE ID = ?", id)
+	if err != nil {
+		return structs.Comment{}, err
+	}
+	if len(comments) == 0 {
+		return structs.Comment{}, store.ErrNotFound
+	}
+	return comments[0], nil
+}
+
+func (r *CommentRepo) Owner(id int) (int, string, error) {
+	var userID int
+	var userName string
+	err := r.db.QueryRow("SELECT UserId, UserName FROM COMMENTS WHERE ID = ? AND DeletedAt IS NULL", id).Scan(&userID, &userName)
+	return userID, userName, err
+}
+
+func (r *CommentRepo) Create(comment structs.Comment) (int, error) {
+	var id int
+	parentID := sql.NullInt64{Int64: int64(comment.ParentID), Valid: comment.ParentID != 0}
+	err := r.db.QueryRow(`INSERT INTO COMMENTS (PostId, UserId, UserName, Comment, CommentHTML, Shadow, ParentID) VALUES (?, ?, ?, ?, ?, ?, ?) RETURNING ID`,
+		comment.PostId, comment.UserId, comment.UserName, comment.Comment, comment.CommentHTML, comment.Shadow, parentID).Scan(&id)
+	return id, err
+}
+
+// Edit keeps the current text as a revision before replacing it.
+func (r *CommentRepo) Edit(id int, text, html string, editorID int, now time.Time) error {
+	return r.db.withTx(func(tx *tx) error {
+		if err := addRevision(tx, id, true); err != nil {
+			return err
+		}
+		_, err := tx.Exec(`UPDATE COMMENTS SET Comment = ?, CommentHTML = ?, EditedAt = ?, EditedBy = ? WHERE ID = ?`, text, html, now.UTC(), editorID, id)
+		return err
+	})
+}
+
+func (r *CommentRepo) Rerender(render func(source string) string, all bool) (int, error) {
+	return rerender(r.db, "COMMENTS", "Comment", "CommentHTML", render, all)
+}
+
+func (r *CommentRepo) SetHidden(id int, hidden bool) error {
+	_, err := r.db.Exec(`UPDATE COMMENTS SET Hidden = ? WHERE ID = ?`, hidden, id)
+	return err
+}
+
+// Delete moves the comment to the trash.
+func (r *CommentRepo) Delete(id, deletedBy int, now time.Time) error {
+	return changedOne(r.db.Exec(`UPDATE COMMENTS SET DeletedAt = ?, DeletedBy = ? WHERE ID = ? AND DeletedAt IS NULL`, now.UTC(), deletedBy, id))
+}
+
+func (r *CommentRepo) Restore(id int) error {
+	return changedOne(r.db.Exec(`UPDATE COMMENTS SET DeletedAt = NULL, DeletedBy = NULL WHERE ID = ? AND DeletedAt IS NOT NULL`, id))
+}
+
+func (r *CommentRepo) Trash(userID int) ([]structs.Comment, error) {
+	return r.list("SELECT "+commentColumns+" FROM COMMENTS WHERE UserId = ? AND DeletedAt IS NOT NULL ORDER BY DeletedAt DESC, ID DESC", userID)
+}
+
+func (r *CommentRepo) Purge(before time.Time) (int, error) {
+	var purged int
+	err := r.db.withTx(func(tx *tx) error {
+		// Removing replies can leave their parents without any, so go on
+		// until nothing changes.
+		for {
+			ids, err := queryIDs(tx, `SELECT ID FROM COMMENTS WHERE DeletedAt < ?
+				AND NOT EXISTS (SELECT 1 FROM COMMENTS AS reply WHERE reply.ParentID = COMMENTS.ID)`, before.UTC())
+			if err != nil {
+				return err
+			}
+			if len(ids) == 0 {
+				break
+			}
+			for _, id := range ids {
+				if err := purgeComment(tx, id); err != nil {
+					return err
+				}
+			}
+			purged += len(ids)
+		}
+
+		ids, err := queryIDs(tx, `SELECT ID FROM COMMENTS WHERE DeletedAt < ? AND UserId <> 0`, before.UTC())
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if err := scrubComment(tx, id); err != nil {
+				return err
+			}
+		}
+		purged += len(ids)
+		return nil
+	})
+	return purged, err
+}
+
+func queryIDs(tx *tx, query string, args ...any) ([]int, error) {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func queryStrings(tx *tx, query string, args ...any) ([]string, error) {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, rows.Err()
+}
+
+// scrubComment empties a deleted comment that still has replies, leaving
+// only its place in the thread.
+func scrubComment(tx *tx, id int) error {
+	if err := dropDerivedEvents(tx, "IsComment = ? AND TargetID = ?", true, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM reports WHERE IsComment = ? AND TargetID = ? AND ResolvedAt IS NULL`, true, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM revisions WHERE IsComment = ? AND TargetID = ?`, true, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM USERLIKES WHERE PostID = ? AND IsComment = ?`, id, true); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE POSTS SET AcceptedCommentID = NULL WHERE AcceptedCommentID = ?`, id); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`UPDATE COMMENTS SET UserId = 0, UserName = '', Comment = '', CommentHTML = '', LikeCount = 0, UpCount = 0, DownCount = 0 WHERE ID = ?`, id)
+	return err
+}
+
+// purgeComment removes the comment, its revisions, the votes cast on it and
+// its open reports.
+func purgeComment(tx *tx, id int) error {
+	if err := dropDerivedEvents(tx, "IsComment = ? AND TargetID = ?", true, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM reports WHERE IsComment = ? AND TargetID = ? AND ResolvedAt IS NULL`, true, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM revisions WHERE IsComment = ? AND TargetID = ?`, true, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE POSTS SET AcceptedCommentID = NULL WHERE AcceptedCommentID = ?`, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM COMMENTS WHERE ID = ?`, id); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`DELETE FROM USERLIKES WHERE PostID = ? AND IsComment = ?`, id, true)
+	return err
+}
+
+func (r *CommentRepo) paged(where []string, args []any, newestFirst bool, page store.Page) ([]structs.Comment, bool, error) {
+	cond, keyArg, order := keyset("COMMENTS.ID", newestFirst, page)
+	if cond != "" {
+		where = append(where, cond)
+		args = append(args, keyArg)
+	}
+
+	query := "SELECT " + commentColumns + " FROM COMMENTS"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY " + order + " LIMIT ?"
+
+	comments, err := r.list(query, append(args, page.Limit+1)...)
+	if err != nil {
+		return nil, false, err
+	}
+	comments, more := pageOf(comments, page)
+	return comments, more, nil
+}
+
+func (r *CommentRepo) list(query string, args ...any) ([]structs.Comment, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comments []structs.Comment
+	for rows.Next() {
+		var comment structs.Comment
+		var editedAt, deletedAt sql.NullTime
+		var deletedBy, parentID sql.NullInt64
+		var commentHTML sql.NullString
+		err := rows.Scan(&comment.ID, &comment.PostId, &comment.UserId, &comment.Comment, &comment.UserName, &comment.LikeCount, &comment.UpCount, &comment.DownCount,
+			&comment.Hidden, &comment.Shadow, &editedAt, &deletedAt, &deletedBy, &parentID, &commentHTML)
+		if err != nil {
+			return nil, err
+		}
+		comment.EditedAt = editedAt.Time
+		comment.DeletedAt = deletedAt.Time
+		comment.DeletedBy = int(deletedBy.Int64)
+		comment.ParentID = int(parentID.Int64)
+		comment.CommentHTML = commentHTML.String
+		comments = append(comments, comment)
+	}
+
+	return comments, rows.Err()
+}
@@ -0,0 +1,173 @@
+package repository_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"forum/backend/controllers/structs"
+	"forum/backend/store"
+)
+
+func reply(t *testing.T, st store.Store, postID, parentID, userID int, text string) int {
+	t.Helper()
+	id, err := st.Comments().Create(structs.Comment{PostId: postID, ParentID: parentID, UserId: userID, UserName: "user", Comment: text})
+	if err != nil {
+		t.Fatalf("create reply: %v", err)
+	}
+	return id
+}
+
+func TestCommentsByPostAndUser(t *testing.T) {
+	st := openStore(t)
+	author := createUser(t, st, "author")
+	reader := createUser(t, st, "reader")
+	postID := createPost(t, st, author, "Comments", "Say something")
+	otherPost := createPost(t, st, author, "Other", "Elsewhere")
+	first := createComment(t, st, postID, reader, "First")
+	createComment(t, st, postID, author, "Second")
+	createComment(t, st, otherPost, reader, "Somewhere else")
+
+	comments, more, err := st.Comments().ByPost(postID, store.Audience{}, store.SortOldest, store.Page{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comments) != 2 || more {
+		t.Fatalf("ByPost = %d comments, more %v; want 2 and no more", len(comments), more)
+	}
+	if comments, more, err := st.Comments().ByPost(postID, store.Audience{}, store.SortOldest, store.Page{Limit: 1}); err != nil || len(comments) != 1 || !more {
+		t.Fatalf("ByPost one at a time = %d comments, more %v, %v; want 1 and more", len(comments), more, err)
+	}
+
+	mine, _, err := st.Comments().ByUser(reader, store.Page{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mine) != 2 {
+		t.Fatalf("ByUser = %d comments, want 2", len(mine))
+	}
+
+	if owner, _, err := st.Comments().Owner(first); err != nil || owner != reader {
+		t.Errorf("Owner = %d, %v; want %d", owner, err, reader)
+	}
+}
+
+func TestPurgedPostTakesItsComments(t *testing.T) {
+	st := openStore(t)
+	author := createUser(t, st, "author")
+	postID := createPost(t, st, author, "Doomed", "Content")
+	createComment(t, st, postID, author, "Goes with it")
+
+	if err := st.Posts().Delete(postID, author, time.Now().Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if trash, err := st.Posts().Trash(author); err != nil || len(trash) != 1 || trash[0].ID != postID {
+		t.Fatalf("Trash = %+v, %v; want the deleted post", trash, err)
+	}
+	if purged, _, err := st.Posts().Purge(time.Now()); err != nil || purged != 1 {
+		t.Fatalf("Purge = %d, %v; want 1", purged, err)
+	}
+	if _, err := st.Posts().ByID(postID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("ByID after Purge error = %v, want ErrNotFound", err)
+	}
+	if comments, _, err := st.Comments().ByPost(postID, store.Audience{}, store.SortOldest, store.Page{Limit: 10}); err != nil || len(comments) != 0 {
+		t.Errorf("ByPost after Purge = %d comments, %v; want none", len(comments), err)
+	}
+}
+
+func TestCommentEditKeepsRevisions(t *testing.T) {
+	st := openStore(t)
+	author := createUser(t, st, "author")
+	postID := createPost(t, st, author, "Edits", "Content")
+	commentID := createComment(t, st, postID, author, "First version")
+
+	if err := st.Comments().Edit(commentID, "Second version", "<p>Second version</p>", author, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	comment, err := st.Comments().ByID(commentID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if comment.Comment != "Second version" || comment.EditedAt.IsZero() {
+		t.Errorf("comment = %q edited at %v, want the second version marked edited", comment.Comment, comment.EditedAt)
+	}
+
+	history, err := st.Revisions().History(commentID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[0].Body != "First version" || history[1].Body != "Second version" {
+		t.Fatalf("History = %+v, want the first and second versions", history)
+	}
+}
+
+func TestCommentThreads(t *testing.T) {
+	st := openStore(t)
+	author := createUser(t, st, "author")
+	postID := createPost(t, st, author, "Threads", "Reply to me")
+	top := createComment(t, st, postID, author, "Top")
+	first := reply(t, st, postID, top, author, "First reply")
+	second := reply(t, st, postID, top, author, "Second reply")
+	reply(t, st, postID, first, author, "Reply to the first reply")
+
+	comments, more, err := st.Comments().ByPost(postID, store.Audience{}, store.SortOldest, store.Page{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comments) != 1 || comments[0].ID != top || more {
+		t.Fatalf("ByPost = %d comments, more %v; want the top-level one only", len(comments), more)
+	}
+
+	replies, err := st.Comments().Replies([]int{top}, store.Audience{}, store.SortNewest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(replies) != 2 || replies[0].ID != second || replies[1].ID != first {
+		t.Fatalf("Replies newest first = %+v, want %d then %d", replies, second, first)
+	}
+	counts, err := st.Comments().ReplyCounts([]int{top, first, second}, store.Audience{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counts[top] != 2 || counts[first] != 1 {
+		t.Errorf("ReplyCounts = %v, want %d: 2, %d: 1", counts, top, first)
+	}
+	if _, ok := counts[second]; ok {
+		t.Errorf("ReplyCounts includes %d, which has no replies", second)
+	}
+}
+
+func TestPurgeEmptiesCommentsWithReplies(t *testing.T) {
+	st := openStore(t)
+	author := createUser(t, st, "author")
+	postID := createPost(t, st, author, "Purge", "Content")
+	parent := createComment(t, st, postID, author, "Parent")
+	child := reply(t, st, postID, parent, author, "Child")
+
+	deletedAt := time.Now().Add(-time.Hour)
+	if err := st.Comments().Delete(parent, author, deletedAt); err != nil {
+		t.Fatal(err)
+	}
+	if purged, err := st.Comments().Purge(time.Now()); err != nil || purged != 1 {
+		t.Fatalf("Purge with a reply left = %d, %v; want the parent emptied", purged, err)
+	}
+	emptied, err := st.Comments().ByID(parent)
+	if err != nil {
+		t.Fatalf("parent with a reply was removed: %v", err)
+	}
+	if emptied.Comment == "Parent" || emptied.UserId != 0 {
+		t.Errorf("emptied parent = %q by user %d, want its text and author gone", emptied.Comment, emptied.UserId)
+	}
+
+	if err := st.Comments().Delete(child, author, deletedAt); err != nil {
+		t.Fatal(err)
+	}
+	if purged, err := st.Comments().Purge(time.Now()); err != nil || purged != 2 {
+		t.Fatalf("Purge = %d, %v; want both comments", purged, err)
+	}
+	for _, id := range []int{parent, child} {
+		if _, err := st.Comments().ByID(id); err == nil {
+			t.Errorf("comment %d is still there after Purge", id)
+		}
+	}
+}
@@ -0,0 +1,411 @@
+package repository_test
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"forum/backend/config"
+	"forum/backend/controllers/structs"
+	"forum/backend/database"
+	"forum/backend/store"
+)
+
+// The conformance tests hold both implementations of store.Store to the
+// same behavior. They always run against in-memory SQLite, and against
+// PostgreSQL: the server TEST_DATABASE_URL points at, or else a throwaway
+// cluster started from the initdb and pg_ctl on PATH. Every test gets a
+// schema of its own there, dropped when it ends.
+const postgresEnv = "TEST_DATABASE_URL"
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	stopPostgres()
+	os.Exit(code)
+}
+
+func eachStore(t *testing.T, test func(t *testing.T, st store.Store)) {
+	t.Run("sqlite", func(t *testing.T) { test(t, openStore(t)) })
+	t.Run("postgres", func(t *testing.T) { test(t, openPostgres(t)) })
+}
+
+var errNoPostgres = errors.New(postgresEnv + " is not set and initdb or pg_ctl is not on PATH")
+
+// cluster is the throwaway PostgreSQL server, started by the first test
+// that needs it and stopped by TestMain.
+var cluster struct {
+	once  sync.Once
+	pgCtl string
+	dir   string
+	dsn   string
+	err   error
+}
+
+func postgresDSN() (string, error) {
+	if dsn := os.Getenv(postgresEnv); dsn != "" {
+		return dsn, nil
+	}
+	cluster.once.Do(startPostgres)
+	return cluster.dsn, cluster.err
+}
+
+// startPostgres creates a cluster in a temporary directory and starts it on
+// a free local port, with fsync off since nothing in it has to survive.
+func startPostgres() {
+	initdb, err := exec.LookPath("initdb")
+	if err != nil {
+		cluster.err = errNoPostgres
+		return
+	}
+	cluster.pgCtl, err = exec.LookPath("pg_ctl")
+	if err != nil {
+		cluster.err = errNoPostgres
+		return
+	}
+
+	cluster.dir, cluster.err = os.MkdirTemp("", "forum-postgres")
+	if cluster.err != nil {
+		return
+	}
+	data := filepath.Join(cluster.dir, "data")
+	if out, err := exec.Command(initdb, "-D", data, "-U", "postgres", "-A", "trust", "-E", "UTF8", "--no-sync").CombinedOutput(); err != nil {
+		cluster.err = fmt.Errorf("initdb: %v\n%s", err, out)
+		return
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		cluster.err = err
+		return
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	options := fmt.Sprintf("-h 127.0.0.1 -p %d -k %s -F", port, cluster.dir)
+	start := exec.Command(cluster.pgCtl, "-D", data, "-l", filepath.Join(cluster.dir, "postgres.log"), "-o", options, "-w", "start")
+	if out, err := start.CombinedOutput(); err != nil {
+		cluster.err = fmt.Errorf("pg_ctl start: %v\n%s", err, out)
+		return
+	}
+	cluster.dsn = fmt.Sprintf("postgres://postgres@127.0.0.1:%d/postgres?sslmode=disable", port)
+}
+
+func stopPostgres() {
+	if cluster.dir == "" {
+		return
+	}
+	if cluster.dsn != "" {
+		exec.Command(cluster.pgCtl, "-D", filepath.Join(cluster.dir, "data"), "-m", "immediate", "-w", "stop").Run()
+	}
+	os.RemoveAll(cluster.dir)
+}
+
+func openPostgres(t *testing.T) store.Store {
+	t.Helper()
+	dsn, err := postgresDSN()
+	if errors.Is(err, errNoPostgres) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	admin, err := sql.Open(config.DriverPostgres, dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { admin.Close() })
+	schema := fmt.Sprintf("forum_test_%d", time.Now().UnixNano())
+	if _, err := admin.Exec("CREATE SCHEMA " + schema); err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() { admin.Exec("DROP SCHEMA " + schema + " CASCADE") })
+
+	st, err := database.Connect(config.Config{DatabaseDriver: config.DriverPostgres, DatabaseURL: withSearchPath(dsn, schema)})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	t.Cleanup(func() { st.Close() })
+	return st
+}
+
+// withSearchPath makes every connection of the DSN, URL or key=value form,
+// work in the schema.
+func withSearchPath(dsn, schema string) string {
+	switch {
+	case !strings.Contains(dsn, "://"):
+		return dsn + " search_path=" + schema
+	case strings.Contains(dsn, "?"):
+		return dsn + "&search_path=" + schema
+	}
+	return dsn + "?search_path=" + schema
+}
+
+func TestConformanceUsers(t *testing.T) {
+	eachStore(t, func(t *testing.T, st store.Store) {
+		id := createUser(t, st, "alice")
+
+		byEmail, err := st.Users().ByEmail("alice@example.com")
+		if err != nil || byEmail.ID != id || byEmail.UserName != "alice" {
+			t.Fatalf("ByEmail = %+v, %v; want alice", byEmail, err)
+		}
+		if _, err := st.Users().ByID(id + 100); err == nil {
+			t.Errorf("ByID(missing) found a user")
+		}
+		if taken, err := st.Users().EmailTaken("alice@example.com"); err != nil || !taken {
+			t.Errorf("EmailTaken = %v, %v; want true", taken, err)
+		}
+		if taken, err := st.Users().UserNameTaken("bob"); err != nil || taken {
+			t.Errorf("UserNameTaken(bob) = %v, %v; want false", taken, err)
+		}
+
+		if err := st.Users().SetRole(id, "moderator"); err != nil {
+			t.Fatal(err)
+		}
+		if user, _ := st.Users().ByID(id); user.Role != "moderator" {
+			t.Errorf("role = %q after SetRole, want moderator", user.Role)
+		}
+		if _, err := st.Users().Profile("nobody"); !errors.Is(err, store.ErrNotFound) {
+			t.Errorf("Profile(missing) error = %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestConformanceSessions(t *testing.T) {
+	eachStore(t, func(t *testing.T, st store.Store) {
+		id := createUser(t, st, "alice")
+		now := time.Now().UTC().Truncate(time.Second)
+		session := structs.Session{UserID: id, UserAgent: "test", IP: "127.0.0.1", CreatedAt: now, LastSeenAt: now, ExpiresAt: now.Add(time.Hour)}
+		if _, err := st.Sessions().Create(session, "live"); err != nil {
+			t.Fatal(err)
+		}
+		session.ExpiresAt = now.Add(-time.Minute)
+		if _, err := st.Sessions().Create(session, "stale"); err != nil {
+			t.Fatal(err)
+		}
+
+		found, userName, err := st.Sessions().ByTokenHash("live", now)
+		if err != nil || found.UserID != id || userName != "alice" {
+			t.Fatalf("ByTokenHash(live) = %+v, %q, %v; want alice's session", found, userName, err)
+		}
+		if _, _, err := st.Sessions().ByTokenHash("stale", now); !errors.Is(err, store.ErrNotFound) {
+			t.Errorf("ByTokenHash(expired) error = %v, want ErrNotFound", err)
+		}
+		if removed, err := st.Sessions().DeleteExpired(now); err != nil || removed != 1 {
+			t.Errorf("DeleteExpired = %d, %v; want 1", removed, err)
+		}
+		if err := st.Sessions().DeleteByUser(id); err != nil {
+			t.Fatal(err)
+		}
+		if sessions, err := st.Sessions().ByUser(id, now); err != nil || len(sessions) != 0 {
+			t.Errorf("ByUser after DeleteByUser = %d sessions, %v; want none", len(sessions), err)
+		}
+	})
+}
+
+func TestConformancePosts(t *testing.T) {
+	eachStore(t, func(t *testing.T, st store.Store) {
+		author := createUser(t, st, "author")
+		other := createUser(t, st, "other")
+		postID := createPost(t, st, author, "Mine", "Content")
+		createPost(t, st, other, "Theirs", "Content")
+		createComment(t, st, postID, other, "A comment")
+
+		posts, _, err := st.Posts().ByUser(author, store.Page{Limit: 10})
+		if err != nil || len(posts) != 1 || posts[0].ID != postID {
+			t.Fatalf("ByUser = %v, %v; want post %d only", postIDs(posts), err, postID)
+		}
+		if post, err := st.Posts().ByID(postID); err != nil || post.Title != "Mine" {
+			t.Fatalf("ByID = %+v, %v; want the post", post, err)
+		}
+
+		now := time.Now().UTC().Truncate(time.Second)
+		if err := st.Posts().Delete(postID, author, now); err != nil {
+			t.Fatal(err)
+		}
+		if trash, err := st.Posts().Trash(author); err != nil || len(trash) != 1 || trash[0].ID != postID {
+			t.Fatalf("Trash = %v, %v; want post %d", postIDs(trash), err, postID)
+		}
+		if purged, _, err := st.Posts().Purge(now.Add(time.Second)); err != nil || purged != 1 {
+			t.Fatalf("Purge = %d, %v; want 1", purged, err)
+		}
+		if _, err := st.Posts().ByID(postID); err == nil {
+			t.Errorf("ByID found the post after Purge")
+		}
+		if comments, _, err := st.Comments().ByPost(postID, store.Audience{}, store.SortOldest, store.Page{Limit: 10}); err != nil || len(comments) != 0 {
+			t.Errorf("ByPost after Purge = %d comments, %v; want none", len(comments), err)
+		}
+	})
+}
+
+func TestConformancePostPaging(t *testing.T) {
+	eachStore(t, func(t *testing.T, st store.Store) {
+		author := createUser(t, st, "author")
+		var ids []int
+		for i := 0; i < 5; i++ {
+			ids = append(ids, createPost(t, st, author, fmt.Sprintf("Post %d", i), "Content"))
+		}
+
+		first, more, err := st.Posts().All(store.Audience{}, store.Page{Limit: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(first) != 2 || first[0].ID != ids[4] || first[1].ID != ids[3] || !more {
+			t.Fatalf("first page = %v, more %v; want posts %d, %d and more", postIDs(first), more, ids[4], ids[3])
+		}
+		last, more, err := st.Posts().All(store.Audience{}, store.Page{Limit: 2, After: ids[1]})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(last) != 1 || last[0].ID != ids[0] || more {
+			t.Fatalf("last page = %v, more %v; want post %d only", postIDs(last), more, ids[0])
+		}
+		back, more, err := st.Posts().All(store.Audience{}, store.Page{Limit: 2, Before: ids[2]})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(back) != 2 || back[0].ID != ids[4] || back[1].ID != ids[3] || more {
+			t.Fatalf("page before %d = %v, more %v; want posts %d, %d", ids[2], postIDs(back), more, ids[4], ids[3])
+		}
+
+		if err := st.Posts().SetHidden(ids[4], true); err != nil {
+			t.Fatal(err)
+		}
+		public, _, err := st.Posts().All(store.Audience{}, store.Page{Limit: 1})
+		if err != nil || len(public) != 1 || public[0].ID != ids[3] {
+			t.Fatalf("public first page = %v, %v; want the hidden post left out", postIDs(public), err)
+		}
+		moderators, _, err := st.Posts().All(store.Audience{Everything: true}, store.Page{Limit: 1})
+		if err != nil || len(moderators) != 1 || moderators[0].ID != ids[4] {
+			t.Fatalf("moderators' first page = %v, %v; want the hidden post", postIDs(moderators), err)
+		}
+	})
+}
+
+func postIDs(posts []structs.Post) []int {
+	ids := make([]int, len(posts))
+	for i, post := range posts {
+		ids[i] = post.ID
+	}
+	return ids
+}
+
+func TestConformanceBans(t *testing.T) {
+	eachStore(t, func(t *testing.T, st store.Store) {
+		user := createUser(t, st, "user")
+		moderator := createUser(t, st, "moderator")
+		now := time.Now().UTC().Truncate(time.Second)
+
+		ban := structs.Ban{UserID: user, BannedBy: moderator, Reason: "spam", CreatedAt: now}
+		if _, err := st.Bans().Ban(ban); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := st.Bans().Ban(ban); !errors.Is(err, store.ErrAlreadyBanned) {
+			t.Fatalf("second Ban error = %v, want ErrAlreadyBanned", err)
+		}
+		if active, err := st.Bans().Active(user, now); err != nil || active.Reason != "spam" {
+			t.Fatalf("Active = %+v, %v; want the ban", active, err)
+		}
+
+		if err := st.Bans().Lift(user, moderator, now); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := st.Bans().Active(user, now.Add(time.Second)); !errors.Is(err, store.ErrNotFound) {
+			t.Errorf("Active after Lift error = %v, want ErrNotFound", err)
+		}
+		if err := st.Bans().Lift(user, moderator, now); !errors.Is(err, store.ErrNotFound) {
+			t.Errorf("second Lift error = %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestConformanceVotes(t *testing.T) {
+	eachStore(t, func(t *testing.T, st store.Store) {
+		author := createUser(t, st, "author")
+		voter := createUser(t, st, "voter")
+		postID := createPost(t, st, author, "Votes", "Vote on me")
+		target := store.VoteTarget{ID: postID}
+
+		tally, err := st.Votes().Apply(voter, target, toggle(store.VoteUp))
+		if err != nil || tally.State != store.VoteUp || tally.UpCount != 1 || tally.Score != 1 {
+			t.Fatalf("upvote = %+v, %v; want one up", tally, err)
+		}
+		tally, err = st.Votes().Apply(voter, target, toggle(store.VoteDown))
+		if err != nil || tally.State != store.VoteDown || tally.UpCount != 0 || tally.DownCount != 1 || tally.Score != -1 {
+			t.Fatalf("switch to downvote = %+v, %v; want one down", tally, err)
+		}
+		tally, err = st.Votes().Apply(voter, target, toggle(store.VoteDown))
+		if err != nil || tally.State != store.VoteNone || tally.DownCount != 0 || tally.Score != 0 {
+			t.Fatalf("withdraw = %+v, %v; want no votes", tally, err)
+		}
+		if drifted, err := st.Votes().Reconcile(); err != nil || drifted != 0 {
+			t.Errorf("Reconcile = %d, %v; want 0", drifted, err)
+		}
+	})
+}
+
+func TestConformanceTags(t *testing.T) {
+	eachStore(t, func(t *testing.T, st store.Store) {
+		admin := createUser(t, st, "admin")
+		postID := createPost(t, st, admin, "Tagged", "Content")
+		for _, slug := range []string{"alpha", "beta"} {
+			if _, err := st.Tags().Create(structs.Tag{Slug: slug, Name: strings.ToUpper(slug), Color: "#000000"}, admin); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if err := st.Tags().SetForPost(postID, []string{"alpha", "beta"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := st.Tags().SetForPost(postID, []string{"alpha", "nope"}); !errors.Is(err, store.ErrUnknownTag) {
+			t.Fatalf("SetForPost(unknown) error = %v, want ErrUnknownTag", err)
+		}
+		tags, err := st.Tags().ForPost(postID)
+		if err != nil || len(tags) != 2 {
+			t.Fatalf("ForPost = %+v, %v; want both tags unchanged", tags, err)
+		}
+
+		tag, err := st.Tags().BySlug("beta")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := st.Tags().Delete(tag.ID); err != nil {
+			t.Fatal(err)
+		}
+		if tags, err := st.Tags().ForPost(postID); err != nil || len(tags) != 1 || tags[0].Slug != "alpha" {
+			t.Fatalf("ForPost after Delete = %+v, %v; want alpha only", tags, err)
+		}
+	})
+}
+
+func TestConformanceSearch(t *testing.T) {
+	eachStore(t, func(t *testing.T, st store.Store) {
+		author := createUser(t, st, "author")
+		postID := createPost(t, st, author, "Goroutines", "How many goroutines is too many")
+		commentID := createComment(t, st, postID, author, "Thousands are fine, schedulerword")
+		createPost(t, st, author, "Unrelated", "Nothing to see")
+
+		posts := searchFor(t, st, "goroutines")
+		if len(posts) != 1 || posts[0].ID != postID {
+			t.Fatalf("search by title = %v, want post %d", postIDs(posts), postID)
+		}
+		if posts := searchFor(t, st, "schedulerword"); len(posts) != 1 || posts[0].ID != postID {
+			t.Fatalf("search by comment = %v, want post %d", postIDs(posts), postID)
+		}
+
+		if err := st.Comments().SetHidden(commentID, true); err != nil {
+			t.Fatal(err)
+		}
+		if posts := searchFor(t, st, "schedulerword"); len(posts) != 0 {
+			t.Fatalf("search by hidden comment = %v, want nothing", postIDs(posts))
+		}
+	})
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"forum/backend/config"
+)
+
+type Dialect int
+
+const (
+	SQLite Dialect = iota
+	Postgres
+)
+
+func DialectFor(driver string) (Dialect, error) {
+	switch driver {
+	case config.DriverSQLite:
+		return SQLite, nil
+	case config.DriverPostgres:
+		return Postgres, nil
+	default:
+		return 0, fmt.Errorf("unsupported database driver %q", driver)
+	}
+}
+
+// Rebind rewrites "?" placeholders into the dialect's native form. Question
+// marks inside quoted literals are left alone.
+func (d Dialect) Rebind(query string) string {
+	if d != Postgres {
+		return query
+	}
+
+	var b strings.Builder
+	b.Grow(len(query) + 8)
+
+	n := 0
+	var quote rune
+	for _, ch := range query {
+		switch {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '\'' || ch == '"':
+			quote = ch
+		case ch == '?':
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(ch)
+	}
+	return b.String()
+}
+
+// ForUpdate returns the row-locking clause for SELECTs inside a transaction.
+// SQLite has no row locks; its immediate transactions already hold the
+// database write lock.
+func (d Dialect) ForUpdate() string {
+	if d == Postgres {
+		return " FOR UPDATE"
+	}
+	return ""
+}
+
+// Date returns an expression for the "YYYY-MM-DD" date of a timestamp
+// column.
+func (d Dialect) Date(column string) string {
+	if d == Postgres {
+		return "to_char(" + column + ", 'YYYY-MM-DD')"
+	}
+	return "date(" + column + ")"
+}
+
+// Timestamp returns an expression for the "YYYY-MM-DD HH:MM:SS" text of a
+// timestamp column, as timestampLayout reads it. SQLite gives it in UTC.
+func (d Dialect) Timestamp(column string) string {
+	if d == Postgres {
+		return "to_char(" + column + ", 'YYYY-MM-DD HH24:MI:SS')"
+	}
+	return "datetime(" + column + ")"
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"forum/backend/controllers/structs"
+	"forum/backend/store"
+)
+
+type IdentityRepo struct {
+	db *conn
+}
+
+func (r *IdentityRepo) ByUser(userID int) ([]structs.Identity, error) {
+	rows, err := r.db.Query(`SELECT ID, UserID, Provider, Subject, Email, CreatedAt
+		FROM user_identities WHERE UserID = ? ORDER BY Provider, ID`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var identities []structs.Identity
+	for rows.Next() {
+		var identity structs.Identity
+		err := rows.Scan(&identity.ID, &identity.UserID, &identity.Provider, &identity.Subject, &identity.Email, &identity.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		identities = append(identities, identity)
+	}
+
+	return identities, rows.Err()
+}
+
+func (r *IdentityRepo) UserID(provider, subject string) (int, error) {
+	var userID int
+	err := r.db.QueryRow(`SELECT UserID FROM user_identities WHERE Provider = ? AND Subject = ?`, provider, subject).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, store.ErrNotFound
+	}
+	return userID, err
+}
+
+func (r *IdentityRepo) Link(identity structs.Identity) error {
+	return r.db.withTx(func(tx *tx) error {
+		return linkIdentity(tx, identity)
+	})
+}
+
+// linkIdentity links the provider account to identity.UserID, or refreshes
+// its email if it is linked already.
+func linkIdentity(tx *tx, identity structs.Identity) error {
+	var ownerID int
+	err := tx.QueryRow(`SELECT UserID FROM user_identities WHERE Provider = ? AND Subject = ?`,
+		identity.Provider, identity.Subject).Scan(&ownerID)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		_, err = tx.Exec(`INSERT INTO user_identities (UserID, Provider, Subject, Email, CreatedAt) VALUES (?, ?, ?, ?, ?)`,
+			identity.UserID, identity.Provider, identity.Subject, identity.Email, time.Now().UTC().Truncate(time.Second))
+		return err
+	case err != nil:
+		return err
+	case ownerID != identity.UserID:
+		return store.ErrIdentityTaken
+	default:
+		_, err = tx.Exec(`UPDATE user_identities SET Email = ? WHERE Provider = ? AND Subject = ?`,
+			identity.Email, identity.Provider, identity.Subject)
+		return err
+	}
+}
+
+func (r *IdentityRepo) Unlink(userID, identityID int) error {
+	return r.db.withTx(func(tx *tx) error {
+		var password string
+		err := tx.QueryRow("SELECT Password FROM USERS WHERE ID = ?"+r.db.dialect.ForUpdate(), userID).Scan(&password)
+		if errors.Is(err, sql.ErrNoRows) {
+			return store.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		var others int
+		err = tx.QueryRow(`SELECT COUNT(*) FROM user_identities WHERE UserID = ? AND ID <> ?`, userID, identityID).Scan(&others)
+		if err != nil {
+			return err
+		}
+
+		result, err := tx.Exec(`DELETE FROM user_identities WHERE ID = ? AND UserID = ?`, identityID, userID)
+		if err != nil {
+			return err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return store.ErrNotFound
+		}
+
+		if password == "" && others == 0 {
+			return store.ErrLastLoginMethod
+		}
+		return nil
+	})
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"forum/backend/controllers/structs"
+)
+
+type LoginFailureRepo struct {
+	db *conn
+}
+
+func (r *LoginFailureRepo) Record(failure structs.LoginFailure) error {
+	_, err := r.db.Exec(`INSERT INTO login_failures (UserID, Email, IP, UserAgent, Reason, CreatedAt) VALUES (?, ?, ?, ?, ?, ?)`,
+		failure.UserID, failure.Email, failure.IP, failure.UserAgent, failure.Reason, failure.CreatedAt.UTC())
+	return err
+}
+
+func (r *LoginFailureRepo) Recent(limit int) ([]structs.LoginFailure, error) {
+	rows, err := r.db.Query(`SELECT ID, UserID, Email, IP, UserAgent, Reason, CreatedAt
+		FROM login_failures ORDER BY ID DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var failures []structs.LoginFailure
+	for rows.Next() {
+		var f structs.LoginFailure
+		err := rows.Scan(&f.ID, &f.UserID, &f.Email, &f.IP, &f.UserAgent, &f.Reason, &f.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		failures = append(failures, f)
+	}
+
+	return failures, rows.Err()
+}
+
+type RateLimitRepo struct {
+	db *conn
+}
+
+func (r *RateLimitRepo) Failures(key string) (int, time.Time, error) {
+	var failures int
+	var last time.Time
+	err := r.db.QueryRow(`SELECT Failures, LastFailure FROM rate_limits WHERE LimitKey = ?`, key).Scan(&failures, &last)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, time.Time{}, nil
+	}
+	return failures, last, err
+}
+
+func (r *RateLimitRepo) AddFailure(key string, now, since time.Time) (int, error) {
+	var failures int
+	err := r.db.QueryRow(`INSERT INTO rate_limits (LimitKey, Failures, LastFailure) VALUES (?, 1, ?)
+		ON CONFLICT (LimitKey) DO UPDATE SET
+			Failures = CASE WHEN rate_limits.LastFailure < ? THEN 1 ELSE rate_limits.Failures + 1 END,
+			LastFailure = excluded.LastFailure
+		RETURNING Failures`, key, now.UTC(), since.UTC()).Scan(&failures)
+	return failures, err
+}
+
+func (r *RateLimitRepo) Reset(key string) error {
+	_, err := r.db.Exec(`DELETE FROM rate_limits WHERE LimitKey = ?`, key)
+	return err
+}
+
+func (r *RateLimitRepo) DeleteBefore(t time.Time) (int, error) {
+	result, err := r.db.Exec(`DELETE FROM rate_limits WHERE LastFailure < ?`, t.UTC())
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"forum/backend/store"
+)
+
+type OAuthStateRepo struct {
+	db *conn
+}
+
+// Create also forgets states whose logins were abandoned.
+func (r *OAuthStateRepo) Create(stateHash string, state store.OAuthState, now time.Time) error {
+	return r.db.withTx(func(tx *tx) error {
+		if _, err := tx.Exec(`DELETE FROM oauth_states WHERE ExpiresAt <= ?`, now.UTC()); err != nil {
+			return err
+		}
+		_, err := tx.Exec(`INSERT INTO oauth_states (StateHash, Provider, CodeVerifier, Nonce, ExpiresAt, LinkUserID) VALUES (?, ?, ?, ?, ?, ?)`,
+			stateHash, state.Provider, state.CodeVerifier, state.Nonce, state.ExpiresAt.UTC(), state.LinkUserID)
+		return err
+	})
+}
+
+func (r *OAuthStateRepo) Take(stateHash string, now time.Time) (store.OAuthState, error) {
+	var state store.OAuthState
+	err := r.db.QueryRow(`DELETE FROM oauth_states WHERE StateHash = ? RETURNING Provider, CodeVerifier, Nonce, ExpiresAt, LinkUserID`, stateHash).
+		Scan(&state.Provider, &state.CodeVerifier, &state.Nonce, &state.ExpiresAt, &state.LinkUserID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !state.ExpiresAt.After(now)) {
+		return store.OAuthState{}, store.ErrNotFound
+	}
+	return state, err
+}
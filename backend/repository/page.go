@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"slices"
+
+	"forum/backend/store"
+)
+
+// keyset returns the condition and ORDER BY clause that select one page of a
+// listing ordered by column. Going back a page reads the rows nearest the
+// cursor first, so the order is flipped and pageOf puts it right again. cond
+// is empty on the first page.
+func keyset(column string, descending bool, page store.Page) (cond string, arg int, order string) {
+	backward := page.Before != 0
+	desc := descending != backward
+
+	order = column + " ASC"
+	op := " > ?"
+	if desc {
+		order = column + " DESC"
+		op = " < ?"
+	}
+
+	switch {
+	case backward:
+		return column + op, page.Before, order
+	case page.After != 0:
+		return column + op, page.After, order
+	}
+	return "", 0, order
+}
+
+// pageOf trims rows queried with LIMIT page.Limit+1 down to the page and
+// reports whether the extra row was there.
+func pageOf[T any](rows []T, page store.Page) ([]T, bool) {
+	more := len(rows) > page.Limit
+	if more {
+		rows = rows[:page.Limit]
+	}
+	if page.Before != 0 {
+		slices.Reverse(rows)
+	}
+	return rows, more
+}
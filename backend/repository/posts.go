@@ -0,0 +1,402 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"forum/backend/controllers/structs"
+	"forum/backend/reputation"
+	"forum/backend/store"
+)
+
+type PostRepo struct {
+	db *conn
+}
+
+const postColumns = "POSTS.ID, POSTS.UserID, POSTS.UserName, POSTS.Title, POSTS.Content, POSTS.LikeCount, POSTS.UpCount, POSTS.DownCount, POSTS.Hidden, POSTS.Shadow, POSTS.EditedAt, POSTS.DeletedAt, POSTS.DeletedBy, POSTS.ContentHTML, POSTS.IsQuestion, POSTS.AcceptedCommentID"
+
+func (r *PostRepo) All(audience store.Audience, page store.Page) ([]structs.Post, bool, error) {
+	where, args := visible("POSTS", "POSTS.UserID", audience)
+	if !audience.Everything {
+		where = append(where, "POSTS.DeletedAt IS NULL")
+	}
+	return r.paged("POSTS", where, args, page)
+}
+
+func (r *PostRepo) ByUser(userID int, page store.Page) ([]structs.Post, bool, error) {
+	return r.paged("POSTS", []string{"POSTS.UserID = ?", "POSTS.DeletedAt IS NULL"}, []any{userID}, page)
+}
+
+func (r *PostRepo) ByAuthor(userID int, audience store.Audience, page store.Page) ([]structs.Post, bool, error) {
+	where, args := visible("POSTS", "POSTS.UserID", audience)
+	return r.paged("POSTS", append([]string{"POSTS.UserID = ?", "POSTS.DeletedAt IS NULL"}, where...),
+		append([]any{userID}, args...), page)
+}
+
+func (r *PostRepo) VotedBy(userID int, page store.Page) ([]structs.Post, bool, error) {
+	where, args := visible("POSTS", "POSTS.UserID", store.Audience{UserID: userID})
+	return r.paged("POSTS INNER JOIN USERLIKES ON POSTS.ID = USERLIKES.PostID",
+		append([]string{"USERLIKES.UserID = ?", "USERLIKES.IsComment = ?", "(USERLIKES.Liked = ? OR USERLIKES.Disliked = ?)", "POSTS.DeletedAt IS NULL"}, where...),
+		append([]any{userID, false, true, true}, args...), page)
+}
+
+// searchableComments are the comments of the post that search matches on
+// PostgreSQL: those the public sees, like the ones the FTS5 triggers index
+// on SQLite.
+const searchableComments = "COMMENTS.PostId = POSTS.ID AND COMMENTS.Hidden = FALSE AND COMMENTS.Shadow = FALSE AND COMMENTS.DeletedAt IS NULL"
+
+// Search uses the FTS5 index on SQLite and the tsvector columns on
+// PostgreSQL. On PostgreSQL a post matches when its own text or one of its
+// comments contains every term; FTS5 also matches terms split between them.
+// Relevance has no stable key to page by, so results page by offset.
+func (r *PostRepo) Search(query store.SearchQuery, page store.Page) ([]structs.Post, bool, error) {
+	snippet, from, order := "''", "POSTS", "POSTS.ID DESC"
+	where, whereArgs := visible("POSTS", "POSTS.UserID", store.Audience{})
+	where = append(where, "POSTS.DeletedAt IS NULL")
+	var selectArgs, fromArgs []any
+
+	if len(query.Terms) > 0 {
+		if r.db.dialect == Postgres {
+			snippet = "ts_headline('simple', POSTS.Title || ' ' || POSTS.Content, q, ?)"
+			selectArgs = append(selectArgs, "StartSel="+store.MatchStart+", StopSel="+store.MatchEnd+", MaxWords=30, MinWords=10, MaxFragments=2, FragmentDelimiter=\" … \"")
+			from = "POSTS CROSS JOIN to_tsquery('simple', ?) AS q"
+			fromArgs = append(fromArgs, tsQuery(query.Terms))
+			where = append(where, "(POSTS.SearchVector @@ q OR EXISTS (SELECT 1 FROM COMMENTS WHERE "+searchableComments+" AND COMMENTS.SearchVector @@ q))")
+			order = "ts_rank(POSTS.SearchVector, q) + COALESCE((SELECT MAX(ts_rank(COMMENTS.SearchVector, q)) FROM COMMENTS WHERE " + searchableComments + "), 0) DESC, POSTS.ID DESC"
+		} else {
+			snippet = "snippet(posts_fts, -1, ?, ?, '…', 16)"
+			selectArgs = append(selectArgs, store.MatchStart, store.MatchEnd)
+			from = "posts_fts INNER JOIN POSTS ON POSTS.ID = posts_fts.rowid"
+			where = append(where, "posts_fts MATCH ?")
+			whereArgs = append(whereArgs, ftsQuery(query.Terms))
+			// Title matches outweigh body matches, which outweigh comments.
+			order = "bm25(posts_fts, 10.0, 4.0, 1.0), POSTS.ID DESC"
+		}
+	}
+
+	if query.Author != "" {
+		where = append(where, "LOWER(POSTS.UserName) = LOWER(?)")
+		whereArgs = append(whereArgs, query.Author)
+	}
+	if query.Tag != "" {
+		where = append(where, "EXISTS (SELECT 1 FROM post_tags INNER JOIN tags ON tags.ID = post_tags.TagID WHERE post_tags.PostID = POSTS.ID AND tags.Slug = ?)")
+		whereArgs = append(whereArgs, query.Tag)
+	}
+	if query.Unanswered {
+		where = append(where, "POSTS.IsQuestion = ?", "POSTS.AcceptedCommentID IS NULL")
+		whereArgs = append(whereArgs, true)
+	}
+	if !query.Before.IsZero() {
+		where = append(where, "POSTS.PostDate < ?")
+		whereArgs = append(whereArgs, query.Before.UTC().Format(timestampLayout))
+	}
+	if !query.After.IsZero() {
+		where = append(where, "POSTS.PostDate >= ?")
+		whereArgs = append(whereArgs, query.After.UTC().Format(timestampLayout))
+	}
+
+	statement := "SELECT " + postColumns + ", " + snippet + " FROM " + from
+	statement += " WHERE " + strings.Join(where, " AND ")
+	if query.Top {
+		order = "POSTS.LikeCount DESC, POSTS.ID DESC"
+	}
+	statement += " ORDER BY " + order + " LIMIT ? OFFSET ?"
+
+	args := append(append(selectArgs, fromArgs...), whereArgs...)
+	rows, err := r.db.Query(statement, append(args, page.Limit+1, page.Offset)...)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	var posts []structs.Post
+	for rows.Next() {
+		var snippet string
+		post, err := scanPost(rows, &snippet)
+		if err != nil {
+			return nil, false, err
+		}
+		post.Snippet = snippet
+		posts = append(posts, post)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+
+	posts, more := pageOf(posts, page)
+	return posts, more, nil
+}
+
+func (r *PostRepo) ByID(id int) (structs.Post, error) {
+	var photoPath sql.NullString
+	post, err := scanPost(r.db.QueryRow("SELECT "+postColumns+", POSTS.PhotoPath FROM POSTS WHERE ID = ?", id), &photoPath)
+	if errors.Is(err, sql.ErrNoRows) {
+		return structs.Post{}, store.ErrNotFound
+	}
+	post.PhotoPath = photoPath.String
+	return post, err
+}
+
+func (r *PostRepo) Owner(id int) (int, string, error) {
+	var userID int
+	var userName string
+	err := r.db.QueryRow("SELECT UserID, UserName FROM POSTS WHERE ID = ? AND DeletedAt IS NULL", id).Scan(&userID, &userName)
+	return userID, userName, err
+}
+
+func (r *PostRepo) Create(post structs.Post) (int, error) {
+	var id int
+	err := r.db.QueryRow(`INSERT INTO POSTS (UserID, UserName, Title, Content, ContentHTML, PhotoPath, Shadow, IsQuestion) VALUES (?, ?, ?, ?, ?, ?, ?, ?) RETURNING ID`,
+		post.UserID, post.UserName, post.Title, post.Content, post.ContentHTML, post.PhotoPath, post.Shadow, post.Question).Scan(&id)
+	return id, err
+}
+
+// Edit keeps the current title, content and tags as a revision before
+// replacing them.
+func (r *PostRepo) Edit(post structs.Post, tagSlugs []string, editorID int, now time.Time) error {
+	return r.db.withTx(func(tx *tx) error {
+		if err := addRevision(tx, post.ID, false); err != nil {
+			return err
+		}
+		_, err := tx.Exec(`UPDATE POSTS SET Title = ?, Content = ?, ContentHTML = ?, EditedAt = ?, EditedBy = ? WHERE ID = ?`,
+			post.Title, post.Content, post.ContentHTML, now.UTC(), editorID, post.ID)
+		if err != nil {
+			return err
+		}
+		return setPostTags(tx, post.ID, tagSlugs)
+	})
+}
+
+func (r *PostRepo) Rerender(render func(source string) string, all bool) (int, error) {
+	return rerender(r.db, "POSTS", "Content", "ContentHTML", render, all)
+}
+
+// Accept makes the comment the accepted answer of the post, or takes the
+// acceptance back with 0. The author of the answer earns reputation for it,
+// and the author of the answer it replaces loses it again; answering your
+// own question earns nothing.
+func (r *PostRepo) Accept(postID, commentID int, now time.Time) error {
+	return r.db.withTx(func(tx *tx) error {
+		var askerID int
+		var previousID sql.NullInt64
+		err := tx.QueryRow(`SELECT UserID, AcceptedCommentID FROM POSTS WHERE ID = ?`, postID).Scan(&askerID, &previousID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return store.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if int(previousID.Int64) == commentID {
+			return nil
+		}
+
+		if previousID.Valid {
+			err := dropDerivedEvents(tx, "Reason = ? AND TargetID = ? AND IsComment = ?", reputation.ReasonAccepted, previousID.Int64, true)
+			if err != nil {
+				return err
+			}
+		}
+
+		var accepted any
+		if commentID != 0 {
+			accepted = commentID
+			var answererID int
+			if err := tx.QueryRow(`SELECT UserId FROM COMMENTS WHERE ID = ?`, commentID).Scan(&answererID); err != nil {
+				return err
+			}
+			if answererID != 0 && answererID != askerID {
+				err := addEvent(tx, structs.ReputationEvent{UserID: answererID, Points: reputation.AcceptedAnswer, Reason: reputation.ReasonAccepted,
+					TargetID: commentID, IsComment: true, ActorID: askerID, CreatedAt: now})
+				if err != nil {
+					return err
+				}
+				if err := refreshReputation(tx, answererID); err != nil {
+					return err
+				}
+			}
+		}
+		_, err = tx.Exec(`UPDATE POSTS SET AcceptedCommentID = ? WHERE ID = ?`, accepted, postID)
+		return err
+	})
+}
+
+func (r *PostRepo) SetHidden(id int, hidden bool) error {
+	_, err := r.db.Exec(`UPDATE POSTS SET Hidden = ? WHERE ID = ?`, hidden, id)
+	return err
+}
+
+// Delete moves the post to the trash. Its comments stay as they are and
+// come back with it.
+func (r *PostRepo) Delete(id, deletedBy int, now time.Time) error {
+	return changedOne(r.db.Exec(`UPDATE POSTS SET DeletedAt = ?, DeletedBy = ? WHERE ID = ? AND DeletedAt IS NULL`, now.UTC(), deletedBy, id))
+}
+
+func (r *PostRepo) Restore(id int) error {
+	return changedOne(r.db.Exec(`UPDATE POSTS SET DeletedAt = NULL, DeletedBy = NULL WHERE ID = ? AND DeletedAt IS NOT NULL`, id))
+}
+
+func (r *PostRepo) Trash(userID int) ([]structs.Post, error) {
+	return r.list("SELECT "+postColumns+" FROM POSTS WHERE UserID = ? AND DeletedAt IS NOT NULL ORDER BY DeletedAt DESC, ID DESC", userID)
+}
+
+// Purge removes the posts deleted before the time for good, and returns how
+// many there were and the photos that no post uses anymore.
+func (r *PostRepo) Purge(before time.Time) (int, []string, error) {
+	var purged int
+	var unused []string
+	err := r.db.withTx(func(tx *tx) error {
+		rows, err := tx.Query(`SELECT ID, COALESCE(PhotoPath, '') FROM POSTS WHERE DeletedAt < ?`, before.UTC())
+		if err != nil {
+			return err
+		}
+		var ids []int
+		var photos []string
+		for rows.Next() {
+			var id int
+			var photo string
+			if err := rows.Scan(&id, &photo); err != nil {
+				rows.Close()
+				return err
+			}
+			ids = append(ids, id)
+			if photo != "" && !slices.Contains(photos, photo) {
+				photos = append(photos, photo)
+			}
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			if err := purgePost(tx, id); err != nil {
+				return err
+			}
+		}
+		purged = len(ids)
+
+		unused, err = unusedPhotos(tx, photos)
+		return err
+	})
+	return purged, unused, err
+}
+
+// unusedPhotos returns the photos no post shows anymore. Older uploads were
+// named by the file name they were sent with, so another post, or a user's
+// avatar, may be showing the same file.
+func unusedPhotos(tx *tx, photos []string) ([]string, error) {
+	var unused []string
+	for _, photo := range photos {
+		var users int
+		err := tx.QueryRow(`SELECT (SELECT COUNT(*) FROM POSTS WHERE PhotoPath = ?) + (SELECT COUNT(*) FROM USERS WHERE Avatar = ?)`,
+			photo, photo).Scan(&users)
+		if err != nil {
+			return nil, err
+		}
+		if users == 0 {
+			unused = append(unused, photo)
+		}
+	}
+	return unused, nil
+}
+
+// purgePost removes the post together with its comments, tags, revisions
+// and every vote cast on it. Open reports of them are dropped; resolved ones
+// are kept.
+func purgePost(tx *tx, id int) error {
+	if _, err := tx.Exec(`DELETE FROM reports WHERE ResolvedAt IS NULL AND ((IsComment = ? AND TargetID = ?)
+		OR (IsComment = ? AND TargetID IN (SELECT ID FROM COMMENTS WHERE PostId = ?)))`, false, id, true, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM revisions WHERE (IsComment = ? AND TargetID = ?)
+		OR (IsComment = ? AND TargetID IN (SELECT ID FROM COMMENTS WHERE PostId = ?))`, false, id, true, id); err != nil {
+		return err
+	}
+	err := dropDerivedEvents(tx, "(IsComment = ? AND TargetID = ?) OR (IsComment = ? AND TargetID IN (SELECT ID FROM COMMENTS WHERE PostId = ?))",
+		false, id, true, id)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM POSTS WHERE ID = ?`, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM COMMENTS WHERE PostId = ?`, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM post_tags WHERE PostID = ?`, id); err != nil {
+		return err
+	}
+	_, err = tx.Exec(`DELETE FROM USERLIKES WHERE DeleteID = ?`, id)
+	return err
+}
+
+func (r *PostRepo) paged(from string, where []string, args []any, page store.Page) ([]structs.Post, bool, error) {
+	cond, arg, order := keyset("POSTS.ID", true, page)
+	if cond != "" {
+		where = append(where, cond)
+		args = append(args, arg)
+	}
+
+	query := "SELECT " + postColumns + " FROM " + from
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY " + order + " LIMIT ?"
+
+	posts, err := r.list(query, append(args, page.Limit+1)...)
+	if err != nil {
+		return nil, false, err
+	}
+	posts, more := pageOf(posts, page)
+	return posts, more, nil
+}
+
+func (r *PostRepo) list(query string, args ...any) ([]structs.Post, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var posts []structs.Post
+	for rows.Next() {
+		post, err := scanPost(rows)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, post)
+	}
+
+	return posts, rows.Err()
+}
+
+func scanPost(row interface{ Scan(...any) error }, extra ...any) (structs.Post, error) {
+	var post structs.Post
+	var editedAt, deletedAt sql.NullTime
+	var deletedBy sql.NullInt64
+	var contentHTML sql.NullString
+	var acceptedID sql.NullInt64
+	dest := []any{&post.ID, &post.UserID, &post.UserName, &post.Title, &post.Content, &post.LikeCount, &post.UpCount, &post.DownCount,
+		&post.Hidden, &post.Shadow, &editedAt, &deletedAt, &deletedBy, &contentHTML, &post.Question, &acceptedID}
+	err := row.Scan(append(dest, extra...)...)
+	post.AcceptedID = int(acceptedID.Int64)
+	post.ContentHTML = contentHTML.String
+	post.EditedAt = editedAt.Time
+	post.DeletedAt = deletedAt.Time
+	post.DeletedBy = int(deletedBy.Int64)
+	return post, err
+}
+
+// visible is the condition for the audience to see a row of table, a post
+// or comment written by userColumn.
+func visible(table, userColumn string, audience store.Audience) ([]string, []any) {
+	if audience.Everything {
+		return nil, nil
+	}
+	return []string{table + ".Hidden = ?", "(" + table + ".Shadow = ? OR " + userColumn + " = ?)"},
+		[]any{false, false, audience.UserID}
+}
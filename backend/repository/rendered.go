@@ -0,0 +1,42 @@
+package repository
+
+// rerender renders the source column of table into its html column: for
+// every row with all, otherwise only where it hasn't been rendered yet.
+func rerender(db *conn, table, sourceColumn, htmlColumn string, render func(source string) string, all bool) (int, error) {
+	query := "SELECT ID, " + sourceColumn + " FROM " + table
+	if !all {
+		query += " WHERE " + htmlColumn + " IS NULL"
+	}
+
+	var rendered int
+	err := db.withTx(func(tx *tx) error {
+		rows, err := tx.Query(query)
+		if err != nil {
+			return err
+		}
+		sources := map[int]string{}
+		for rows.Next() {
+			var id int
+			var source string
+			if err := rows.Scan(&id, &source); err != nil {
+				rows.Close()
+				return err
+			}
+			sources[id] = source
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return err
+		}
+
+		for id, source := range sources {
+			if _, err := tx.Exec("UPDATE "+table+" SET "+htmlColumn+" = ? WHERE ID = ?", render(source), id); err != nil {
+				return err
+			}
+		}
+		rendered = len(sources)
+		return nil
+	})
+	return rendered, err
+}
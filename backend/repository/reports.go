@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"slices"
+	"time"
+
+	"forum/backend/controllers/structs"
+	"forum/backend/store"
+)
+
+type ReportRepo struct {
+	db *conn
+}
+
+const reportColumns = "reports.ID, reports.ReporterID, reports.TargetID, reports.IsComment, reports.Reason, reports.Details, reports.CreatedAt, reports.ResolvedAt, reports.ResolvedBy, reports.Resolution"
+
+func (r *ReportRepo) Create(report structs.Report) (int, error) {
+	var id int
+	err := r.db.QueryRow(`INSERT INTO reports (ReporterID, TargetID, IsComment, Reason, Details, CreatedAt) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (ReporterID, TargetID, IsComment) WHERE ResolvedAt IS NULL DO NOTHING RETURNING ID`,
+		report.ReporterID, report.TargetID, report.IsComment, report.Reason, report.Details, report.CreatedAt.UTC()).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, store.ErrAlreadyReported
+	}
+	return id, err
+}
+
+func (r *ReportRepo) OpenCount(targetID int, isComment bool) (int, error) {
+	var n int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM reports WHERE TargetID = ? AND IsComment = ? AND ResolvedAt IS NULL`,
+		targetID, isComment).Scan(&n)
+	return n, err
+}
+
+// Queue reads the open reports of posts and then of comments, and groups
+// them by target in the order each target was first reported. Reports of
+// deleted content are left out.
+func (r *ReportRepo) Queue() ([]structs.ReportedItem, error) {
+	queries := []string{
+		`SELECT ` + reportColumns + `, POSTS.ID, POSTS.UserID, POSTS.UserName, POSTS.Title, POSTS.Content, POSTS.Hidden
+			FROM reports INNER JOIN POSTS ON POSTS.ID = reports.TargetID
+			WHERE reports.IsComment = ? AND reports.ResolvedAt IS NULL AND POSTS.DeletedAt IS NULL ORDER BY reports.ID`,
+		`SELECT ` + reportColumns + `, COMMENTS.PostId, COMMENTS.UserId, COMMENTS.UserName, POSTS.Title, COMMENTS.Comment, COMMENTS.Hidden
+			FROM reports INNER JOIN COMMENTS ON COMMENTS.ID = reports.TargetID INNER JOIN POSTS ON POSTS.ID = COMMENTS.PostId
+			WHERE reports.IsComment = ? AND reports.ResolvedAt IS NULL AND COMMENTS.DeletedAt IS NULL ORDER BY reports.ID`,
+	}
+
+	type target struct {
+		id        int
+		isComment bool
+	}
+	var items []structs.ReportedItem
+	index := map[target]int{}
+	for i, query := range queries {
+		rows, err := r.db.Query(query, i == 1)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var item structs.ReportedItem
+			report, err := scanReport(rows, &item.PostID, &item.AuthorID, &item.AuthorName, &item.Title, &item.Text, &item.Hidden)
+			if err != nil {
+				rows.Close()
+				return nil, err
+			}
+			key := target{report.TargetID, report.IsComment}
+			at, ok := index[key]
+			if !ok {
+				item.TargetID, item.IsComment = report.TargetID, report.IsComment
+				at = len(items)
+				index[key] = at
+				items = append(items, item)
+			}
+			items[at].Reports = append(items[at].Reports, report)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	slices.SortStableFunc(items, func(a, b structs.ReportedItem) int {
+		return a.Reports[0].ID - b.Reports[0].ID
+	})
+	return items, nil
+}
+
+func (r *ReportRepo) Resolve(targetID int, isComment bool, resolvedBy int, resolution string, outcome store.Outcome, now time.Time) ([]structs.Report, error) {
+	var reports []structs.Report
+	err := r.db.withTx(func(tx *tx) error {
+		rows, err := tx.Query(`UPDATE reports SET ResolvedAt = ?, ResolvedBy = ?, Resolution = ?
+			WHERE TargetID = ? AND IsComment = ? AND ResolvedAt IS NULL RETURNING `+reportColumns,
+			now.UTC(), resolvedBy, resolution, targetID, isComment)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			report, err := scanReport(rows)
+			if err != nil {
+				return err
+			}
+			reports = append(reports, report)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		rows.Close()
+		if len(reports) == 0 {
+			return store.ErrNotFound
+		}
+
+		table := "POSTS"
+		if isComment {
+			table = "COMMENTS"
+		}
+		if outcome.Remove {
+			// The author may have deleted it already.
+			_, err = tx.Exec(`UPDATE `+table+` SET DeletedAt = ?, DeletedBy = ? WHERE ID = ? AND DeletedAt IS NULL`, now.UTC(), resolvedBy, targetID)
+		} else {
+			_, err = tx.Exec(`UPDATE `+table+` SET Hidden = FALSE WHERE ID = ?`, targetID)
+		}
+		if err != nil {
+			return err
+		}
+		if outcome.Penalty != nil {
+			if err := addEvent(tx, *outcome.Penalty); err != nil {
+				return err
+			}
+			if err := refreshReputation(tx, outcome.Penalty.UserID); err != nil {
+				return err
+			}
+		}
+		if outcome.Ban == nil {
+			return nil
+		}
+		_, err = placeBan(tx, *outcome.Ban)
+		if errors.Is(err, store.ErrAlreadyBanned) {
+			return nil
+		}
+		if err != nil || outcome.Ban.Shadow {
+			return err
+		}
+		_, err = tx.Exec(`DELETE FROM sessions WHERE UserID = ?`, outcome.Ban.UserID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+// scanReport reads reportColumns and then any extra columns into extra.
+func scanReport(row interface{ Scan(...any) error }, extra ...any) (structs.Report, error) {
+	var report structs.Report
+	var resolvedAt sql.NullTime
+	var resolvedBy sql.NullInt64
+	dest := []any{&report.ID, &report.ReporterID, &report.TargetID, &report.IsComment, &report.Reason, &report.Details,
+		&report.CreatedAt, &resolvedAt, &resolvedBy, &report.Resolution}
+	err := row.Scan(append(dest, extra...)...)
+	report.ResolvedAt = resolvedAt.Time
+	report.ResolvedBy = int(resolvedBy.Int64)
+	return report, err
+}
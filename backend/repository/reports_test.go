@@ -0,0 +1,138 @@
+package repository_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"forum/backend/controllers/structs"
+	"forum/backend/reputation"
+	"forum/backend/store"
+)
+
+func report(t *testing.T, st store.Store, reporterID, targetID int, isComment bool) {
+	t.Helper()
+	_, err := st.Reports().Create(structs.Report{ReporterID: reporterID, TargetID: targetID, IsComment: isComment, Reason: "spam", CreatedAt: time.Now()})
+	if err != nil {
+		t.Fatalf("report: %v", err)
+	}
+}
+
+func TestResolveRemovesWithPenalty(t *testing.T) {
+	st := openStore(t)
+	author := createUser(t, st, "author")
+	reporter := createUser(t, st, "reporter")
+	moderator := createUser(t, st, "moderator")
+	postID := createPost(t, st, author, "Spam", "Buy now")
+	report(t, st, reporter, postID, false)
+	if err := st.Posts().SetHidden(postID, true); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	penalty := &structs.ReputationEvent{UserID: author, Points: reputation.Removed, Reason: reputation.ReasonRemoved,
+		TargetID: postID, ActorID: moderator, Note: "delete", CreatedAt: now}
+	reports, err := st.Reports().Resolve(postID, false, moderator, "delete", store.Outcome{Remove: true, Penalty: penalty}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 1 || reports[0].ReporterID != reporter {
+		t.Fatalf("resolved %+v, want the one report", reports)
+	}
+
+	post, err := st.Posts().ByID(postID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if post.DeletedAt.IsZero() || post.DeletedBy != moderator {
+		t.Errorf("post deleted at %v by %d, want now by the moderator", post.DeletedAt, post.DeletedBy)
+	}
+	user, err := st.Users().ByID(author)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Reputation != reputation.Removed {
+		t.Errorf("author reputation = %d, want %d", user.Reputation, reputation.Removed)
+	}
+	if open, err := st.Reports().OpenCount(postID, false); err != nil || open != 0 {
+		t.Errorf("OpenCount = %d, %v; want 0", open, err)
+	}
+
+	// Resolving again finds nothing and changes nothing.
+	_, err = st.Reports().Resolve(postID, false, moderator, "delete", store.Outcome{Remove: true, Penalty: penalty}, now)
+	if !errors.Is(err, store.ErrNotFound) {
+		t.Fatalf("second Resolve error = %v, want ErrNotFound", err)
+	}
+	if user, _ := st.Users().ByID(author); user.Reputation != reputation.Removed {
+		t.Errorf("author reputation = %d after second Resolve, want %d", user.Reputation, reputation.Removed)
+	}
+}
+
+func TestResolveDismissShowsAgain(t *testing.T) {
+	st := openStore(t)
+	author := createUser(t, st, "author")
+	reporter := createUser(t, st, "reporter")
+	postID := createPost(t, st, author, "Fine", "Nothing wrong here")
+	commentID := createComment(t, st, postID, author, "Nothing wrong here either")
+	report(t, st, reporter, commentID, true)
+	if err := st.Comments().SetHidden(commentID, true); err != nil {
+		t.Fatal(err)
+	}
+
+	again := structs.Report{ReporterID: reporter, TargetID: commentID, IsComment: true, Reason: "spam", CreatedAt: time.Now()}
+	if _, err := st.Reports().Create(again); !errors.Is(err, store.ErrAlreadyReported) {
+		t.Fatalf("second open report error = %v, want ErrAlreadyReported", err)
+	}
+
+	if _, err := st.Reports().Resolve(commentID, true, reporter, "dismiss", store.Outcome{}, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	comment, err := st.Comments().ByID(commentID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if comment.Hidden || !comment.DeletedAt.IsZero() {
+		t.Errorf("comment hidden %v, deleted at %v; want shown", comment.Hidden, comment.DeletedAt)
+	}
+
+	// Once dismissed, it can be reported again.
+	if _, err := st.Reports().Create(again); err != nil {
+		t.Fatalf("report after dismissal: %v", err)
+	}
+	if open, err := st.Reports().OpenCount(commentID, true); err != nil || open != 1 {
+		t.Errorf("OpenCount = %d, %v; want 1", open, err)
+	}
+}
+
+// A ban decided on a report is placed with the reports closed, and logs the
+// author out; an author who is banned already doesn't fail the decision.
+func TestResolveBans(t *testing.T) {
+	st := openStore(t)
+	author := createUser(t, st, "author")
+	reporter := createUser(t, st, "reporter")
+	moderator := createUser(t, st, "moderator")
+	first := createPost(t, st, author, "Spam", "Buy now")
+	second := createPost(t, st, author, "More spam", "Buy more")
+	report(t, st, reporter, first, false)
+	report(t, st, reporter, second, false)
+	now := time.Now().UTC().Truncate(time.Second)
+	session := structs.Session{UserID: author, CreatedAt: now, LastSeenAt: now, ExpiresAt: now.Add(time.Hour)}
+	if _, err := st.Sessions().Create(session, "hash"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, postID := range []int{first, second} {
+		ban := &structs.Ban{UserID: author, BannedBy: moderator, Reason: "spam", CreatedAt: now}
+		if _, err := st.Reports().Resolve(postID, false, moderator, "ban", store.Outcome{Remove: true, Ban: ban}, now); err != nil {
+			t.Fatalf("Resolve post %d: %v", postID, err)
+		}
+	}
+
+	bans, err := st.Bans().ByUser(author)
+	if err != nil || len(bans) != 1 || bans[0].BannedBy != moderator {
+		t.Fatalf("bans = %+v, %v; want the one ban", bans, err)
+	}
+	if sessions, err := st.Sessions().ByUser(author, now); err != nil || len(sessions) != 0 {
+		t.Errorf("author still has %d sessions, %v; want none", len(sessions), err)
+	}
+}
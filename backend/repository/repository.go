@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+
+	"forum/backend/store"
+)
+
+// Store is the database/sql implementation of store.Store. The SQL is written
+// once with "?" placeholders; the dialect adapts it to SQLite or PostgreSQL.
+type Store struct {
+	db       *conn
+	posts    *PostRepo
+	comments *CommentRepo
+	users    *UserRepo
+	idents   *IdentityRepo
+	twoFA    *TwoFactorRepo
+	sessions *SessionRepo
+	states   *OAuthStateRepo
+	failures *LoginFailureRepo
+	limits   *RateLimitRepo
+	votes    *VoteRepo
+	tags     *TagRepo
+	bans     *BanRepo
+	addrBans *AddressBanRepo
+	stats    *StatsRepo
+	reports  *ReportRepo
+	revs     *RevisionRepo
+	rep      *ReputationRepo
+}
+
+func New(db *sql.DB, dialect Dialect) *Store {
+	c := &conn{db: db, dialect: dialect}
+	return &Store{
+		db:       c,
+		posts:    &PostRepo{db: c},
+		comments: &CommentRepo{db: c},
+		users:    &UserRepo{db: c},
+		idents:   &IdentityRepo{db: c},
+		twoFA:    &TwoFactorRepo{db: c},
+		sessions: &SessionRepo{db: c},
+		states:   &OAuthStateRepo{db: c},
+		failures: &LoginFailureRepo{db: c},
+		limits:   &RateLimitRepo{db: c},
+		votes:    &VoteRepo{db: c},
+		tags:     &TagRepo{db: c},
+		bans:     &BanRepo{db: c},
+		addrBans: &AddressBanRepo{db: c},
+		stats:    &StatsRepo{db: c},
+		reports:  &ReportRepo{db: c},
+		revs:     &RevisionRepo{db: c},
+		rep:      &ReputationRepo{db: c},
+	}
+}
+
+func (s *Store) Posts() store.PostRepo {
+	return s.posts
+}
+
+func (s *Store) Comments() store.CommentRepo {
+	return s.comments
+}
+
+func (s *Store) Users() store.UserRepo {
+	return s.users
+}
+
+func (s *Store) Identities() store.IdentityRepo {
+	return s.idents
+}
+
+func (s *Store) TwoFactor() store.TwoFactorRepo {
+	return s.twoFA
+}
+
+func (s *Store) Sessions() store.SessionRepo {
+	return s.sessions
+}
+
+func (s *Store) OAuthStates() store.OAuthStateRepo {
+	return s.states
+}
+
+func (s *Store) LoginFailures() store.LoginFailureRepo {
+	return s.failures
+}
+
+func (s *Store) RateLimits() store.RateLimitRepo {
+	return s.limits
+}
+
+func (s *Store) Votes() store.VoteRepo {
+	return s.votes
+}
+
+func (s *Store) Tags() store.TagRepo {
+	return s.tags
+}
+
+func (s *Store) Bans() store.BanRepo {
+	return s.bans
+}
+
+func (s *Store) AddressBans() store.AddressBanRepo {
+	return s.addrBans
+}
+
+func (s *Store) Stats() store.StatsRepo {
+	return s.stats
+}
+
+func (s *Store) Reports() store.ReportRepo {
+	return s.reports
+}
+
+func (s *Store) Revisions() store.RevisionRepo {
+	return s.revs
+}
+
+func (s *Store) Reputation() store.ReputationRepo {
+	return s.rep
+}
+
+func (s *Store) Close() error {
+	return s.db.db.Close()
+}
+
+// changedOne checks the result of a statement that should change one row,
+// returning store.ErrNotFound if it changed none.
+func changedOne(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+// inList is an "IN (?, ?, ...)" condition on column for the values.
+func inList[T any](column string, values []T) (string, []any) {
+	args := make([]any, len(values))
+	for i, value := range values {
+		args[i] = value
+	}
+	return column + " IN (" + strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ") + ")", args
+}
+
+type conn struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+func (c *conn) Exec(query string, args ...any) (sql.Result, error) {
+	return c.db.Exec(c.dialect.Rebind(query), args...)
+}
+
+func (c *conn) Query(query string, args ...any) (*sql.Rows, error) {
+	return c.db.Query(c.dialect.Rebind(query), args...)
+}
+
+func (c *conn) QueryRow(query string, args ...any) *sql.Row {
+	return c.db.QueryRow(c.dialect.Rebind(query), args...)
+}
+
+func (c *conn) withTx(fn func(tx *tx) error) error {
+	sqlTx, err := c.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer sqlTx.Rollback()
+
+	if err := fn(&tx{tx: sqlTx, dialect: c.dialect}); err != nil {
+		return err
+	}
+	return sqlTx.Commit()
+}
+
+type tx struct {
+	tx      *sql.Tx
+	dialect Dialect
+}
+
+func (t *tx) Exec(query string, args ...any) (sql.Result, error) {
+	return t.tx.Exec(t.dialect.Rebind(query), args...)
+}
+
+func (t *tx) Query(query string, args ...any) (*sql.Rows, error) {
+	return t.tx.Query(t.dialect.Rebind(query), args...)
+}
+
+func (t *tx) QueryRow(query string, args ...any) *sql.Row {
+	return t.tx.QueryRow(t.dialect.Rebind(query), args...)
+}
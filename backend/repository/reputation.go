@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"strings"
+	"time"
+
+	"forum/backend/controllers/structs"
+	"forum/backend/reputation"
+	"forum/backend/store"
+)
+
+type ReputationRepo struct {
+	db *conn
+}
+
+const reputationColumns = `ID, UserID, Points, Reason, TargetID, IsComment, ActorID, Note, CreatedAt,
+	CASE WHEN IsComment THEN COALESCE((SELECT PostId FROM COMMENTS WHERE COMMENTS.ID = TargetID), 0) ELSE TargetID END`
+
+func (r *ReputationRepo) History(userID int, page store.Page) ([]structs.ReputationEvent, bool, error) {
+	where, args := []string{"UserID = ?"}, []any{userID}
+	cond, arg, order := keyset("ID", true, page)
+	if cond != "" {
+		where = append(where, cond)
+		args = append(args, arg)
+	}
+
+	rows, err := r.db.Query("SELECT "+reputationColumns+" FROM reputation_events WHERE "+strings.Join(where, " AND ")+
+		" ORDER BY "+order+" LIMIT ?", append(args, page.Limit+1)...)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	var events []structs.ReputationEvent
+	for rows.Next() {
+		var event structs.ReputationEvent
+		err := rows.Scan(&event.ID, &event.UserID, &event.Points, &event.Reason, &event.TargetID, &event.IsComment,
+			&event.ActorID, &event.Note, &event.CreatedAt, &event.PostID)
+		if err != nil {
+			return nil, false, err
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+
+	events, more := pageOf(events, page)
+	return events, more, nil
+}
+
+func (r *ReputationRepo) Record(event structs.ReputationEvent) error {
+	return r.db.withTx(func(tx *tx) error {
+		if err := addEvent(tx, event); err != nil {
+			return err
+		}
+		return refreshReputation(tx, event.UserID)
+	})
+}
+
+// Recompute throws away the events that follow from votes and accepted
+// answers and records them again from USERLIKES and POSTS. Rebuilt vote
+// events are dated now, as USERLIKES doesn't say when votes were cast.
+func (r *ReputationRepo) Recompute(now time.Time) (int, error) {
+	var changed int
+	err := r.db.withTx(func(tx *tx) error {
+		derived, args := inList("Reason", reputation.Derived)
+		if _, err := tx.Exec("DELETE FROM reputation_events WHERE "+derived, args...); err != nil {
+			return err
+		}
+
+		for _, item := range []struct {
+			table, authorColumn string
+			isComment           bool
+		}{{"POSTS", "POSTS.UserID", false}, {"COMMENTS", "COMMENTS.UserId", true}} {
+			for _, vote := range []struct {
+				column, reason string
+				points         int
+			}{{"Liked", reputation.ReasonUpvote, reputation.Upvote}, {"Disliked", reputation.ReasonDownvote, reputation.Downvote}} {
+				_, err := tx.Exec(`INSERT INTO reputation_events (UserID, Points, Reason, TargetID, IsComment, ActorID, CreatedAt)
+					SELECT `+item.authorColumn+`, ?, ?, `+item.table+`.ID, ?, USERLIKES.UserID, ?
+					FROM USERLIKES INNER JOIN `+item.table+` ON `+item.table+`.ID = USERLIKES.PostID
+					WHERE USERLIKES.IsComment = ? AND USERLIKES.`+vote.column+` = ?
+					AND `+item.authorColumn+` <> USERLIKES.UserID AND `+item.authorColumn+` <> 0`,
+					vote.points, vote.reason, item.isComment, now.UTC(), item.isComment, true)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		_, err := tx.Exec(`INSERT INTO reputation_events (UserID, Points, Reason, TargetID, IsComment, ActorID, CreatedAt)
+			SELECT COMMENTS.UserId, ?, ?, COMMENTS.ID, ?, POSTS.UserID, ?
+			FROM POSTS INNER JOIN COMMENTS ON COMMENTS.ID = POSTS.AcceptedCommentID
+			WHERE COMMENTS.UserId <> POSTS.UserID AND COMMENTS.UserId <> 0`,
+			reputation.AcceptedAnswer, reputation.ReasonAccepted, true, now.UTC())
+		if err != nil {
+			return err
+		}
+
+		err = tx.QueryRow(`SELECT COUNT(*) FROM USERS WHERE Reputation <> ` + reputationSum).Scan(&changed)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`UPDATE USERS SET Reputation = ` + reputationSum)
+		return err
+	})
+	return changed, err
+}
+
+// reputationSum is the total of the events of the USERS row.
+const reputationSum = "COALESCE((SELECT SUM(Points) FROM reputation_events WHERE reputation_events.UserID = USERS.ID), 0)"
+
+func addEvent(tx *tx, event structs.ReputationEvent) error {
+	createdAt := event.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	_, err := tx.Exec(`INSERT INTO reputation_events (UserID, Points, Reason, TargetID, IsComment, ActorID, Note, CreatedAt)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		event.UserID, event.Points, event.Reason, event.TargetID, event.IsComment, event.ActorID, event.Note, createdAt.UTC())
+	return err
+}
+
+// refreshReputation sets the users' USERS.Reputation to the sum of their
+// events.
+func refreshReputation(tx *tx, userIDs ...int) error {
+	for _, id := range userIDs {
+		if _, err := tx.Exec(`UPDATE USERS SET Reputation = `+reputationSum+` WHERE ID = ?`, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dropDerivedEvents removes the vote and accepted answer events matching
+// the condition and refreshes the totals of the users they were for. What
+// moderators recorded stays.
+func dropDerivedEvents(tx *tx, cond string, args ...any) error {
+	derived, derivedArgs := inList("Reason", reputation.Derived)
+	where := derived + " AND (" + cond + ")"
+	whereArgs := append(derivedArgs, args...)
+
+	users, err := queryIDs(tx, "SELECT DISTINCT UserID FROM reputation_events WHERE "+where, whereArgs...)
+	if err != nil {
+		return err
+	}
+	if len(users) == 0 {
+		return nil
+	}
+	if _, err := tx.Exec("DELETE FROM reputation_events WHERE "+where, whereArgs...); err != nil {
+		return err
+	}
+	return refreshReputation(tx, users...)
+}
+
+// setVoteEvent records what the voter's vote on the target earns its
+// author, replacing what their earlier vote on it earned.
+func setVoteEvent(tx *tx, voterID int, target store.VoteTarget, state store.VoteState, now time.Time) error {
+	err := dropDerivedEvents(tx, "ActorID = ? AND TargetID = ? AND IsComment = ? AND Reason <> ?",
+		voterID, target.ID, target.IsComment, reputation.ReasonAccepted)
+	if err != nil {
+		return err
+	}
+	if state == store.VoteNone {
+		return nil
+	}
+
+	query := "SELECT UserID FROM POSTS WHERE ID = ?"
+	if target.IsComment {
+		query = "SELECT UserId FROM COMMENTS WHERE ID = ?"
+	}
+	var authorID int
+	if err := tx.QueryRow(query, target.ID).Scan(&authorID); err != nil {
+		return err
+	}
+	if authorID == 0 || authorID == voterID {
+		return nil
+	}
+
+	event := structs.ReputationEvent{UserID: authorID, Points: reputation.Upvote, Reason: reputation.ReasonUpvote,
+		TargetID: target.ID, IsComment: target.IsComment, ActorID: voterID, CreatedAt: now}
+	if state == store.VoteDown {
+		event.Points, event.Reason = reputation.Downvote, reputation.ReasonDownvote
+	}
+	if err := addEvent(tx, event); err != nil {
+		return err
+	}
+	return refreshReputation(tx, authorID)
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"forum/backend/controllers/structs"
+	"forum/backend/store"
+)
+
+type RevisionRepo struct {
+	db *conn
+}
+
+const revisionColumns = "revisions.ID, revisions.TargetID, revisions.IsComment, revisions.Title, revisions.Body, revisions.Tags, revisions.EditorID, COALESCE(USERS.UserName, ''), revisions.CreatedAt"
+
+// History reads the stored revisions, then the post or comment itself as
+// the current version.
+func (r *RevisionRepo) History(targetID int, isComment bool) ([]structs.Revision, error) {
+	current, err := currentVersion(r.db, r.db.dialect, targetID, isComment)
+	if err != nil {
+		return nil, err
+	}
+	err = r.db.QueryRow("SELECT UserName FROM USERS WHERE ID = ?", current.EditorID).Scan(&current.EditorName)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(`SELECT `+revisionColumns+` FROM revisions LEFT JOIN USERS ON USERS.ID = revisions.EditorID
+		WHERE revisions.TargetID = ? AND revisions.IsComment = ? ORDER BY revisions.ID`, targetID, isComment)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var versions []structs.Revision
+	for rows.Next() {
+		var revision structs.Revision
+		var tags string
+		err := rows.Scan(&revision.ID, &revision.TargetID, &revision.IsComment, &revision.Title, &revision.Body, &tags,
+			&revision.EditorID, &revision.EditorName, &revision.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		revision.Tags = strings.Fields(tags)
+		versions = append(versions, revision)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return append(versions, current), nil
+}
+
+// queryer is a conn or a tx.
+type queryer interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// currentVersion reads the post or comment as a revision without an ID.
+// Inside a transaction on PostgreSQL the row stays locked until it ends.
+func currentVersion(q queryer, dialect Dialect, targetID int, isComment bool) (structs.Revision, error) {
+	version := structs.Revision{TargetID: targetID, IsComment: isComment}
+	var created string
+	var err error
+	if isComment {
+		err = q.QueryRow(`SELECT Comment, COALESCE(EditedBy, UserId), `+dialect.Timestamp("COALESCE(EditedAt, created_at)")+`
+			FROM COMMENTS WHERE ID = ?`+dialect.ForUpdate(), targetID).Scan(&version.Body, &version.EditorID, &created)
+	} else {
+		err = q.QueryRow(`SELECT Title, Content, COALESCE(EditedBy, UserID), `+dialect.Timestamp("COALESCE(EditedAt, PostDate)")+`
+			FROM POSTS WHERE ID = ?`+dialect.ForUpdate(), targetID).Scan(&version.Title, &version.Body, &version.EditorID, &created)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return version, store.ErrNotFound
+	}
+	if err != nil {
+		return version, err
+	}
+	version.CreatedAt, err = time.Parse(timestampLayout, created)
+	if err != nil || isComment {
+		return version, err
+	}
+
+	rows, err := q.Query(`SELECT tags.Slug FROM tags INNER JOIN post_tags ON tags.ID = post_tags.TagID
+		WHERE post_tags.PostID = ? ORDER BY tags.Slug`, targetID)
+	if err != nil {
+		return version, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var slug string
+		if err := rows.Scan(&slug); err != nil {
+			return version, err
+		}
+		version.Tags = append(version.Tags, slug)
+	}
+	return version, rows.Err()
+}
+
+// addRevision keeps the current version of the post or comment before it is
+// replaced.
+func addRevision(tx *tx, targetID int, isComment bool) error {
+	version, err := currentVersion(tx, tx.dialect, targetID, isComment)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`INSERT INTO revisions (TargetID, IsComment, Title, Body, Tags, EditorID, CreatedAt) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		targetID, isComment, version.Title, version.Body, strings.Join(version.Tags, " "), version.EditorID, version.CreatedAt.UTC())
+	return err
+}
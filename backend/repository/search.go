@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"strings"
+	"unicode"
+
+	"forum/backend/store"
+)
+
+// timestampLayout matches how both databases print POSTS.PostDate, so the
+// bound value compares correctly as text on SQLite.
+const timestampLayout = "2006-01-02 15:04:05"
+
+// ftsQuery builds an FTS5 MATCH expression. Every term is quoted so that
+// user input can never be read as FTS5 operators.
+func ftsQuery(terms []store.SearchTerm) string {
+	parts := make([]string, 0, len(terms))
+	for _, term := range terms {
+		part := `"` + strings.ReplaceAll(term.Text, `"`, `""`) + `"`
+		if term.Prefix {
+			part += "*"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " ")
+}
+
+// tsQuery builds a to_tsquery expression: terms are ANDed, the words of a
+// phrase must be adjacent, and each lexeme is quoted.
+func tsQuery(terms []store.SearchTerm) string {
+	parts := make([]string, 0, len(terms))
+	for _, term := range terms {
+		words := strings.FieldsFunc(term.Text, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		if len(words) == 0 {
+			continue
+		}
+
+		lexemes := make([]string, len(words))
+		for i, word := range words {
+			lexemes[i] = "'" + strings.ReplaceAll(word, "'", "''") + "'"
+		}
+		if term.Prefix {
+			lexemes[len(lexemes)-1] += ":*"
+		}
+
+		if len(lexemes) == 1 {
+			parts = append(parts, lexemes[0])
+		} else {
+			parts = append(parts, "("+strings.Join(lexemes, " <-> ")+")")
+		}
+	}
+	return strings.Join(parts, " & ")
+}
@@ -0,0 +1,112 @@
+package repository_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"forum/backend/controllers/structs"
+	"forum/backend/search"
+	"forum/backend/store"
+)
+
+// searchFor returns the posts the public finds for the single word.
+func searchFor(t *testing.T, st store.Store, word string) []structs.Post {
+	t.Helper()
+	posts, _, err := st.Posts().Search(store.SearchQuery{Terms: []store.SearchTerm{{Text: word}}}, store.Page{Limit: 10})
+	if err != nil {
+		t.Fatalf("Search(%q): %v", word, err)
+	}
+	return posts
+}
+
+func TestSearchSkipsHiddenComments(t *testing.T) {
+	st := openStore(t)
+	author := createUser(t, st, "author")
+	commenter := createUser(t, st, "commenter")
+	postID := createPost(t, st, author, "Channels", "How do channels work")
+	commentID := createComment(t, st, postID, commenter, "reportedword here")
+
+	if posts := searchFor(t, st, "reportedword"); len(posts) != 1 {
+		t.Fatalf("visible comment: found %d posts, want 1", len(posts))
+	}
+
+	if err := st.Comments().SetHidden(commentID, true); err != nil {
+		t.Fatal(err)
+	}
+	if posts := searchFor(t, st, "reportedword"); len(posts) != 0 {
+		t.Fatalf("hidden comment: found %d posts with snippet %q, want none", len(posts), posts[0].Snippet)
+	}
+
+	if err := st.Comments().SetHidden(commentID, false); err != nil {
+		t.Fatal(err)
+	}
+	posts := searchFor(t, st, "reportedword")
+	if len(posts) != 1 || !strings.Contains(posts[0].Snippet, "reportedword") {
+		t.Fatalf("unhidden comment: got %+v, want the post again", posts)
+	}
+}
+
+func TestSearchSkipsShadowBannedComments(t *testing.T) {
+	st := openStore(t)
+	author := createUser(t, st, "author")
+	shadowed := createUser(t, st, "shadowed")
+	postID := createPost(t, st, author, "Channels", "How do channels work")
+
+	_, err := st.Comments().Create(structs.Comment{PostId: postID, UserId: shadowed, UserName: "shadowed", Comment: "secretword shadowy", Shadow: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if posts := searchFor(t, st, "secretword"); len(posts) != 0 {
+		t.Fatalf("found %d posts with snippet %q, want none", len(posts), posts[0].Snippet)
+	}
+}
+
+func TestSearchSkipsTrashedComments(t *testing.T) {
+	st := openStore(t)
+	author := createUser(t, st, "author")
+	commenter := createUser(t, st, "commenter")
+	postID := createPost(t, st, author, "Channels", "How do channels work")
+	commentID := createComment(t, st, postID, commenter, "deletedtext here")
+
+	if err := st.Comments().Delete(commentID, commenter, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if posts := searchFor(t, st, "deletedtext"); len(posts) != 0 {
+		t.Fatalf("trashed comment: found %d posts with snippet %q, want none", len(posts), posts[0].Snippet)
+	}
+
+	if err := st.Comments().Restore(commentID); err != nil {
+		t.Fatal(err)
+	}
+	if posts := searchFor(t, st, "deletedtext"); len(posts) != 1 {
+		t.Fatalf("restored comment: found %d posts, want 1", len(posts))
+	}
+}
+
+// FTS5 operators typed into the search box are searched for as words rather
+// than changing the query or failing it.
+func TestSearchInputIsNeverSyntax(t *testing.T) {
+	st := openStore(t)
+	author := createUser(t, st, "author")
+	createPost(t, st, author, "Channels", "How do channels work")
+
+	for _, input := range []string{
+		`NEAR(channels work)`, `channels AND`, `"channels`, `^channels`, `-channels`,
+		`channels:work`, `c++`, `'quote'`, `channels*`, `{title}: channels`, `channels NOT work`,
+	} {
+		if _, _, err := st.Posts().Search(search.Parse(input), store.Page{Limit: 10}); err != nil {
+			t.Errorf("Search(%q): %v", input, err)
+		}
+	}
+
+	// Were OR an operator, the post would match through "channels".
+	posts, _, err := st.Posts().Search(search.Parse("channels OR nothing"), store.Page{Limit: 10})
+	if err != nil || len(posts) != 0 {
+		t.Errorf("Search(channels OR nothing) = %d posts, %v; want none", len(posts), err)
+	}
+	posts, _, err = st.Posts().Search(search.Parse(`"channels work"`), store.Page{Limit: 10})
+	if err != nil || len(posts) != 1 {
+		t.Errorf(`Search("channels work") = %d posts, %v; want the post`, len(posts), err)
+	}
+}
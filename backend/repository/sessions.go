@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"forum/backend/controllers/structs"
+	"forum/backend/store"
+)
+
+type SessionRepo struct {
+	db *conn
+}
+
+const sessionColumns = "sessions.ID, sessions.UserID, sessions.UserAgent, sessions.IP, sessions.CreatedAt, sessions.LastSeenAt, sessions.ExpiresAt"
+
+func (r *SessionRepo) Create(session structs.Session, tokenHash string) (int, error) {
+	var id int
+	err := r.db.QueryRow(`INSERT INTO sessions (UserID, TokenHash, UserAgent, IP, CreatedAt, LastSeenAt, ExpiresAt)
+		VALUES (?, ?, ?, ?, ?, ?, ?) RETURNING ID`,
+		session.UserID, tokenHash, session.UserAgent, session.IP,
+		session.CreatedAt.UTC(), session.LastSeenAt.UTC(), session.ExpiresAt.UTC()).Scan(&id)
+	return id, err
+}
+
+func (r *SessionRepo) ByTokenHash(tokenHash string, now time.Time) (structs.Session, string, error) {
+	var session structs.Session
+	var userName string
+	err := r.db.QueryRow(`SELECT `+sessionColumns+`, USERS.UserName
+		FROM sessions
+		INNER JOIN USERS ON USERS.ID = sessions.UserID
+		WHERE sessions.TokenHash = ? AND sessions.ExpiresAt > ?`, tokenHash, now.UTC()).
+		Scan(&session.ID, &session.UserID, &session.UserAgent, &session.IP, &session.CreatedAt, &session.LastSeenAt, &session.ExpiresAt, &userName)
+	if errors.Is(err, sql.ErrNoRows) {
+		return session, "", store.ErrNotFound
+	}
+	return session, userName, err
+}
+
+func (r *SessionRepo) Touch(id int, lastSeen, expires time.Time) error {
+	_, err := r.db.Exec(`UPDATE sessions SET LastSeenAt = ?, ExpiresAt = ? WHERE ID = ?`, lastSeen.UTC(), expires.UTC(), id)
+	return err
+}
+
+func (r *SessionRepo) ByUser(userID int, now time.Time) ([]structs.Session, error) {
+	rows, err := r.db.Query(`SELECT `+sessionColumns+` FROM sessions
+		WHERE UserID = ? AND ExpiresAt > ?
+		ORDER BY LastSeenAt DESC`, userID, now.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []structs.Session
+	for rows.Next() {
+		var session structs.Session
+		err := rows.Scan(&session.ID, &session.UserID, &session.UserAgent, &session.IP, &session.CreatedAt, &session.LastSeenAt, &session.ExpiresAt)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+// Delete revokes one of the user's sessions. It returns store.ErrNotFound if
+// the session doesn't exist or belongs to someone else.
+func (r *SessionRepo) Delete(id, userID int) error {
+	result, err := r.db.Exec(`DELETE FROM sessions WHERE ID = ? AND UserID = ?`, id, userID)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (r *SessionRepo) DeleteByTokenHash(tokenHash string) error {
+	_, err := r.db.Exec(`DELETE FROM sessions WHERE TokenHash = ?`, tokenHash)
+	return err
+}
+
+func (r *SessionRepo) DeleteByUser(userID int) error {
+	_, err := r.db.Exec(`DELETE FROM sessions WHERE UserID = ?`, userID)
+	return err
+}
+
+func (r *SessionRepo) DeleteOthers(userID, keepID int) error {
+	_, err := r.db.Exec(`DELETE FROM sessions WHERE UserID = ? AND ID <> ?`, userID, keepID)
+	return err
+}
+
+func (r *SessionRepo) DeleteExpired(now time.Time) (int, error) {
+	result, err := r.db.Exec(`DELETE FROM sessions WHERE ExpiresAt <= ?`, now.UTC())
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
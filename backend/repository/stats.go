@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"time"
+
+	"forum/backend/controllers/structs"
+)
+
+type StatsRepo struct {
+	db *conn
+}
+
+func (r *StatsRepo) Site(now time.Time, days int) (structs.SiteStats, error) {
+	var stats structs.SiteStats
+
+	// The first day counted is today's date days-1 days ago.
+	now = now.UTC()
+	first := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1-days)
+	since := first.Format(timestampLayout)
+
+	totals := []struct {
+		dest  *int
+		query string
+		args  []any
+	}{
+		{&stats.Users, "SELECT COUNT(*) FROM USERS", nil},
+		{&stats.Posts, "SELECT COUNT(*) FROM POSTS WHERE DeletedAt IS NULL", nil},
+		{&stats.Comments, "SELECT COUNT(*) FROM COMMENTS WHERE DeletedAt IS NULL", nil},
+		{&stats.UpVotes, "SELECT COUNT(*) FROM USERLIKES WHERE Liked = ?", []any{true}},
+		{&stats.DownVotes, "SELECT COUNT(*) FROM USERLIKES WHERE Disliked = ?", []any{true}},
+		{&stats.BannedUsers, "SELECT COUNT(DISTINCT UserID) FROM bans WHERE " + inForce, []any{now}},
+		// Active users wrote something or were seen logged in.
+		{&stats.ActiveUsers, `SELECT COUNT(*) FROM (
+			SELECT UserID FROM POSTS WHERE PostDate >= ?
+			UNION SELECT UserId FROM COMMENTS WHERE created_at >= ?
+			UNION SELECT UserID FROM sessions WHERE LastSeenAt >= ?
+		) AS active`, []any{since, since, first}},
+	}
+	for _, total := range totals {
+		if err := r.db.QueryRow(total.query, total.args...).Scan(total.dest); err != nil {
+			return stats, err
+		}
+	}
+
+	var err error
+	stats.PostsPerDay, err = r.perDay("POSTS", "PostDate", first, days)
+	if err != nil {
+		return stats, err
+	}
+	stats.CommentsPerDay, err = r.perDay("COMMENTS", "created_at", first, days)
+	return stats, err
+}
+
+// perDay counts the table's rows by the date in column, with a zero for
+// days that have none.
+func (r *StatsRepo) perDay(table, column string, first time.Time, days int) ([]structs.DayCount, error) {
+	day := r.db.dialect.Date(column)
+	rows, err := r.db.Query("SELECT "+day+", COUNT(*) FROM "+table+" WHERE "+column+" >= ? GROUP BY "+day,
+		first.Format(timestampLayout))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var date string
+		var n int
+		if err := rows.Scan(&date, &n); err != nil {
+			return nil, err
+		}
+		counts[date] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	perDay := make([]structs.DayCount, days)
+	for i := range perDay {
+		date := first.AddDate(0, 0, i).Format("2006-01-02")
+		perDay[i] = structs.DayCount{Day: date, Count: counts[date]}
+	}
+	return perDay, nil
+}
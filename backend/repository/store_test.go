@@ -0,0 +1,63 @@
+package repository_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"forum/backend/config"
+	"forum/backend/controllers/structs"
+	"forum/backend/database"
+	"forum/backend/store"
+)
+
+// openStore returns an in-memory SQLite store with the whole schema, closed
+// when the test ends.
+func openStore(t *testing.T) store.Store {
+	t.Helper()
+	st, err := database.OpenMemory()
+	if err != nil {
+		t.Fatalf("OpenMemory: %v", err)
+	}
+	t.Cleanup(func() { st.Close() })
+	return st
+}
+
+// openFileStore returns a SQLite store on a WAL database file with the same
+// connection pool the server uses, so transactions really run side by side.
+func openFileStore(t *testing.T) store.Store {
+	t.Helper()
+	dsn := "file:" + filepath.Join(t.TempDir(), "forum.db") + "?_journal_mode=WAL&_busy_timeout=5000&_txlock=immediate"
+	st, err := database.Connect(config.Config{DatabaseDriver: config.DriverSQLite, DatabaseURL: dsn})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	t.Cleanup(func() { st.Close() })
+	return st
+}
+
+func createUser(t *testing.T, st store.Store, name string) int {
+	t.Helper()
+	id, err := st.Users().Create(structs.User{Email: name + "@example.com", UserName: name, Password: "hash"})
+	if err != nil {
+		t.Fatalf("create user %s: %v", name, err)
+	}
+	return id
+}
+
+func createPost(t *testing.T, st store.Store, userID int, title, content string) int {
+	t.Helper()
+	id, err := st.Posts().Create(structs.Post{UserID: userID, UserName: "user", Title: title, Content: content})
+	if err != nil {
+		t.Fatalf("create post: %v", err)
+	}
+	return id
+}
+
+func createComment(t *testing.T, st store.Store, postID, userID int, text string) int {
+	t.Helper()
+	id, err := st.Comments().Create(structs.Comment{PostId: postID, UserId: userID, UserName: "user", Comment: text})
+	if err != nil {
+		t.Fatalf("create comment: %v", err)
+	}
+	return id
+}
@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"forum/backend/controllers/structs"
+	"forum/backend/store"
+)
+
+type TagRepo struct {
+	db *conn
+}
+
+const tagColumns = "tags.ID, tags.Slug, tags.Name, tags.Description, tags.Color"
+
+func (r *TagRepo) All() ([]structs.Tag, error) {
+	return r.list("SELECT " + tagColumns + " FROM tags ORDER BY tags.Name")
+}
+
+func (r *TagRepo) BySlug(slug string) (structs.Tag, error) {
+	var tag structs.Tag
+	err := r.db.QueryRow("SELECT "+tagColumns+" FROM tags WHERE Slug = ?", slug).
+		Scan(&tag.ID, &tag.Slug, &tag.Name, &tag.Description, &tag.Color)
+	if errors.Is(err, sql.ErrNoRows) {
+		return tag, store.ErrNotFound
+	}
+	return tag, err
+}
+
+func (r *TagRepo) Create(tag structs.Tag, createdBy int) (int, error) {
+	var id int
+	err := r.db.QueryRow(`INSERT INTO tags (Slug, Name, Description, Color, CreatedBy) VALUES (?, ?, ?, ?, ?) RETURNING ID`,
+		tag.Slug, tag.Name, tag.Description, tag.Color, createdBy).Scan(&id)
+	return id, err
+}
+
+func (r *TagRepo) Update(tag structs.Tag) error {
+	result, err := r.db.Exec(`UPDATE tags SET Name = ?, Description = ?, Color = ? WHERE ID = ?`,
+		tag.Name, tag.Description, tag.Color, tag.ID)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (r *TagRepo) Delete(id int) error {
+	return r.db.withTx(func(tx *tx) error {
+		if _, err := tx.Exec(`DELETE FROM post_tags WHERE TagID = ?`, id); err != nil {
+			return err
+		}
+		result, err := tx.Exec(`DELETE FROM tags WHERE ID = ?`, id)
+		if err != nil {
+			return err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return store.ErrNotFound
+		}
+		return nil
+	})
+}
+
+func (r *TagRepo) ForPost(postID int) ([]structs.Tag, error) {
+	return r.list(`
+        SELECT `+tagColumns+`
+        FROM tags
+        INNER JOIN post_tags ON tags.ID = post_tags.TagID
+        WHERE post_tags.PostID = ?
+        ORDER BY tags.Name
+    `, postID)
+}
+
+func (r *TagRepo) Activity(userID int, audience store.Audience, limit int) ([]structs.TagActivity, error) {
+	posts, postArgs := visible("POSTS", "POSTS.UserID", audience)
+	posts = append(posts, "POSTS.DeletedAt IS NULL")
+	comments, commentArgs := visible("COMMENTS", "COMMENTS.UserId", audience)
+	comments = append(append(comments, posts...), "COMMENTS.DeletedAt IS NULL")
+
+	args := append(append([]any{userID}, postArgs...), userID)
+	args = append(append(append(args, commentArgs...), postArgs...), limit)
+	rows, err := r.db.Query(`SELECT `+tagColumns+`, SUM(activity.Posts), SUM(activity.Comments)
+		FROM (
+			SELECT post_tags.TagID, 1 AS Posts, 0 AS Comments
+			FROM POSTS INNER JOIN post_tags ON post_tags.PostID = POSTS.ID
+			WHERE POSTS.UserID = ? AND `+strings.Join(posts, " AND ")+`
+			UNION ALL
+			SELECT post_tags.TagID, 0 AS Posts, 1 AS Comments
+			FROM COMMENTS INNER JOIN POSTS ON POSTS.ID = COMMENTS.PostId
+			INNER JOIN post_tags ON post_tags.PostID = POSTS.ID
+			WHERE COMMENTS.UserId = ? AND `+strings.Join(comments, " AND ")+`
+		) activity INNER JOIN tags ON tags.ID = activity.TagID
+		GROUP BY `+tagColumns+`
+		ORDER BY SUM(activity.Posts) + SUM(activity.Comments) DESC, tags.Name
+		LIMIT ?`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var activity []structs.TagActivity
+	for rows.Next() {
+		var tag structs.TagActivity
+		err := rows.Scan(&tag.ID, &tag.Slug, &tag.Name, &tag.Description, &tag.Color, &tag.Posts, &tag.Comments)
+		if err != nil {
+			return nil, err
+		}
+		activity = append(activity, tag)
+	}
+	return activity, rows.Err()
+}
+
+func (r *TagRepo) SetForPost(postID int, slugs []string) error {
+	return r.db.withTx(func(tx *tx) error {
+		return setPostTags(tx, postID, slugs)
+	})
+}
+
+func setPostTags(tx *tx, postID int, slugs []string) error {
+	if _, err := tx.Exec(`DELETE FROM post_tags WHERE PostID = ?`, postID); err != nil {
+		return err
+	}
+	for _, slug := range slugs {
+		var tagID int
+		err := tx.QueryRow(`SELECT ID FROM tags WHERE Slug = ?`, slug).Scan(&tagID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return store.ErrUnknownTag
+		}
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`INSERT INTO post_tags (PostID, TagID) VALUES (?, ?) ON CONFLICT (PostID, TagID) DO NOTHING`, postID, tagID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *TagRepo) list(query string, args ...any) ([]structs.Tag, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []structs.Tag
+	for rows.Next() {
+		var tag structs.Tag
+		if err := rows.Scan(&tag.ID, &tag.Slug, &tag.Name, &tag.Description, &tag.Color); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, rows.Err()
+}
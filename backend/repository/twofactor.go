@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"forum/backend/controllers/structs"
+	"forum/backend/store"
+)
+
+type TwoFactorRepo struct {
+	db *conn
+}
+
+func (r *TwoFactorRepo) ByUser(userID int) (structs.TwoFactor, error) {
+	var tf structs.TwoFactor
+	err := r.db.QueryRow(`SELECT UserID, Secret, Enabled, LastStep, CreatedAt FROM two_factor WHERE UserID = ?`, userID).
+		Scan(&tf.UserID, &tf.Secret, &tf.Enabled, &tf.LastStep, &tf.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return structs.TwoFactor{}, store.ErrNotFound
+	}
+	return tf, err
+}
+
+func (r *TwoFactorRepo) Begin(userID int, secret string, now time.Time) error {
+	_, err := r.db.Exec(`INSERT INTO two_factor (UserID, Secret, Enabled, LastStep, CreatedAt) VALUES (?, ?, FALSE, 0, ?)
+		ON CONFLICT (UserID) DO UPDATE SET Secret = excluded.Secret, LastStep = 0, CreatedAt = excluded.CreatedAt
+		WHERE two_factor.Enabled = FALSE`, userID, secret, now.UTC())
+	return err
+}
+
+func (r *TwoFactorRepo) Enable(userID int, step int64, codeHashes []string) error {
+	return r.db.withTx(func(tx *tx) error {
+		result, err := tx.Exec(`UPDATE two_factor SET Enabled = TRUE, LastStep = ? WHERE UserID = ? AND Enabled = FALSE`, step, userID)
+		if err != nil {
+			return err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return store.ErrNotFound
+		}
+		return replaceRecoveryCodes(tx, userID, codeHashes)
+	})
+}
+
+func (r *TwoFactorRepo) Disable(userID int) error {
+	return r.db.withTx(func(tx *tx) error {
+		if _, err := tx.Exec(`DELETE FROM recovery_codes WHERE UserID = ?`, userID); err != nil {
+			return err
+		}
+		_, err := tx.Exec(`DELETE FROM two_factor WHERE UserID = ?`, userID)
+		return err
+	})
+}
+
+func (r *TwoFactorRepo) UseStep(userID int, step int64) (bool, error) {
+	result, err := r.db.Exec(`UPDATE two_factor SET LastStep = ? WHERE UserID = ? AND LastStep < ?`, step, userID, step)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func (r *TwoFactorRepo) UseRecoveryCode(userID int, codeHash string, now time.Time) (bool, error) {
+	result, err := r.db.Exec(`UPDATE recovery_codes SET UsedAt = ? WHERE UserID = ? AND CodeHash = ? AND UsedAt IS NULL`,
+		now.UTC(), userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func (r *TwoFactorRepo) ReplaceRecoveryCodes(userID int, codeHashes []string) error {
+	return r.db.withTx(func(tx *tx) error {
+		return replaceRecoveryCodes(tx, userID, codeHashes)
+	})
+}
+
+func replaceRecoveryCodes(tx *tx, userID int, codeHashes []string) error {
+	if _, err := tx.Exec(`DELETE FROM recovery_codes WHERE UserID = ?`, userID); err != nil {
+		return err
+	}
+	for _, hash := range codeHashes {
+		if _, err := tx.Exec(`INSERT INTO recovery_codes (UserID, CodeHash) VALUES (?, ?)`, userID, hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *TwoFactorRepo) RecoveryCodesLeft(userID int) (int, error) {
+	var n int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM recovery_codes WHERE UserID = ? AND UsedAt IS NULL`, userID).Scan(&n)
+	return n, err
+}
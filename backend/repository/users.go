@@ -0,0 +1,322 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"forum/backend/controllers/structs"
+	"forum/backend/store"
+)
+
+type UserRepo struct {
+	db *conn
+}
+
+func (r *UserRepo) ByEmail(email string) (structs.User, error) {
+	return r.scan(r.db.QueryRow("SELECT ID, Email, UserName, Password, Role, EmailVerified, LockedUntil, Reputation FROM USERS WHERE Email = ?", email))
+}
+
+func (r *UserRepo) ByID(id int) (structs.User, error) {
+	return r.scan(r.db.QueryRow("SELECT ID, Email, UserName, Password, Role, EmailVerified, LockedUntil, Reputation FROM USERS WHERE ID = ?", id))
+}
+
+func (r *UserRepo) EmailTaken(email string) (bool, error) {
+	return r.exists("SELECT EXISTS(SELECT 1 FROM USERS WHERE Email = ?)", email)
+}
+
+func (r *UserRepo) UserNameTaken(userName string) (bool, error) {
+	return r.exists("SELECT EXISTS(SELECT 1 FROM USERS WHERE UserName = ?)", userName)
+}
+
+func (r *UserRepo) Create(user structs.User) (int, error) {
+	var id int
+	err := r.db.QueryRow("INSERT INTO USERS (Email, UserName, Password, Role, CreatedAt) VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP) RETURNING ID",
+		user.Email, user.UserName, user.Password, user.Role).Scan(&id)
+	return id, err
+}
+
+func (r *UserRepo) FindOrCreateByIdentity(identity structs.Identity, userName string) (int, error) {
+	var userID int
+	err := r.db.withTx(func(tx *tx) error {
+		err := tx.QueryRow(`SELECT UserID FROM user_identities WHERE Provider = ? AND Subject = ?`,
+			identity.Provider, identity.Subject).Scan(&userID)
+		if err == nil {
+			identity.UserID = userID
+			return linkIdentity(tx, identity)
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		var verified bool
+		err = tx.QueryRow("SELECT ID, EmailVerified FROM USERS WHERE Email = ?", identity.Email).Scan(&userID, &verified)
+		if errors.Is(err, sql.ErrNoRows) {
+			userID, err = createOAuthUser(tx, identity.Email, userName)
+		}
+		if err != nil {
+			return err
+		}
+
+		// Anyone could have registered an address they don't read. The
+		// provider has verified it now, so whatever ways in were set up
+		// before are dropped rather than handed the owner's account.
+		if !verified {
+			if err := resetLogins(tx, userID); err != nil {
+				return err
+			}
+		}
+
+		identity.UserID = userID
+		return linkIdentity(tx, identity)
+	})
+	return userID, err
+}
+
+// resetLogins clears the user's password, sessions, two-factor and linked
+// identities, and marks their email verified.
+func resetLogins(tx *tx, userID int) error {
+	for _, query := range []string{
+		"UPDATE USERS SET Password = '', EmailVerified = TRUE WHERE ID = ?",
+		"DELETE FROM sessions WHERE UserID = ?",
+		"DELETE FROM recovery_codes WHERE UserID = ?",
+		"DELETE FROM two_factor WHERE UserID = ?",
+		"DELETE FROM user_identities WHERE UserID = ?",
+	} {
+		if _, err := tx.Exec(query, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createOAuthUser adds a password-less user, numbering the name if someone
+// already has it.
+func createOAuthUser(tx *tx, email, userName string) (int, error) {
+	name := userName
+	for i := 2; ; i++ {
+		var taken bool
+		if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM USERS WHERE UserName = ?)", name).Scan(&taken); err != nil {
+			return 0, err
+		}
+		if !taken {
+			break
+		}
+		name = fmt.Sprintf("%s%d", userName, i)
+	}
+
+	var id int
+	err := tx.QueryRow("INSERT INTO USERS (Email, UserName, Password, Role, CreatedAt) VALUES (?, ?, '', 'user', CURRENT_TIMESTAMP) RETURNING ID", email, name).Scan(&id)
+	return id, err
+}
+
+func (r *UserRepo) SetEmailVerified(id int) error {
+	_, err := r.db.Exec("UPDATE USERS SET EmailVerified = TRUE WHERE ID = ?", id)
+	return err
+}
+
+func (r *UserRepo) SetPassword(id int, hash string) error {
+	_, err := r.db.Exec("UPDATE USERS SET Password = ? WHERE ID = ?", hash, id)
+	return err
+}
+
+func (r *UserRepo) Lock(id int, until time.Time) error {
+	_, err := r.db.Exec("UPDATE USERS SET LockedUntil = ? WHERE ID = ?", until.UTC(), id)
+	return err
+}
+
+func (r *UserRepo) Unlock(id int) error {
+	_, err := r.db.Exec("UPDATE USERS SET LockedUntil = NULL WHERE ID = ?", id)
+	return err
+}
+
+func (r *UserRepo) SetRole(id int, role string) error {
+	_, err := r.db.Exec("UPDATE USERS SET Role = ? WHERE ID = ?", role, id)
+	return err
+}
+
+func (r *UserRepo) Search(text string, now time.Time, page store.Page) ([]structs.UserSummary, bool, error) {
+	var where []string
+	args := []any{now.UTC()}
+	if text != "" {
+		pattern := "%" + likeEscaper.Replace(strings.ToLower(text)) + "%"
+		where = append(where, `(LOWER(USERS.UserName) LIKE ? ESCAPE '\' OR LOWER(USERS.Email) LIKE ? ESCAPE '\')`)
+		args = append(args, pattern, pattern)
+	}
+	cond, arg, order := keyset("USERS.ID", true, page)
+	if cond != "" {
+		where = append(where, cond)
+		args = append(args, arg)
+	}
+
+	query := `SELECT USERS.ID, USERS.Email, USERS.UserName, USERS.Role, USERS.EmailVerified, USERS.LockedUntil, USERS.Reputation,
+		bans.ID, bans.Reason, bans.BannedBy, bans.CreatedAt, bans.ExpiresAt, bans.Shadow,
+		(SELECT COUNT(*) FROM POSTS WHERE POSTS.UserID = USERS.ID),
+		(SELECT COUNT(*) FROM COMMENTS WHERE COMMENTS.UserId = USERS.ID)
+		FROM USERS LEFT JOIN bans ON bans.UserID = USERS.ID AND bans.LiftedAt IS NULL
+			AND (bans.ExpiresAt IS NULL OR bans.ExpiresAt > ?)`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY " + order + " LIMIT ?"
+
+	rows, err := r.db.Query(query, append(args, page.Limit+1)...)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	var users []structs.UserSummary
+	for rows.Next() {
+		var user structs.UserSummary
+		var role sql.NullString
+		var lockedUntil, banCreatedAt, banExpiresAt sql.NullTime
+		var banID, bannedBy sql.NullInt64
+		var banReason sql.NullString
+		var shadow sql.NullBool
+		err := rows.Scan(&user.ID, &user.Email, &user.UserName, &role, &user.EmailVerified, &lockedUntil, &user.Reputation,
+			&banID, &banReason, &bannedBy, &banCreatedAt, &banExpiresAt, &shadow, &user.Posts, &user.Comments)
+		if err != nil {
+			return nil, false, err
+		}
+		user.Role = role.String
+		user.LockedUntil = lockedUntil.Time
+		if banID.Valid {
+			user.Banned = true
+			user.Ban = structs.Ban{
+				ID:        int(banID.Int64),
+				UserID:    user.ID,
+				Reason:    banReason.String,
+				BannedBy:  int(bannedBy.Int64),
+				CreatedAt: banCreatedAt.Time,
+				ExpiresAt: banExpiresAt.Time,
+				Shadow:    shadow.Bool,
+			}
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+
+	users, more := pageOf(users, page)
+	return users, more, nil
+}
+
+const profileColumns = "ID, UserName, DisplayName, Bio, Website, Location, Avatar, Role, Reputation, CreatedAt"
+
+func (r *UserRepo) Profile(userName string) (structs.Profile, error) {
+	return scanProfile(r.db.QueryRow("SELECT "+profileColumns+" FROM USERS WHERE UserName = ?", userName))
+}
+
+func (r *UserRepo) ProfileByID(id int) (structs.Profile, error) {
+	return scanProfile(r.db.QueryRow("SELECT "+profileColumns+" FROM USERS WHERE ID = ?", id))
+}
+
+func (r *UserRepo) UpdateProfile(profile structs.Profile) error {
+	return changedOne(r.db.Exec("UPDATE USERS SET DisplayName = ?, Bio = ?, Website = ?, Location = ?, Avatar = ? WHERE ID = ?",
+		profile.DisplayName, profile.Bio, profile.Website, profile.Location, profile.Avatar, profile.ID))
+}
+
+func scanProfile(row *sql.Row) (structs.Profile, error) {
+	var profile structs.Profile
+	var role sql.NullString
+	var createdAt sql.NullTime
+	err := row.Scan(&profile.ID, &profile.UserName, &profile.DisplayName, &profile.Bio, &profile.Website, &profile.Location,
+		&profile.Avatar, &role, &profile.Reputation, &createdAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return profile, store.ErrNotFound
+	}
+	profile.Role = role.String
+	profile.JoinedAt = createdAt.Time
+	return profile, err
+}
+
+// likeEscaper makes text match literally in a LIKE pattern with ESCAPE '\'.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// Delete removes the user and everything they created in one transaction.
+// Their posts are purged along with everything on them. Comments that
+// others replied to are emptied and left deleted instead, so the replies
+// keep their place; the purge job removes them later.
+func (r *UserRepo) Delete(id int) ([]string, error) {
+	var unused []string
+	err := r.db.withTx(func(tx *tx) error {
+		// Their votes and accepted answers stop counting for others.
+		if err := dropDerivedEvents(tx, "ActorID = ?", id); err != nil {
+			return err
+		}
+		if err := withdrawVotes(tx, id); err != nil {
+			return err
+		}
+
+		posts, err := queryIDs(tx, "SELECT ID FROM POSTS WHERE UserID = ?", id)
+		if err != nil {
+			return err
+		}
+		photos, err := queryStrings(tx, "SELECT DISTINCT PhotoPath FROM POSTS WHERE UserID = ? AND PhotoPath <> ''", id)
+		if err != nil {
+			return err
+		}
+		for _, post := range posts {
+			if err := purgePost(tx, post); err != nil {
+				return err
+			}
+		}
+		if unused, err = unusedPhotos(tx, photos); err != nil {
+			return err
+		}
+
+		statements := []string{
+			"DELETE FROM sessions WHERE UserID = ?",
+			"DELETE FROM user_identities WHERE UserID = ?",
+			"DELETE FROM recovery_codes WHERE UserID = ?",
+			"DELETE FROM two_factor WHERE UserID = ?",
+			"DELETE FROM bans WHERE UserID = ?",
+			"DELETE FROM reports WHERE ReporterID = ?",
+			"DELETE FROM reports WHERE IsComment AND ResolvedAt IS NULL AND TargetID IN (SELECT ID FROM COMMENTS WHERE UserID = ?)",
+			"DELETE FROM revisions WHERE IsComment AND TargetID IN (SELECT ID FROM COMMENTS WHERE UserID = ?)",
+			"UPDATE POSTS SET AcceptedCommentID = NULL WHERE AcceptedCommentID IN (SELECT ID FROM COMMENTS WHERE UserID = ?)",
+			"DELETE FROM reputation_events WHERE UserID = ?",
+			`UPDATE COMMENTS SET UserId = 0, UserName = '', Comment = '', CommentHTML = '', DeletedAt = COALESCE(DeletedAt, CURRENT_TIMESTAMP)
+				WHERE UserId = ? AND EXISTS (SELECT 1 FROM COMMENTS AS reply WHERE reply.ParentID = COMMENTS.ID)`,
+		}
+		for _, statement := range statements {
+			if _, err := tx.Exec(statement, id); err != nil {
+				return err
+			}
+		}
+
+		// The rest of their comments go with the votes on them.
+		comments, err := queryIDs(tx, "SELECT ID FROM COMMENTS WHERE UserId = ?", id)
+		if err != nil {
+			return err
+		}
+		for _, comment := range comments {
+			if err := purgeComment(tx, comment); err != nil {
+				return err
+			}
+		}
+		_, err = tx.Exec("DELETE FROM USERS WHERE ID = ?", id)
+		return err
+	})
+	return unused, err
+}
+
+func (r *UserRepo) scan(row *sql.Row) (structs.User, error) {
+	var user structs.User
+	var role sql.NullString
+	var lockedUntil sql.NullTime
+	err := row.Scan(&user.ID, &user.Email, &user.UserName, &user.Password, &role, &user.EmailVerified, &lockedUntil, &user.Reputation)
+	user.Role = role.String
+	user.LockedUntil = lockedUntil.Time
+	return user, err
+}
+
+func (r *UserRepo) exists(query string, args ...any) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(query, args...).Scan(&exists)
+	return exists, err
+}
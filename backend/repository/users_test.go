@@ -0,0 +1,126 @@
+package repository_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"forum/backend/controllers/structs"
+	"forum/backend/store"
+)
+
+// Deleting a user purges their posts with everything others left on them.
+func TestDeleteUserPurgesPosts(t *testing.T) {
+	st := openStore(t)
+	leaving := createUser(t, st, "leaving")
+	staying := createUser(t, st, "staying")
+
+	postID, err := st.Posts().Create(structs.Post{UserID: leaving, UserName: "leaving", Title: "Goodbye", Content: "farewellword", PhotoPath: "/uploads/bye.png"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	commentID := createComment(t, st, postID, staying, "replyword")
+	if _, err := st.Votes().Apply(staying, store.VoteTarget{ID: postID}, toggle(store.VoteUp)); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Comments().Edit(commentID, "replyword edited", "", staying, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	photos, err := st.Users().Delete(leaving)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(photos, []string{"/uploads/bye.png"}) {
+		t.Errorf("unused photos = %q, want the post's photo", photos)
+	}
+
+	if _, err := st.Posts().ByID(postID); err == nil {
+		t.Error("the post is still there")
+	}
+	comments, _, err := st.Comments().ByUser(staying, store.Page{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comments) != 0 {
+		t.Errorf("the other user still has %d comments on the deleted post", len(comments))
+	}
+	if revisions, err := st.Revisions().History(commentID, true); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("History of the purged comment = %d revisions, %v; want ErrNotFound", len(revisions), err)
+	}
+	for _, word := range []string{"farewellword", "replyword"} {
+		if posts := searchFor(t, st, word); len(posts) != 0 {
+			t.Errorf("search for %q still finds %d posts", word, len(posts))
+		}
+	}
+	if drifted, err := st.Votes().Reconcile(); err != nil || drifted != 0 {
+		t.Errorf("Reconcile = %d, %v; want no drift", drifted, err)
+	}
+}
+
+// A photo that is also someone's avatar is never handed out for removal.
+func TestPurgeKeepsAvatars(t *testing.T) {
+	st := openStore(t)
+	leaving := createUser(t, st, "leaving")
+	staying := createUser(t, st, "staying")
+	if err := st.Users().UpdateProfile(structs.Profile{ID: staying, Avatar: "/uploads/me.png"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.Posts().Create(structs.Post{UserID: leaving, UserName: "leaving", Title: "Mine now", Content: "Content", PhotoPath: "/uploads/me.png"}); err != nil {
+		t.Fatal(err)
+	}
+
+	photos, err := st.Users().Delete(leaving)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(photos) != 0 {
+		t.Errorf("unused photos = %q, want none: the file is an avatar", photos)
+	}
+}
+
+// The comments a deleted user left on others' posts go with the votes and
+// open reports on them.
+func TestDeleteUserPurgesComments(t *testing.T) {
+	st := openStore(t)
+	leaving := createUser(t, st, "leaving")
+	staying := createUser(t, st, "staying")
+	postID := createPost(t, st, staying, "Stays", "Content")
+	commentID := createComment(t, st, postID, leaving, "Goes")
+	if _, err := st.Votes().Apply(staying, store.VoteTarget{ID: commentID, IsComment: true}, toggle(store.VoteUp)); err != nil {
+		t.Fatal(err)
+	}
+	report(t, st, staying, commentID, true)
+	answered := createComment(t, st, postID, leaving, "Answered")
+	reply(t, st, postID, answered, staying, "Reply")
+	report(t, st, staying, answered, true)
+
+	if _, err := st.Users().Delete(leaving); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := st.Comments().ByID(commentID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("ByID of the comment error = %v, want ErrNotFound", err)
+	}
+	if open, err := st.Reports().OpenCount(commentID, true); err != nil || open != 0 {
+		t.Errorf("OpenCount = %d, %v; want 0", open, err)
+	}
+	// A comment with replies stays, emptied, without its reports.
+	if comment, err := st.Comments().ByID(answered); err != nil || comment.UserId != 0 || comment.Comment != "" {
+		t.Errorf("answered comment = %+v, %v; want it emptied", comment, err)
+	}
+	if open, err := st.Reports().OpenCount(answered, true); err != nil || open != 0 {
+		t.Errorf("OpenCount of the answered comment = %d, %v; want 0", open, err)
+	}
+	if queue, err := st.Reports().Queue(); err != nil || len(queue) != 0 {
+		t.Errorf("Queue = %d items, %v; want none", len(queue), err)
+	}
+	stats, err := st.Stats().Site(time.Now(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.UpVotes != 0 {
+		t.Errorf("%d upvotes left, want the vote on the comment gone", stats.UpVotes)
+	}
+}
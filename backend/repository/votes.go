@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"forum/backend/store"
+)
+
+type VoteRepo struct {
+	db *conn
+}
+
+func (r *VoteRepo) Apply(userID int, target store.VoteTarget, transition func(store.VoteState) store.VoteState) (store.VoteTally, error) {
+	var tally store.VoteTally
+
+	err := r.db.withTx(func(tx *tx) error {
+		table := "POSTS"
+		postQuery := "SELECT ID FROM POSTS WHERE ID = ? AND DeletedAt IS NULL"
+		if target.IsComment {
+			table = "COMMENTS"
+			postQuery = "SELECT PostId FROM COMMENTS WHERE ID = ? AND DeletedAt IS NULL"
+		}
+
+		// USERLIKES.DeleteID points at the post so deleting a post can drop
+		// the votes on its comments too.
+		var postID int
+		err := tx.QueryRow(postQuery, target.ID).Scan(&postID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return store.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`INSERT INTO USERLIKES (UserID, PostID, IsComment, DeleteID, Liked, Disliked) VALUES (?, ?, ?, ?, ?, ?)
+			ON CONFLICT (UserID, PostID, IsComment) DO NOTHING`,
+			userID, target.ID, target.IsComment, postID, false, false)
+		if err != nil {
+			return err
+		}
+
+		var liked, disliked bool
+		err = tx.QueryRow("SELECT Liked, Disliked FROM USERLIKES WHERE UserID = ? AND PostID = ? AND IsComment = ?"+r.db.dialect.ForUpdate(),
+			userID, target.ID, target.IsComment).Scan(&liked, &disliked)
+		if err != nil {
+			return err
+		}
+
+		current := store.VoteNone
+		if liked {
+			current = store.VoteUp
+		} else if disliked {
+			current = store.VoteDown
+		}
+		next := transition(current)
+
+		upDelta := boolToInt(next == store.VoteUp) - boolToInt(current == store.VoteUp)
+		downDelta := boolToInt(next == store.VoteDown) - boolToInt(current == store.VoteDown)
+
+		err = tx.QueryRow(`UPDATE `+table+` SET UpCount = UpCount + ?, DownCount = DownCount + ?, LikeCount = LikeCount + ?
+			WHERE ID = ? RETURNING UpCount, DownCount, LikeCount`,
+			upDelta, downDelta, upDelta-downDelta, target.ID).Scan(&tally.UpCount, &tally.DownCount, &tally.Score)
+		if err != nil {
+			return err
+		}
+
+		if next != current {
+			_, err = tx.Exec(`UPDATE USERLIKES SET Liked = ?, Disliked = ? WHERE UserID = ? AND PostID = ? AND IsComment = ?`,
+				next == store.VoteUp, next == store.VoteDown, userID, target.ID, target.IsComment)
+			if err != nil {
+				return err
+			}
+			if err := setVoteEvent(tx, userID, target, next, time.Now()); err != nil {
+				return err
+			}
+		}
+
+		tally.State = next
+		return nil
+	})
+
+	return tally, err
+}
+
+func (r *VoteRepo) Reconcile() (int, error) {
+	drifted := 0
+
+	err := r.db.withTx(func(tx *tx) error {
+		for _, item := range []struct {
+			table     string
+			isComment bool
+		}{{"POSTS", false}, {"COMMENTS", true}} {
+			upCount := `(SELECT COUNT(*) FROM USERLIKES WHERE USERLIKES.PostID = ` + item.table + `.ID AND USERLIKES.IsComment = ? AND USERLIKES.Liked = ?)`
+			downCount := `(SELECT COUNT(*) FROM USERLIKES WHERE USERLIKES.PostID = ` + item.table + `.ID AND USERLIKES.IsComment = ? AND USERLIKES.Disliked = ?)`
+
+			var count int
+			err := tx.QueryRow(`SELECT COUNT(*) FROM `+item.table+` WHERE UpCount <> `+upCount+` OR DownCount <> `+downCount+` OR LikeCount <> UpCount - DownCount`,
+				item.isComment, true, item.isComment, true).Scan(&count)
+			if err != nil {
+				return err
+			}
+			drifted += count
+
+			_, err = tx.Exec(`UPDATE `+item.table+` SET UpCount = `+upCount+`, DownCount = `+downCount,
+				item.isComment, true, item.isComment, true)
+			if err != nil {
+				return err
+			}
+			if _, err := tx.Exec(`UPDATE ` + item.table + ` SET LikeCount = UpCount - DownCount`); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	return drifted, err
+}
+
+// withdrawVotes takes the user's votes off the tallies of the posts and
+// comments they voted on and deletes them.
+func withdrawVotes(tx *tx, userID int) error {
+	for _, item := range []struct {
+		table     string
+		isComment bool
+	}{{"POSTS", false}, {"COMMENTS", true}} {
+		vote := `(SELECT COUNT(*) FROM USERLIKES WHERE USERLIKES.PostID = ` + item.table + `.ID AND USERLIKES.IsComment = ? AND USERLIKES.UserID = ? AND `
+		up, down := vote+`USERLIKES.Liked = ?)`, vote+`USERLIKES.Disliked = ?)`
+		_, err := tx.Exec(`UPDATE `+item.table+` SET UpCount = UpCount - `+up+`, DownCount = DownCount - `+down+`,
+			LikeCount = LikeCount - `+up+` + `+down+`
+			WHERE ID IN (SELECT PostID FROM USERLIKES WHERE UserID = ? AND IsComment = ?)`,
+			item.isComment, userID, true, item.isComment, userID, true,
+			item.isComment, userID, true, item.isComment, userID, true,
+			userID, item.isComment)
+		if err != nil {
+			return err
+		}
+	}
+	_, err := tx.Exec("DELETE FROM USERLIKES WHERE UserID = ?", userID)
+	return err
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,133 @@
+package repository_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"forum/backend/store"
+)
+
+func toggle(want store.VoteState) func(store.VoteState) store.VoteState {
+	return func(current store.VoteState) store.VoteState {
+		if current == want {
+			return store.VoteNone
+		}
+		return want
+	}
+}
+
+// TestParallelVotes has every voter flip their vote on the post and the
+// comment several times at once, over a pool of connections to one WAL
+// database; the tallies must end up matching the votes left in USERLIKES.
+func TestParallelVotes(t *testing.T) {
+	st := openFileStore(t)
+	author := createUser(t, st, "author")
+	postID := createPost(t, st, author, "Votes", "Vote on me")
+	commentID := createComment(t, st, postID, author, "And on me")
+
+	const voters = 100
+	var ids []int
+	for i := 0; i < voters; i++ {
+		ids = append(ids, createUser(t, st, fmt.Sprintf("voter%d", i)))
+	}
+
+	// Even voters end up voting up, odd voters down: three flips leave the
+	// vote cast, and a final flip in the other direction replaces it.
+	var wg sync.WaitGroup
+	errs := make(chan error, voters*8)
+	for i, id := range ids {
+		first, second := store.VoteUp, store.VoteDown
+		if i%2 == 1 {
+			first, second = second, first
+		}
+		for _, target := range []store.VoteTarget{{ID: postID}, {ID: commentID, IsComment: true}} {
+			wg.Add(1)
+			go func(id int, target store.VoteTarget) {
+				defer wg.Done()
+				for _, state := range []store.VoteState{second, second, second, first} {
+					if _, err := st.Votes().Apply(id, target, toggle(state)); err != nil {
+						errs <- err
+					}
+				}
+			}(id, target)
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	post, err := st.Posts().ByID(postID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if post.UpCount != voters/2 || post.DownCount != voters/2 || post.LikeCount != 0 {
+		t.Errorf("post tally = %d up, %d down, score %d; want %d, %d, 0", post.UpCount, post.DownCount, post.LikeCount, voters/2, voters/2)
+	}
+	comments, _, err := st.Comments().ByPost(postID, store.Audience{}, store.SortOldest, store.Page{Limit: 10})
+	if err != nil || len(comments) != 1 {
+		t.Fatalf("ByPost = %d comments, %v; want 1", len(comments), err)
+	}
+	if comment := comments[0]; comment.UpCount != voters/2 || comment.DownCount != voters/2 || comment.LikeCount != 0 {
+		t.Errorf("comment tally = %d up, %d down, score %d; want %d, %d, 0", comment.UpCount, comment.DownCount, comment.LikeCount, voters/2, voters/2)
+	}
+
+	drifted, err := st.Votes().Reconcile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if drifted != 0 {
+		t.Errorf("Reconcile found %d drifted tallies, want 0", drifted)
+	}
+}
+
+// Deleting a user takes their votes off the tallies.
+func TestDeleteUserWithdrawsVotes(t *testing.T) {
+	st := openStore(t)
+	author := createUser(t, st, "author")
+	voter := createUser(t, st, "voter")
+	postID := createPost(t, st, author, "Votes", "Vote on me")
+	commentID := createComment(t, st, postID, author, "And on me")
+
+	if _, err := st.Votes().Apply(voter, store.VoteTarget{ID: postID}, toggle(store.VoteUp)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.Votes().Apply(voter, store.VoteTarget{ID: commentID, IsComment: true}, toggle(store.VoteDown)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.Users().Delete(voter); err != nil {
+		t.Fatal(err)
+	}
+
+	post, err := st.Posts().ByID(postID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if post.UpCount != 0 || post.DownCount != 0 || post.LikeCount != 0 {
+		t.Errorf("post tally = %d up, %d down, score %d; want all 0", post.UpCount, post.DownCount, post.LikeCount)
+	}
+	comment, err := st.Comments().ByID(commentID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if comment.UpCount != 0 || comment.DownCount != 0 || comment.LikeCount != 0 {
+		t.Errorf("comment tally = %d up, %d down, score %d; want all 0", comment.UpCount, comment.DownCount, comment.LikeCount)
+	}
+	user, err := st.Users().ByID(author)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Reputation != 0 {
+		t.Errorf("author reputation = %d, want 0", user.Reputation)
+	}
+
+	drifted, err := st.Votes().Reconcile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if drifted != 0 {
+		t.Errorf("Reconcile found %d drifted tallies, want 0", drifted)
+	}
+}
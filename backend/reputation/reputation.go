@@ -0,0 +1,57 @@
+// Package reputation has the points users earn for their contributions and
+// the privileges those points unlock.
+package reputation
+
+import (
+	"forum/backend/config"
+	"forum/backend/rbac"
+)
+
+// Why reputation changed, as recorded in the ledger.
+const (
+	ReasonUpvote   = "upvote"
+	ReasonDownvote = "downvote"
+	ReasonAccepted = "accepted"
+	// ReasonRemoved is a moderator removing reported content.
+	ReasonRemoved = "removed"
+	// ReasonAdjusted is a moderator changing a user's reputation by hand.
+	ReasonAdjusted = "adjusted"
+)
+
+// Derived are the reasons whose events follow from votes and accepted
+// answers, and are rebuilt by a recompute. The others only moderators
+// record.
+var Derived = []string{ReasonUpvote, ReasonDownvote, ReasonAccepted}
+
+const (
+	// Upvote and Downvote are what a vote on a post or comment earns or
+	// costs its author.
+	Upvote   = 10
+	Downvote = -2
+	// AcceptedAnswer is what an answer earns its author when the asker
+	// accepts it.
+	AcceptedAnswer = 15
+	// Removed is what it costs when a moderator removes reported content.
+	Removed = -20
+)
+
+// Threshold is the reputation at which users earn the permission beyond
+// their role, if reputation can earn it at all.
+func Threshold(p rbac.Permission) (int, bool) {
+	cfg := config.Get()
+	switch p {
+	case rbac.VoteDown:
+		return cfg.ReputationDownvote, true
+	case rbac.PostEditAny:
+		return cfg.ReputationEditPosts, true
+	case rbac.TagCreate:
+		return cfg.ReputationCreateTag, true
+	}
+	return 0, false
+}
+
+// Unlocks reports whether the reputation earns the permission.
+func Unlocks(points int, p rbac.Permission) bool {
+	threshold, ok := Threshold(p)
+	return ok && points >= threshold
+}
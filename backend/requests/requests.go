@@ -12,83 +12,125 @@ import (
 
 	"forum/backend/auth"
 	"forum/backend/controllers/structs"
-	"forum/backend/database"
+	"forum/backend/csrf"
+	"forum/backend/store"
+	"forum/backend/tokens"
 )
 
-func GetDataForServe(apiURL string) ([]structs.Post, error) {
+func GetDataForServe(apiURL string, cursor string) (structs.PostList, error) {
+	resp, err := http.Get(withCursor(apiURL, cursor))
+	if err != nil {
+		return structs.PostList{}, err
+	}
+	defer resp.Body.Close()
+
+	var posts structs.PostList
+	if err := json.NewDecoder(resp.Body).Decode(&posts); err != nil {
+		return structs.PostList{}, err
+	}
+
+	return posts, nil
+}
+
+// withCursor adds the page cursor, if any, to a listing URL.
+func withCursor(apiURL string, cursor string) string {
+	if cursor == "" {
+		return apiURL
+	}
+	separator := "?"
+	if strings.Contains(apiURL, "?") {
+		separator = "&"
+	}
+	return apiURL + separator + "cursor=" + url.QueryEscape(cursor)
+}
+
+// withSession makes req on behalf of the browser's session: it passes the
+// session cookie on, along with the CSRF token the API expects for it.
+func withSession(req *http.Request, cookieValue string) {
+	req.AddCookie(&http.Cookie{Name: auth.SessionCookieName, Value: cookieValue})
+	req.Header.Set(csrf.HeaderName, csrf.Token(cookieValue))
+}
+
+func GetTags(apiURL string) ([]structs.Tag, error) {
 	resp, err := http.Get(apiURL)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	var posts []structs.Post
-	if err := json.NewDecoder(resp.Body).Decode(&posts); err != nil {
+	var tags []structs.Tag
+	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
 		return nil, err
 	}
 
-	return posts, nil
+	return tags, nil
 }
 
-func GetDataForServeWithReq(apiURL string, cookieValue string) ([]structs.Post, error) {
-	req, err := http.NewRequest("GET", apiURL, nil)
+func GetDataForServeWithReq(apiURL string, cookieValue string, cursor string) (structs.PostList, error) {
+	req, err := http.NewRequest("GET", withCursor(apiURL, cursor), nil)
 	if err != nil {
 		fmt.Println("Error creating request:", err)
-		return nil, err
+		return structs.PostList{}, err
 	}
 
-	req.AddCookie(&http.Cookie{Name: "session_token", Value: cookieValue})
+	withSession(req, cookieValue)
 
 	client := &http.Client{}
 
 	resp, err := client.Do(req)
 	if err != nil {
 		fmt.Println("Error sending request:", err)
-		return nil, nil
+		return structs.PostList{}, err
 	}
 	defer resp.Body.Close()
 
-	var posts []structs.Post
+	var posts structs.PostList
 	if err := json.NewDecoder(resp.Body).Decode(&posts); err != nil {
-		return nil, err
+		return structs.PostList{}, err
 	}
 
 	return posts, nil
 }
 
-func GetCommentDataForServeWithReq(apiURL string, cookieValue string) ([]structs.Comment, error) {
-	req, err := http.NewRequest("GET", apiURL, nil)
+func GetCommentDataForServeWithReq(apiURL string, cookieValue string, cursor string) (structs.CommentList, error) {
+	req, err := http.NewRequest("GET", withCursor(apiURL, cursor), nil)
 	if err != nil {
 		fmt.Println("Error creating request:", err)
-		return nil, err
+		return structs.CommentList{}, err
 	}
 
-	req.AddCookie(&http.Cookie{Name: "session_token", Value: cookieValue})
+	withSession(req, cookieValue)
 
 	client := &http.Client{}
 
 	resp, err := client.Do(req)
 	if err != nil {
 		fmt.Println("Error sending request:", err)
-		return nil, nil
+		return structs.CommentList{}, err
 	}
 	defer resp.Body.Close()
 
-	var posts []structs.Comment
+	var posts structs.CommentList
 	if err := json.NewDecoder(resp.Body).Decode(&posts); err != nil {
-		return nil, err
+		return structs.CommentList{}, err
 	}
 
 	return posts, nil
 }
 
-func GetPostWithComments(apiURL string, postId string) (structs.PostWithComments, error) {
-	req, err := http.NewRequest("GET", apiURL+"?id="+postId, nil)
+// GetPostWithComments passes the session on if there is one, so users see
+// what they posted while shadow banned.
+func GetPostWithComments(apiURL string, postId string, sort string, cookieValue string, cursor string) (structs.PostWithComments, error) {
+	req, err := http.NewRequest("GET", withCursor(apiURL+"?id="+url.QueryEscape(postId)+"&sort="+url.QueryEscape(sort), cursor), nil)
 	if err != nil {
 		fmt.Println("Error creating request:", err)
 		return structs.PostWithComments{}, err
 	}
 
+	if cookieValue != "" {
+		withSession(req, cookieValue)
+	}
+
 	client := &http.Client{}
 
 	resp, err := client.Do(req)
@@ -110,11 +152,19 @@ func GetPostWithComments(apiURL string, postId string) (structs.PostWithComments
 	return data, nil
 }
 
-func GetSearchedDataForServeWithReq(apiURL string, filter string, category string, search string) ([]structs.Post, error) {
-	req, err := http.NewRequest("GET", apiURL+"?filter="+filter+"&category="+category+"&search="+search, nil)
+func GetSearchedDataForServeWithReq(apiURL string, filter string, category string, search string, cursor string) (structs.PostList, error) {
+	params := url.Values{}
+	params.Set("filter", filter)
+	params.Set("category", category)
+	params.Set("search", search)
+	if cursor != "" {
+		params.Set("cursor", cursor)
+	}
+
+	req, err := http.NewRequest("GET", apiURL+"?"+params.Encode(), nil)
 	if err != nil {
 		fmt.Println("Error creating request:", err)
-		return nil, err
+		return structs.PostList{}, err
 	}
 
 	client := &http.Client{}
@@ -122,19 +172,19 @@ func GetSearchedDataForServeWithReq(apiURL string, filter string, category strin
 	resp, err := client.Do(req)
 	if err != nil {
 		fmt.Println("Error sending request:", err)
-		return nil, nil
+		return structs.PostList{}, err
 	}
 	defer resp.Body.Close()
 
-	var posts []structs.Post
+	var posts structs.PostList
 	if err := json.NewDecoder(resp.Body).Decode(&posts); err != nil {
-		return nil, err
+		return structs.PostList{}, err
 	}
 
 	return posts, nil
 }
 
-func RegisterRequest(apiURL string, email string, userName string, password string) error {
+func RegisterRequest(apiURL string, email string, userName string, password string, r *http.Request) error {
 	formData := url.Values{}
 	formData.Set("email", email)
 	formData.Set("username", userName)
@@ -147,6 +197,7 @@ func RegisterRequest(apiURL string, email string, userName string, password stri
 		return err
 	}
 
+	auth.Forward(req, r)
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
 	client := &http.Client{}
@@ -165,7 +216,7 @@ func RegisterRequest(apiURL string, email string, userName string, password stri
 	return nil
 }
 
-func LoginRequest(apiURL string, email string, password string, w http.ResponseWriter) error {
+func LoginRequest(apiURL string, email string, password string, w http.ResponseWriter, r *http.Request) (structs.LoginResult, error) {
 	formData := url.Values{}
 	formData.Set("email", email)
 	formData.Set("password", password)
@@ -174,56 +225,83 @@ func LoginRequest(apiURL string, email string, password string, w http.ResponseW
 
 	req, err := http.NewRequest("POST", apiURL, strings.NewReader(encodedFormData))
 	if err != nil {
-		return err
+		return structs.LoginResult{}, err
 	}
 
+	auth.Forward(req, r)
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
 	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
-		return err
+		return structs.LoginResult{}, err
 	}
 	defer resp.Body.Close()
 
+	bodyBytes, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode == http.StatusAccepted {
+		return structs.LoginResult{Challenge: string(bodyBytes)}, nil
+	}
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		bodyString := string(bodyBytes)
-		return fmt.Errorf(bodyString)
+		return structs.LoginResult{}, fmt.Errorf("%s", bodyBytes)
+	}
+
+	repos := store.Get()
+
+	user, errQue := repos.Users().ByEmail(email)
+	if errQue != nil {
+		return structs.LoginResult{}, fmt.Errorf("ERROR: Invalid email")
 	}
 
-	db, errDb := database.OpenDb(w)
-	if errDb != nil {
-		http.Error(w, "ERROR: Database cannot open", http.StatusBadRequest)
-		return errDb
+	errSession := auth.StartSession(w, r, repos.Sessions(), user.ID)
+	if errSession != nil {
+		return structs.LoginResult{}, fmt.Errorf("ERROR: Internal Server Error")
 	}
-	defer db.Close()
 
-	sessionToken, errToken := auth.CreateSessionToken()
-	if errToken != nil {
-		http.Error(w, "ERROR: Internal Server Error", http.StatusInternalServerError)
-		return errToken
+	return structs.LoginResult{SetupTwoFactor: !auth.TwoFactorSatisfied(repos.TwoFactor(), user)}, nil
+}
+
+// LoginTwoFactorRequest finishes a login that LoginRequest answered with a
+// challenge.
+func LoginTwoFactorRequest(apiURL string, challenge string, code string, w http.ResponseWriter, r *http.Request) error {
+	formData := url.Values{}
+	formData.Set("challenge", challenge)
+	formData.Set("code", code)
+
+	req, err := http.NewRequest("POST", apiURL, strings.NewReader(formData.Encode()))
+	if err != nil {
+		return err
 	}
 
-	var userID int
-	errQue := db.QueryRow("SELECT ID FROM USERS WHERE Email = ?", email).Scan(&userID)
-	if errQue != nil {
-		http.Error(w, "ERROR: Invalid email", http.StatusBadRequest)
+	auth.Forward(req, r)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
 		return err
 	}
+	defer resp.Body.Close()
 
-	errSetToken := auth.SetTokenInDatabase(w, db, sessionToken, userID)
-	if errSetToken != nil {
-		http.Error(w, "ERROR: Internal Server Error", http.StatusInternalServerError)
-		return errSetToken
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s", bodyBytes)
 	}
 
-	auth.SetCookie(w, sessionToken)
+	userId, err := tokens.UserID(challenge)
+	if err != nil {
+		return err
+	}
+
+	errSession := auth.StartSession(w, r, store.Get().Sessions(), userId)
+	if errSession != nil {
+		return fmt.Errorf("ERROR: Internal Server Error")
+	}
 
 	return nil
 }
 
-func CreatePostRequest(apiURL string, title string, content string, categoryDatas map[string]string, cookieValue string, photo io.Reader, photoFileName string) error {
+func CreatePostRequest(apiURL string, title string, content string, question bool, tags []string, cookieValue string, photo io.Reader, photoFileName string) error {
 	// Multipart form oluşturma
 	var body bytes.Buffer
 	writer := multipart.NewWriter(&body)
@@ -231,6 +309,9 @@ func CreatePostRequest(apiURL string, title string, content string, categoryData
 	// Title ve content alanlarını ekleme
 	writer.WriteField("title", title)
 	writer.WriteField("content", content)
+	if question {
+		writer.WriteField("question", "true")
+	}
 
 	// Fotoğraf dosyasını ekleme
 	if photo != nil {
@@ -244,9 +325,9 @@ func CreatePostRequest(apiURL string, title string, content string, categoryData
 		}
 	}
 
-	// Kategorileri ekleme
-	for key, val := range categoryDatas {
-		writer.WriteField(key, val)
+	// Etiketleri ekleme
+	for _, tag := range tags {
+		writer.WriteField("tags", tag)
 	}
 
 	// Writer'ı kapatma
@@ -262,7 +343,7 @@ func CreatePostRequest(apiURL string, title string, content string, categoryData
 	}
 
 	// Cookie ekleme
-	req.AddCookie(&http.Cookie{Name: "session_token", Value: cookieValue})
+	withSession(req, cookieValue)
 
 	// Content-Type header'ını ayarlama
 	req.Header.Set("Content-Type", writer.FormDataContentType())
@@ -284,9 +365,10 @@ func CreatePostRequest(apiURL string, title string, content string, categoryData
 	return nil
 }
 
-func CreateCommentRequest(apiURL string, postId string, comment string, cookieValue string) error {
+func CreateCommentRequest(apiURL string, postId string, parentId string, comment string, cookieValue string) error {
 	formData := url.Values{}
 	formData.Set("id", postId)
+	formData.Set("parent", parentId)
 	formData.Set("comment", comment)
 
 	encodedFormData := formData.Encode()
@@ -296,7 +378,7 @@ func CreateCommentRequest(apiURL string, postId string, comment string, cookieVa
 		return err
 	}
 
-	req.AddCookie(&http.Cookie{Name: "session_token", Value: cookieValue})
+	withSession(req, cookieValue)
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
@@ -329,7 +411,7 @@ func VoteRequest(apiURL string, id string, isComment string, postId string, cook
 		return err
 	}
 
-	req.AddCookie(&http.Cookie{Name: "session_token", Value: cookieValue})
+	withSession(req, cookieValue)
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
@@ -349,9 +431,10 @@ func VoteRequest(apiURL string, id string, isComment string, postId string, cook
 	return nil
 }
 
-func DeleteAccountRequest(apiURL string, password string, cookieValue string) error {
+func DeleteAccountRequest(apiURL string, password string, confirm string, cookieValue string) error {
 	formData := url.Values{}
 	formData.Set("password", password)
+	formData.Set("confirm", confirm)
 
 	encodedFormData := formData.Encode()
 
@@ -360,7 +443,7 @@ func DeleteAccountRequest(apiURL string, password string, cookieValue string) er
 		return err
 	}
 
-	req.AddCookie(&http.Cookie{Name: "session_token", Value: cookieValue})
+	withSession(req, cookieValue)
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
@@ -391,7 +474,7 @@ func DeletePostRequest(apiURL string, postId string, cookieValue string) error {
 		return err
 	}
 
-	req.AddCookie(&http.Cookie{Name: "session_token", Value: cookieValue})
+	withSession(req, cookieValue)
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
@@ -422,7 +505,7 @@ func DeleteCommentRequest(apiURL string, commentId string, cookieValue string) e
 		return err
 	}
 
-	req.AddCookie(&http.Cookie{Name: "session_token", Value: cookieValue})
+	withSession(req, cookieValue)
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
@@ -441,3 +524,571 @@ func DeleteCommentRequest(apiURL string, commentId string, cookieValue string) e
 
 	return nil
 }
+
+func CreateTagRequest(apiURL string, tag structs.Tag, cookieValue string) error {
+	formData := url.Values{}
+	formData.Set("slug", tag.Slug)
+	formData.Set("name", tag.Name)
+	formData.Set("description", tag.Description)
+	formData.Set("color", tag.Color)
+
+	encodedFormData := formData.Encode()
+
+	req, err := http.NewRequest("POST", apiURL, strings.NewReader(encodedFormData))
+	if err != nil {
+		return err
+	}
+
+	withSession(req, cookieValue)
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		bodyString := string(bodyBytes)
+		return fmt.Errorf(bodyString)
+	}
+
+	return nil
+}
+
+func GetSessionsRequest(apiURL string, cookieValue string) ([]structs.Session, error) {
+	req, err := http.NewRequest("GET", apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	withSession(req, cookieValue)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("%s", bodyBytes)
+	}
+
+	var sessions []structs.Session
+	if err := json.NewDecoder(resp.Body).Decode(&sessions); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+func DeleteSessionRequest(apiURL string, sessionId string, cookieValue string) error {
+	formData := url.Values{}
+	formData.Set("id", sessionId)
+	return postFormWithCookie(apiURL, formData, cookieValue)
+}
+
+func LogoutEverywhereRequest(apiURL string, cookieValue string) error {
+	return postFormWithCookie(apiURL, url.Values{}, cookieValue)
+}
+
+func GetLoginMethodsRequest(apiURL string, cookieValue string) (structs.LoginMethods, error) {
+	req, err := http.NewRequest("GET", apiURL, nil)
+	if err != nil {
+		return structs.LoginMethods{}, err
+	}
+
+	withSession(req, cookieValue)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return structs.LoginMethods{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return structs.LoginMethods{}, fmt.Errorf("%s", bodyBytes)
+	}
+
+	var methods structs.LoginMethods
+	if err := json.NewDecoder(resp.Body).Decode(&methods); err != nil {
+		return structs.LoginMethods{}, err
+	}
+
+	return methods, nil
+}
+
+func DeleteIdentityRequest(apiURL string, identityId string, cookieValue string) error {
+	formData := url.Values{}
+	formData.Set("id", identityId)
+	return postFormWithCookie(apiURL, formData, cookieValue)
+}
+
+// UpdatePasswordRequest forwards the browser's address, as the API throttles
+// wrong current passwords like failed logins.
+func UpdatePasswordRequest(apiURL string, currentPassword string, password string, cookieValue string, r *http.Request) error {
+	formData := url.Values{}
+	formData.Set("currentpassword", currentPassword)
+	formData.Set("password", password)
+
+	req, err := http.NewRequest("POST", apiURL, strings.NewReader(formData.Encode()))
+	if err != nil {
+		return err
+	}
+
+	withSession(req, cookieValue)
+	auth.Forward(req, r)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s", bodyBytes)
+	}
+
+	return nil
+}
+
+func VerifyEmailRequest(apiURL string, token string) error {
+	formData := url.Values{}
+	formData.Set("token", token)
+	return postFormWithCookie(apiURL, formData, "")
+}
+
+func UnlockAccountRequest(apiURL string, token string) error {
+	formData := url.Values{}
+	formData.Set("token", token)
+	return postFormWithCookie(apiURL, formData, "")
+}
+
+func SendVerificationRequest(apiURL string, cookieValue string) error {
+	return postFormWithCookie(apiURL, url.Values{}, cookieValue)
+}
+
+// RequestPasswordResetRequest forwards the browser's address, so that the
+// API throttles each client on its own rather than the whole site at once.
+func RequestPasswordResetRequest(apiURL string, email string, r *http.Request) error {
+	formData := url.Values{}
+	formData.Set("email", email)
+
+	req, err := http.NewRequest("POST", apiURL, strings.NewReader(formData.Encode()))
+	if err != nil {
+		return err
+	}
+
+	auth.Forward(req, r)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s", bodyBytes)
+	}
+
+	return nil
+}
+
+func ResetPasswordRequest(apiURL string, token string, password string) error {
+	formData := url.Values{}
+	formData.Set("token", token)
+	formData.Set("password", password)
+	return postFormWithCookie(apiURL, formData, "")
+}
+
+func GetTwoFactorRequest(apiURL string, cookieValue string) (structs.TwoFactorStatus, error) {
+	req, err := http.NewRequest("GET", apiURL, nil)
+	if err != nil {
+		return structs.TwoFactorStatus{}, err
+	}
+
+	withSession(req, cookieValue)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return structs.TwoFactorStatus{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return structs.TwoFactorStatus{}, fmt.Errorf("%s", bodyBytes)
+	}
+
+	var status structs.TwoFactorStatus
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return structs.TwoFactorStatus{}, err
+	}
+
+	return status, nil
+}
+
+func SetupTwoFactorRequest(apiURL string, cookieValue string) (structs.TwoFactorSetup, error) {
+	var setup structs.TwoFactorSetup
+	err := postFormForJSON(apiURL, url.Values{}, cookieValue, &setup)
+	return setup, err
+}
+
+func EnableTwoFactorRequest(apiURL string, code string, cookieValue string) ([]string, error) {
+	formData := url.Values{}
+	formData.Set("code", code)
+	var recoveryCodes []string
+	err := postFormForJSON(apiURL, formData, cookieValue, &recoveryCodes)
+	return recoveryCodes, err
+}
+
+func DisableTwoFactorRequest(apiURL string, code string, cookieValue string) error {
+	formData := url.Values{}
+	formData.Set("code", code)
+	return postFormWithCookie(apiURL, formData, cookieValue)
+}
+
+func RegenerateRecoveryCodesRequest(apiURL string, code string, cookieValue string) ([]string, error) {
+	formData := url.Values{}
+	formData.Set("code", code)
+	var recoveryCodes []string
+	err := postFormForJSON(apiURL, formData, cookieValue, &recoveryCodes)
+	return recoveryCodes, err
+}
+
+func UnlockUserRequest(apiURL string, userId string, cookieValue string) error {
+	formData := url.Values{}
+	formData.Set("id", userId)
+	return postFormWithCookie(apiURL, formData, cookieValue)
+}
+
+func GetAdminUsersRequest(apiURL string, query string, cookieValue string, cursor string) (structs.UserList, error) {
+	var users structs.UserList
+	err := getJSON(withCursor(apiURL+"?q="+url.QueryEscape(query), cursor), cookieValue, &users)
+	return users, err
+}
+
+func SetRoleRequest(apiURL string, userId string, role string, cookieValue string) error {
+	formData := url.Values{}
+	formData.Set("id", userId)
+	formData.Set("role", role)
+	return postFormWithCookie(apiURL, formData, cookieValue)
+}
+
+func BanUserRequest(apiURL string, userId string, reason string, duration string, shadow string, cookieValue string) error {
+	formData := url.Values{}
+	formData.Set("id", userId)
+	formData.Set("reason", reason)
+	formData.Set("duration", duration)
+	formData.Set("shadow", shadow)
+	return postFormWithCookie(apiURL, formData, cookieValue)
+}
+
+func UnbanUserRequest(apiURL string, userId string, cookieValue string) error {
+	formData := url.Values{}
+	formData.Set("id", userId)
+	return postFormWithCookie(apiURL, formData, cookieValue)
+}
+
+func RevokeSessionsRequest(apiURL string, userId string, cookieValue string) error {
+	formData := url.Values{}
+	formData.Set("id", userId)
+	return postFormWithCookie(apiURL, formData, cookieValue)
+}
+
+func GetAdminPostsRequest(apiURL string, cookieValue string, cursor string) (structs.PostList, error) {
+	var posts structs.PostList
+	err := getJSON(withCursor(apiURL, cursor), cookieValue, &posts)
+	return posts, err
+}
+
+func GetAdminCommentsRequest(apiURL string, cookieValue string, cursor string) (structs.CommentList, error) {
+	var comments structs.CommentList
+	err := getJSON(withCursor(apiURL, cursor), cookieValue, &comments)
+	return comments, err
+}
+
+// DeleteManyRequest deletes the posts or comments with the ids.
+func DeleteManyRequest(apiURL string, ids []string, cookieValue string) error {
+	return postFormWithCookie(apiURL, url.Values{"id": ids}, cookieValue)
+}
+
+func UpdateTagRequest(apiURL string, tag structs.Tag, cookieValue string) error {
+	formData := url.Values{}
+	formData.Set("id", fmt.Sprint(tag.ID))
+	formData.Set("name", tag.Name)
+	formData.Set("description", tag.Description)
+	formData.Set("color", tag.Color)
+	return postFormWithCookie(apiURL, formData, cookieValue)
+}
+
+func DeleteTagRequest(apiURL string, tagId string, cookieValue string) error {
+	formData := url.Values{}
+	formData.Set("id", tagId)
+	return postFormWithCookie(apiURL, formData, cookieValue)
+}
+
+func GetStatsRequest(apiURL string, cookieValue string) (structs.SiteStats, error) {
+	var stats structs.SiteStats
+	err := getJSON(apiURL, cookieValue, &stats)
+	return stats, err
+}
+
+func ReportRequest(apiURL string, id string, isComment string, reason string, details string, cookieValue string) error {
+	formData := url.Values{}
+	formData.Set("id", id)
+	formData.Set("isComment", isComment)
+	formData.Set("reason", reason)
+	formData.Set("details", details)
+	return postFormWithCookie(apiURL, formData, cookieValue)
+}
+
+func GetReportsRequest(apiURL string, cookieValue string) ([]structs.ReportedItem, error) {
+	var items []structs.ReportedItem
+	err := getJSON(apiURL, cookieValue, &items)
+	return items, err
+}
+
+func ResolveReportRequest(apiURL string, id string, isComment string, action string, note string, cookieValue string) error {
+	formData := url.Values{}
+	formData.Set("id", id)
+	formData.Set("isComment", isComment)
+	formData.Set("action", action)
+	formData.Set("note", note)
+	return postFormWithCookie(apiURL, formData, cookieValue)
+}
+
+func GetUserBansRequest(apiURL string, userId string, cookieValue string) ([]structs.Ban, error) {
+	var bans []structs.Ban
+	err := getJSON(apiURL+"?id="+url.QueryEscape(userId), cookieValue, &bans)
+	return bans, err
+}
+
+func GetAddressBansRequest(apiURL string, cookieValue string) ([]structs.AddressBan, error) {
+	var bans []structs.AddressBan
+	err := getJSON(apiURL, cookieValue, &bans)
+	return bans, err
+}
+
+func BanAddressRequest(apiURL string, kind string, value string, reason string, cookieValue string) error {
+	formData := url.Values{}
+	formData.Set("kind", kind)
+	formData.Set("value", value)
+	formData.Set("reason", reason)
+	return postFormWithCookie(apiURL, formData, cookieValue)
+}
+
+func DeleteAddressBanRequest(apiURL string, banId string, cookieValue string) error {
+	formData := url.Values{}
+	formData.Set("id", banId)
+	return postFormWithCookie(apiURL, formData, cookieValue)
+}
+
+func EditPostRequest(apiURL string, postId string, title string, content string, tags []string, cookieValue string) error {
+	formData := url.Values{}
+	formData.Set("id", postId)
+	formData.Set("title", title)
+	formData.Set("content", content)
+	formData["tags"] = tags
+	return postFormWithCookie(apiURL, formData, cookieValue)
+}
+
+func EditCommentRequest(apiURL string, commentId string, comment string, cookieValue string) error {
+	formData := url.Values{}
+	formData.Set("id", commentId)
+	formData.Set("comment", comment)
+	return postFormWithCookie(apiURL, formData, cookieValue)
+}
+
+func GetRevisionsRequest(apiURL string, id string, isComment string, cookieValue string) (structs.RevisionHistory, error) {
+	var history structs.RevisionHistory
+	err := getJSON(apiURL+"?id="+url.QueryEscape(id)+"&comment="+url.QueryEscape(isComment), cookieValue, &history)
+	return history, err
+}
+
+func GetRepliesRequest(apiURL string, commentId string, sort string, cookieValue string) (structs.Comment, error) {
+	var thread structs.Comment
+	err := getJSON(apiURL+"?id="+url.QueryEscape(commentId)+"&sort="+url.QueryEscape(sort), cookieValue, &thread)
+	return thread, err
+}
+
+func GetTrashRequest(apiURL string, cookieValue string) (structs.Trash, error) {
+	var trash structs.Trash
+	err := getJSON(apiURL, cookieValue, &trash)
+	return trash, err
+}
+
+func RestoreRequest(apiURL string, id string, isComment string, cookieValue string) error {
+	formData := url.Values{}
+	formData.Set("id", id)
+	formData.Set("isComment", isComment)
+	return postFormWithCookie(apiURL, formData, cookieValue)
+}
+
+func RestoreManyRequest(apiURL string, ids []string, cookieValue string) error {
+	return postFormWithCookie(apiURL, url.Values{"id": ids}, cookieValue)
+}
+
+func PreviewRequest(apiURL string, text string, cookieValue string) (string, error) {
+	formData := url.Values{}
+	formData.Set("text", text)
+	var preview structs.Preview
+	err := postFormForJSON(apiURL, formData, cookieValue, &preview)
+	return preview.HTML, err
+}
+
+func AcceptAnswerRequest(apiURL string, commentId string, undo string, cookieValue string) error {
+	formData := url.Values{}
+	formData.Set("id", commentId)
+	formData.Set("undo", undo)
+	return postFormWithCookie(apiURL, formData, cookieValue)
+}
+
+func GetReputationRequest(apiURL string, userId string, cookieValue string, cursor string) (structs.ReputationHistory, error) {
+	if userId != "" {
+		apiURL += "?id=" + url.QueryEscape(userId)
+	}
+	var history structs.ReputationHistory
+	err := getJSON(withCursor(apiURL, cursor), cookieValue, &history)
+	return history, err
+}
+
+func AdjustReputationRequest(apiURL string, userId string, points string, note string, cookieValue string) error {
+	formData := url.Values{}
+	formData.Set("id", userId)
+	formData.Set("points", points)
+	formData.Set("note", note)
+	return postFormWithCookie(apiURL, formData, cookieValue)
+}
+
+// GetProfileRequest fetches the profile of the user with the name, or of
+// the session's user if it is empty.
+func GetProfileRequest(apiURL string, userName string, cookieValue string) (structs.UserProfile, error) {
+	if userName != "" {
+		apiURL += "?username=" + url.QueryEscape(userName)
+	}
+	var profile structs.UserProfile
+	err := getJSON(apiURL, cookieValue, &profile)
+	return profile, err
+}
+
+func UpdateProfileRequest(apiURL string, profile structs.Profile, removeAvatar bool, cookieValue string, avatar io.Reader, avatarFileName string) error {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	writer.WriteField("displayname", profile.DisplayName)
+	writer.WriteField("bio", profile.Bio)
+	writer.WriteField("website", profile.Website)
+	writer.WriteField("location", profile.Location)
+	if removeAvatar {
+		writer.WriteField("removeavatar", "true")
+	}
+	if avatar != nil {
+		part, err := writer.CreateFormFile("avatar", avatarFileName)
+		if err != nil {
+			return fmt.Errorf("error creating form file: %v", err)
+		}
+		if _, err := io.Copy(part, avatar); err != nil {
+			return fmt.Errorf("error copying avatar file: %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("error closing writer: %v", err)
+	}
+
+	req, err := http.NewRequest("POST", apiURL, &body)
+	if err != nil {
+		return err
+	}
+	withSession(req, cookieValue)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s", bodyBytes)
+	}
+	return nil
+}
+
+// getJSON fetches apiURL on behalf of the session and decodes the JSON
+// answer into v.
+func getJSON(apiURL string, cookieValue string, v any) error {
+	req, err := http.NewRequest("GET", apiURL, nil)
+	if err != nil {
+		return err
+	}
+
+	withSession(req, cookieValue)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s", bodyBytes)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// postFormWithCookie posts formData to the API, on behalf of the session if
+// cookieValue isn't empty.
+func postFormWithCookie(apiURL string, formData url.Values, cookieValue string) error {
+	return postFormForJSON(apiURL, formData, cookieValue, nil)
+}
+
+// postFormForJSON is postFormWithCookie for endpoints that answer with JSON,
+// which is decoded into v.
+func postFormForJSON(apiURL string, formData url.Values, cookieValue string, v any) error {
+	req, err := http.NewRequest("POST", apiURL, strings.NewReader(formData.Encode()))
+	if err != nil {
+		return err
+	}
+
+	if cookieValue != "" {
+		withSession(req, cookieValue)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s", bodyBytes)
+	}
+
+	if v != nil {
+		return json.NewDecoder(resp.Body).Decode(v)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+// Package search turns the text typed into the search box into a
+// store.SearchQuery. Besides plain words it understands "quoted phrases",
+// prefix* matches and the author:, tag:, before: and after: filters.
+package search
+
+import (
+	"html"
+	"strings"
+	"time"
+	"unicode"
+
+	"forum/backend/store"
+)
+
+const dateLayout = "2006-01-02"
+
+// Parse splits the input into free-text terms and filters. Unknown or
+// malformed filters are searched for as ordinary words.
+func Parse(input string) store.SearchQuery {
+	var query store.SearchQuery
+
+	for _, token := range tokenize(input) {
+		if token.quoted {
+			if hasWord(token.text) {
+				query.Terms = append(query.Terms, store.SearchTerm{Text: token.text, Phrase: true})
+			}
+			continue
+		}
+
+		key, value, found := strings.Cut(token.text, ":")
+		if found && value != "" && applyFilter(&query, strings.ToLower(key), value) {
+			continue
+		}
+
+		text := token.text
+		prefix := strings.HasSuffix(text, "*")
+		text = strings.TrimRight(text, "*")
+		if hasWord(text) {
+			query.Terms = append(query.Terms, store.SearchTerm{Text: text, Prefix: prefix})
+		}
+	}
+
+	return query
+}
+
+func applyFilter(query *store.SearchQuery, key, value string) bool {
+	switch key {
+	case "author":
+		query.Author = value
+	case "tag":
+		query.Tag = strings.ToLower(value)
+	case "before":
+		date, err := time.Parse(dateLayout, value)
+		if err != nil {
+			return false
+		}
+		query.Before = date
+	case "after":
+		date, err := time.Parse(dateLayout, value)
+		if err != nil {
+			return false
+		}
+		// after:2024-05-01 means from the start of the next day.
+		query.After = date.AddDate(0, 0, 1)
+	default:
+		return false
+	}
+	return true
+}
+
+type token struct {
+	text   string
+	quoted bool
+}
+
+// tokenize splits on whitespace, keeping "double quoted" runs together. A
+// filter value may be quoted too, as in author:"John Doe".
+func tokenize(input string) []token {
+	var tokens []token
+	var current strings.Builder
+	inQuotes := false
+	phrase := false
+
+	flush := func() {
+		if current.Len() > 0 {
+			tokens = append(tokens, token{text: current.String(), quoted: phrase})
+		}
+		current.Reset()
+		phrase = false
+	}
+
+	for _, r := range input {
+		switch {
+		case r == '"' && inQuotes:
+			inQuotes = false
+			flush()
+		case r == '"':
+			if current.Len() > 0 && !strings.HasSuffix(current.String(), ":") {
+				flush()
+			}
+			inQuotes = true
+			phrase = current.Len() == 0
+		case unicode.IsSpace(r) && !inQuotes:
+			flush()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush()
+
+	return tokens
+}
+
+func hasWord(text string) bool {
+	return strings.IndexFunc(text, func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r)
+	}) >= 0
+}
+
+// Highlight escapes a snippet returned by the store and turns its match
+// markers into <mark> elements, so it can be rendered as HTML.
+func Highlight(snippet string) string {
+	escaped := html.EscapeString(snippet)
+	escaped = strings.ReplaceAll(escaped, store.MatchStart, "<mark>")
+	return strings.ReplaceAll(escaped, store.MatchEnd, "</mark>")
+}
@@ -0,0 +1,77 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"forum/backend/store"
+)
+
+func date(s string) time.Time {
+	d, err := time.Parse(dateLayout, s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func TestParse(t *testing.T) {
+	for _, tc := range []struct {
+		input string
+		want  store.SearchQuery
+	}{
+		{"", store.SearchQuery{}},
+		{"go  channels", store.SearchQuery{Terms: []store.SearchTerm{{Text: "go"}, {Text: "channels"}}}},
+		{`"worker pool" go`, store.SearchQuery{Terms: []store.SearchTerm{{Text: "worker pool", Phrase: true}, {Text: "go"}}}},
+		{`"unclosed phrase`, store.SearchQuery{Terms: []store.SearchTerm{{Text: "unclosed phrase", Phrase: true}}}},
+		{`"" "  " "!!"`, store.SearchQuery{}},
+		{"gorout*", store.SearchQuery{Terms: []store.SearchTerm{{Text: "gorout", Prefix: true}}}},
+		{"author:ada", store.SearchQuery{Author: "ada"}},
+		{`author:"Ada Lovelace" engines`, store.SearchQuery{Author: "Ada Lovelace", Terms: []store.SearchTerm{{Text: "engines"}}}},
+		{"AUTHOR:ada Tag:GoLang", store.SearchQuery{Author: "ada", Tag: "golang"}},
+		{"before:2024-05-01", store.SearchQuery{Before: date("2024-05-01")}},
+		{"after:2024-05-01", store.SearchQuery{After: date("2024-05-02")}},
+		{"after:2024-12-31", store.SearchQuery{After: date("2025-01-01")}},
+	} {
+		if got := Parse(tc.input); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Parse(%q) = %+v, want %+v", tc.input, got, tc.want)
+		}
+	}
+}
+
+// Malformed and unknown filters are searched for as words.
+func TestParseBadFilters(t *testing.T) {
+	for input, want := range map[string][]store.SearchTerm{
+		"before:yesterday": {{Text: "before:yesterday"}},
+		"after:2024-13-01": {{Text: "after:2024-13-01"}},
+		"after:01/05/2024": {{Text: "after:01/05/2024"}},
+		"color:red":        {{Text: "color:red"}},
+		"author:":          {{Text: "author:"}},
+	} {
+		got := Parse(input)
+		if !reflect.DeepEqual(got.Terms, want) || got.Author != "" || !got.Before.IsZero() || !got.After.IsZero() {
+			t.Errorf("Parse(%q) = %+v, want only the terms %+v", input, got, want)
+		}
+	}
+}
+
+// FTS5 syntax typed into the box is kept as text for the store to quote;
+// terms without a letter or digit are dropped.
+func TestParseKeepsOperatorsAsText(t *testing.T) {
+	got := Parse(`NEAR(go rust) OR -java ^lisp c++ * "" ( ) :`)
+	want := []store.SearchTerm{
+		{Text: "NEAR(go"}, {Text: "rust)"}, {Text: "OR"}, {Text: "-java"}, {Text: "^lisp"}, {Text: "c++"},
+	}
+	if !reflect.DeepEqual(got.Terms, want) {
+		t.Errorf("Terms = %+v, want %+v", got.Terms, want)
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	snippet := "<b>" + store.MatchStart + "go" + store.MatchEnd + " & rust"
+	want := "&lt;b&gt;<mark>go</mark> &amp; rust"
+	if got := Highlight(snippet); got != want {
+		t.Errorf("Highlight = %q, want %q", got, want)
+	}
+}
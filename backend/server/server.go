@@ -4,16 +4,17 @@ import (
 	"fmt"
 	"net/http"
 
-	"forum/backend/controllers/login"
+	"forum/backend/csrf"
+	"forum/backend/oauth"
 )
 
 const port = ":8080"
 
 func StartServer() {
-	login.LoadEnv()
+	oauth.LoadProviders()
 
 	fmt.Printf("Server is running on %s", port)
-	err := http.ListenAndServe(port, nil)
+	err := http.ListenAndServe(port, csrf.Protect(http.DefaultServeMux))
 	if err != nil {
 		panic(err)
 	}
@@ -0,0 +1,449 @@
+package store
+
+import (
+	"errors"
+	"time"
+
+	"forum/backend/controllers/structs"
+)
+
+var ErrNotFound = errors.New("not found")
+
+// Store is the storage backend the handlers talk to. Both the SQLite and the
+// PostgreSQL implementations in backend/repository satisfy it.
+type Store interface {
+	Posts() PostRepo
+	Comments() CommentRepo
+	Users() UserRepo
+	Identities() IdentityRepo
+	TwoFactor() TwoFactorRepo
+	Sessions() SessionRepo
+	OAuthStates() OAuthStateRepo
+	LoginFailures() LoginFailureRepo
+	RateLimits() RateLimitRepo
+	Votes() VoteRepo
+	Tags() TagRepo
+	Bans() BanRepo
+	AddressBans() AddressBanRepo
+	Stats() StatsRepo
+	Reports() ReportRepo
+	Revisions() RevisionRepo
+	Reputation() ReputationRepo
+	Close() error
+}
+
+// Page selects one window of a listing. Listings are keyed by row ID: After
+// continues past that row, Before goes back to the rows preceding it. Search
+// results have no stable key and page by Offset instead.
+type Page struct {
+	Limit  int
+	After  int
+	Before int
+	Offset int
+}
+
+// Audience is who a listing is for. The public isn't shown hidden or
+// shadowed content; a user also sees what they wrote during a shadow ban,
+// and Everything shows moderators all of it.
+type Audience struct {
+	UserID     int
+	Everything bool
+}
+
+// Listing methods return at most page.Limit rows in listing order, and
+// whether more rows exist further in the direction being paged. VotedBy and
+// Search list posts as the public sees them, apart from the voter's own.
+// Deleted posts are only listed to Everything and by Trash, and Owner
+// doesn't find them; ByID does.
+type PostRepo interface {
+	All(audience Audience, page Page) ([]structs.Post, bool, error)
+	ByUser(userID int, page Page) ([]structs.Post, bool, error)
+	// ByAuthor lists the user's posts as the audience sees them, newest
+	// first, for their profile.
+	ByAuthor(userID int, audience Audience, page Page) ([]structs.Post, bool, error)
+	VotedBy(userID int, page Page) ([]structs.Post, bool, error)
+	ByID(id int) (structs.Post, error)
+	// Search returns the posts matching the query, best match first, with
+	// Snippet set to an excerpt whose matches are wrapped in MatchStart and
+	// MatchEnd.
+	Search(query SearchQuery, page Page) ([]structs.Post, bool, error)
+	Owner(id int) (int, string, error)
+	Create(post structs.Post) (int, error)
+	// Edit replaces the post's title, content and tags, keeping the old
+	// ones as a revision. It returns ErrNotFound if there is no such post,
+	// and ErrUnknownTag and changes nothing if any slug does not exist.
+	Edit(post structs.Post, tagSlugs []string, editorID int, now time.Time) error
+	// Rerender stores render(Content) as the ContentHTML of every post with
+	// all, otherwise of those that have none yet, and returns how many.
+	Rerender(render func(source string) string, all bool) (int, error)
+	// Accept makes the comment the post's accepted answer, or clears it
+	// with commentID 0, moving the reputation it earns from the author of
+	// the previous answer to the author of the new one. It returns
+	// ErrNotFound if there is no such post.
+	Accept(postID, commentID int, now time.Time) error
+	SetHidden(id int, hidden bool) error
+	// Delete moves the post to the trash and Restore takes it back out.
+	// Both return ErrNotFound if the post isn't there.
+	Delete(id, deletedBy int, now time.Time) error
+	Restore(id int) error
+	// Trash lists the user's deleted posts, most recently deleted first.
+	Trash(userID int) ([]structs.Post, error)
+	// Purge removes posts deleted before the time for good, with everything
+	// attached to them. It returns how many there were and the photo paths
+	// no post uses anymore.
+	Purge(before time.Time) (int, []string, error)
+}
+
+// Deleted comments stay in ByPost and All, in their place in the thread.
+// ByUser and Owner leave them out.
+type CommentRepo interface {
+	// ByPost lists the post's top-level comments. Sorted by score they page
+	// by offset, otherwise by ID.
+	ByPost(postID int, audience Audience, sort CommentSort, page Page) ([]structs.Comment, bool, error)
+	// Replies lists the direct replies to any of the comments.
+	Replies(parentIDs []int, audience Audience, sort CommentSort) ([]structs.Comment, error)
+	// ReplyCounts counts the direct replies to each of the comments; those
+	// without any are left out.
+	ReplyCounts(parentIDs []int, audience Audience) (map[int]int, error)
+	// All lists every comment, newest first.
+	All(page Page) ([]structs.Comment, bool, error)
+	ByUser(userID int, page Page) ([]structs.Comment, bool, error)
+	// ByAuthor lists the user's comments as the audience sees them, newest
+	// first, leaving out those on posts the audience can't see.
+	ByAuthor(userID int, audience Audience, page Page) ([]structs.Comment, bool, error)
+	ByID(id int) (structs.Comment, error)
+	Owner(id int) (int, string, error)
+	Create(comment structs.Comment) (int, error)
+	// Edit replaces the comment's text and its HTML, keeping the old text
+	// as a revision. It returns ErrNotFound if there is no such comment.
+	Edit(id int, text, html string, editorID int, now time.Time) error
+	// Rerender is PostRepo.Rerender for CommentHTML.
+	Rerender(render func(source string) string, all bool) (int, error)
+	SetHidden(id int, hidden bool) error
+	Delete(id, deletedBy int, now time.Time) error
+	Restore(id int) error
+	Trash(userID int) ([]structs.Comment, error)
+	// Purge removes comments deleted before the time for good and returns
+	// how many there were. Those that still have replies are emptied
+	// instead and removed once the replies are gone.
+	Purge(before time.Time) (int, error)
+}
+
+// CommentSort orders comments, and the replies to each comment.
+type CommentSort string
+
+const (
+	SortOldest CommentSort = "old"
+	SortNewest CommentSort = "new"
+	SortTop    CommentSort = "top"
+)
+
+// ParseCommentSort reads a ?sort= value; anything unknown sorts oldest
+// first.
+func ParseCommentSort(value string) CommentSort {
+	switch sort := CommentSort(value); sort {
+	case SortNewest, SortTop:
+		return sort
+	}
+	return SortOldest
+}
+
+type UserRepo interface {
+	ByEmail(email string) (structs.User, error)
+	ByID(id int) (structs.User, error)
+	EmailTaken(email string) (bool, error)
+	UserNameTaken(userName string) (bool, error)
+	Create(user structs.User) (int, error)
+	// FindOrCreateByIdentity returns the user the provider account is linked
+	// to. A provider account seen for the first time is linked to the user
+	// with the same email, or to a new password-less user. If that user's
+	// email wasn't verified yet, their password, sessions, two-factor and
+	// other identities are dropped first, as someone else may have set them
+	// up.
+	FindOrCreateByIdentity(identity structs.Identity, userName string) (int, error)
+	SetEmailVerified(id int) error
+	SetPassword(id int, hash string) error
+	Lock(id int, until time.Time) error
+	Unlock(id int) error
+	SetRole(id int, role string) error
+	// Profile returns the profile of the user with the name, or
+	// ErrNotFound.
+	Profile(userName string) (structs.Profile, error)
+	ProfileByID(id int) (structs.Profile, error)
+	// UpdateProfile sets the display name, bio, website, location and
+	// avatar of the user profile.ID.
+	UpdateProfile(profile structs.Profile) error
+	// Search lists users whose name or email contains the text, newest
+	// first; an empty text lists everyone.
+	Search(text string, now time.Time, page Page) ([]structs.UserSummary, bool, error)
+	// Delete removes the user with everything they created, and returns
+	// the photos of their posts that no post shows anymore.
+	Delete(id int) ([]string, error)
+}
+
+var ErrAlreadyBanned = errors.New("user is already banned")
+
+// BanRepo keeps every ban, lifted, expired or not.
+type BanRepo interface {
+	// Active returns the user's ban in force at the time, or ErrNotFound.
+	Active(userID int, now time.Time) (structs.Ban, error)
+	// Ban returns ErrAlreadyBanned if a ban of the user is in force at
+	// ban.CreatedAt.
+	Ban(ban structs.Ban) (int, error)
+	// Lift ends the user's ban in force. It returns ErrNotFound if there is
+	// none.
+	Lift(userID, liftedBy int, now time.Time) error
+	// ByUser returns the user's bans, newest first.
+	ByUser(userID int) ([]structs.Ban, error)
+}
+
+var ErrAlreadyReported = errors.New("already reported")
+
+// ReportRepo keeps reports of posts and comments. Resolved reports are kept
+// as a record of what was done.
+type ReportRepo interface {
+	// Create returns ErrAlreadyReported if the reporter's earlier report of
+	// the target is still open.
+	Create(report structs.Report) (int, error)
+	// OpenCount is how many different users are waiting for a decision on
+	// the target.
+	OpenCount(targetID int, isComment bool) (int, error)
+	// Queue lists the reported posts and comments with open reports, the
+	// longest waiting first.
+	Queue() ([]structs.ReportedItem, error)
+	// Resolve closes every open report on the target, carries out the
+	// outcome in the same transaction and returns the reports. It returns
+	// ErrNotFound if there are none.
+	Resolve(targetID int, isComment bool, resolvedBy int, resolution string, outcome Outcome, now time.Time) ([]structs.Report, error)
+}
+
+// Outcome is what a decision on reports does to the reported post or
+// comment.
+type Outcome struct {
+	// Remove moves it to the trash, unless it is there already. Otherwise
+	// it is shown again.
+	Remove bool
+	// Penalty, if not nil, is recorded in its author's reputation.
+	Penalty *structs.ReputationEvent
+	// Ban, if not nil, is placed on its author unless one is in force
+	// already. Unless it is a shadow ban, it also ends their sessions.
+	Ban *structs.Ban
+}
+
+type RevisionRepo interface {
+	// History returns every version of the post or comment, the original
+	// first and the current one last, or ErrNotFound.
+	History(targetID int, isComment bool) ([]structs.Revision, error)
+}
+
+// ReputationRepo is the ledger behind USERS.Reputation. Votes and accepted
+// answers record their events themselves, in the same transaction.
+type ReputationRepo interface {
+	// History lists the user's events, newest first.
+	History(userID int, page Page) ([]structs.ReputationEvent, bool, error)
+	// Record adds a moderator's event and updates the user's total.
+	Record(event structs.ReputationEvent) error
+	// Recompute rebuilds the events of votes and accepted answers from
+	// USERLIKES and POSTS, and every user's total, returning how many
+	// totals changed.
+	Recompute(now time.Time) (int, error)
+}
+
+var ErrAddressBanned = errors.New("address is already banned")
+
+type AddressBanRepo interface {
+	All() ([]structs.AddressBan, error)
+	// Create returns ErrAddressBanned if the same kind and value are banned
+	// already.
+	Create(ban structs.AddressBan) (int, error)
+	Delete(id int) error
+}
+
+type StatsRepo interface {
+	// Site counts everything on the forum, and the activity of the days
+	// days up to now.
+	Site(now time.Time, days int) (structs.SiteStats, error)
+}
+
+var (
+	ErrIdentityTaken   = errors.New("this login is already linked to another account")
+	ErrLastLoginMethod = errors.New("an account needs a password or at least one linked login")
+)
+
+type IdentityRepo interface {
+	ByUser(userID int) ([]structs.Identity, error)
+	// UserID returns the user the provider account is linked to, or
+	// ErrNotFound.
+	UserID(provider, subject string) (int, error)
+	// Link adds a provider account to the user. It returns ErrIdentityTaken
+	// if the provider account belongs to a different user.
+	Link(identity structs.Identity) error
+	// Unlink removes one of the user's provider accounts. It returns
+	// ErrLastLoginMethod instead if the user would be left unable to log in.
+	Unlink(userID, identityID int) error
+}
+
+// TwoFactorRepo stores TOTP secrets and recovery codes. Recovery codes are
+// passed in and compared as hashes.
+type TwoFactorRepo interface {
+	ByUser(userID int) (structs.TwoFactor, error)
+	// Begin replaces any setup that wasn't finished with a new secret. It
+	// doesn't touch an enabled authenticator.
+	Begin(userID int, secret string, now time.Time) error
+	// Enable turns on the pending setup, recording step as used, and
+	// replaces the recovery codes.
+	Enable(userID int, step int64, codeHashes []string) error
+	Disable(userID int) error
+	// UseStep records that a code for the step was accepted. It returns
+	// false if that step or a later one was used already.
+	UseStep(userID int, step int64) (bool, error)
+	// UseRecoveryCode marks the code used. It returns false if the user has
+	// no such unused code.
+	UseRecoveryCode(userID int, codeHash string, now time.Time) (bool, error)
+	ReplaceRecoveryCodes(userID int, codeHashes []string) error
+	RecoveryCodesLeft(userID int) (int, error)
+}
+
+// SessionRepo stores logins. Only a hash of each session token is kept, so
+// the table can't be used to hijack sessions.
+type SessionRepo interface {
+	Create(session structs.Session, tokenHash string) (int, error)
+	// ByTokenHash returns the unexpired session with the hash and the user
+	// name of its owner.
+	ByTokenHash(tokenHash string, now time.Time) (structs.Session, string, error)
+	Touch(id int, lastSeen, expires time.Time) error
+	ByUser(userID int, now time.Time) ([]structs.Session, error)
+	Delete(id, userID int) error
+	DeleteByTokenHash(tokenHash string) error
+	DeleteByUser(userID int) error
+	// DeleteOthers ends every session of the user except keepID.
+	DeleteOthers(userID, keepID int) error
+	DeleteExpired(now time.Time) (int, error)
+}
+
+// OAuthState is what we remember about a login sent to an OAuth provider
+// until the provider redirects back.
+type OAuthState struct {
+	Provider     string
+	CodeVerifier string
+	Nonce        string
+	ExpiresAt    time.Time
+	// LinkUserID is set when the login links the provider account to this
+	// already logged in user instead.
+	LinkUserID int
+}
+
+// OAuthStateRepo keys states by a hash of the state parameter. Take removes
+// the state as it returns it, so each one can complete a single login.
+type OAuthStateRepo interface {
+	Create(stateHash string, state OAuthState, now time.Time) error
+	Take(stateHash string, now time.Time) (OAuthState, error)
+}
+
+// LoginFailureRepo is the audit log of failed logins.
+type LoginFailureRepo interface {
+	Record(failure structs.LoginFailure) error
+	// Recent returns the newest failures first.
+	Recent(limit int) ([]structs.LoginFailure, error)
+}
+
+// RateLimitRepo keeps failure counters for the database-backed login
+// limiter, so every instance sees the same counts.
+type RateLimitRepo interface {
+	// Failures returns the key's count and the time of its last failure, or
+	// zeros if it has none.
+	Failures(key string) (int, time.Time, error)
+	// AddFailure counts a failure and returns the new count. The count
+	// starts over if the last failure was before since.
+	AddFailure(key string, now, since time.Time) (int, error)
+	Reset(key string) error
+	// DeleteBefore forgets counters whose last failure was before t.
+	DeleteBefore(t time.Time) (int, error)
+}
+
+type VoteState int
+
+const (
+	VoteNone VoteState = iota
+	VoteUp
+	VoteDown
+)
+
+type VoteTarget struct {
+	ID        int
+	IsComment bool
+}
+
+type VoteTally struct {
+	State     VoteState `json:"state"`
+	UpCount   int       `json:"upcount"`
+	DownCount int       `json:"downcount"`
+	Score     int       `json:"score"`
+}
+
+type VoteRepo interface {
+	// Apply reads the user's current vote on the target, asks transition for
+	// the next state and stores it together with the adjusted tallies, all in
+	// one transaction.
+	Apply(userID int, target VoteTarget, transition func(VoteState) VoteState) (VoteTally, error)
+	// Reconcile recomputes every tally from USERLIKES and returns how many
+	// posts and comments had drifted.
+	Reconcile() (int, error)
+}
+
+// MatchStart and MatchEnd surround the matched words in search snippets.
+const (
+	MatchStart = "\x02"
+	MatchEnd   = "\x03"
+)
+
+type SearchTerm struct {
+	Text   string
+	Phrase bool
+	Prefix bool
+}
+
+// SearchQuery is a parsed search. Terms must all match somewhere in the post
+// title, body or comments; the filters are ignored when zero. A query with
+// no terms or filters lists every post.
+type SearchQuery struct {
+	Terms  []SearchTerm
+	Author string
+	Tag    string
+	Before time.Time
+	After  time.Time
+	// Top orders by score instead of relevance or date.
+	Top bool
+	// Unanswered keeps only questions without an accepted answer.
+	Unanswered bool
+}
+
+var ErrUnknownTag = errors.New("unknown tag")
+
+type TagRepo interface {
+	All() ([]structs.Tag, error)
+	BySlug(slug string) (structs.Tag, error)
+	Create(tag structs.Tag, createdBy int) (int, error)
+	ForPost(postID int) ([]structs.Tag, error)
+	// Activity counts the user's posts and comments the audience sees by
+	// the tags of their posts, most active first, up to limit tags.
+	Activity(userID int, audience Audience, limit int) ([]structs.TagActivity, error)
+	// Update changes the tag's name, description and color; the slug stays.
+	Update(tag structs.Tag) error
+	// Delete removes the tag from every post and then itself.
+	Delete(id int) error
+	// SetForPost replaces the post's tags. It returns ErrUnknownTag and
+	// changes nothing if any slug does not exist.
+	SetForPost(postID int, slugs []string) error
+}
+
+var current Store
+
+// Set installs the store used by the HTTP handlers; it is called once at startup.
+func Set(s Store) {
+	current = s
+}
+
+func Get() Store {
+	return current
+}
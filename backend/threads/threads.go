@@ -0,0 +1,71 @@
+// Package threads nests the replies to comments under them.
+package threads
+
+import (
+	"strconv"
+
+	"forum/backend/config"
+	"forum/backend/controllers/structs"
+	"forum/backend/store"
+)
+
+// Depth reads ?depth=, how many levels of replies to include. It can't be
+// more than COMMENT_MAX_DEPTH, which is also the default.
+func Depth(value string) int {
+	maxDepth := config.Get().CommentMaxDepth
+	if depth, err := strconv.Atoi(value); err == nil && depth >= 0 {
+		return min(depth, maxDepth)
+	}
+	return maxDepth
+}
+
+// Load fills in the replies to the comments and to those replies, depth
+// levels deep, each level in the sort order. The comments on the last level
+// get MoreReplies instead. Deleted comments become placeholders, so the
+// replies to them still read in place.
+func Load(repo store.CommentRepo, comments []structs.Comment, audience store.Audience, sort store.CommentSort, depth int) error {
+	level := make([]*structs.Comment, len(comments))
+	for i := range comments {
+		level[i] = &comments[i]
+	}
+
+	for ; len(level) > 0; depth-- {
+		ids := make([]int, len(level))
+		for i, comment := range level {
+			if !comment.DeletedAt.IsZero() {
+				*comment = comment.Placeholder()
+			}
+			ids[i] = comment.ID
+		}
+
+		if depth == 0 {
+			counts, err := repo.ReplyCounts(ids, audience)
+			if err != nil {
+				return err
+			}
+			for _, comment := range level {
+				comment.MoreReplies = counts[comment.ID]
+			}
+			return nil
+		}
+
+		replies, err := repo.Replies(ids, audience, sort)
+		if err != nil {
+			return err
+		}
+		byParent := map[int][]structs.Comment{}
+		for _, reply := range replies {
+			byParent[reply.ParentID] = append(byParent[reply.ParentID], reply)
+		}
+
+		var next []*structs.Comment
+		for _, comment := range level {
+			comment.Replies = byParent[comment.ID]
+			for i := range comment.Replies {
+				next = append(next, &comment.Replies[i])
+			}
+		}
+		level = next
+	}
+	return nil
+}
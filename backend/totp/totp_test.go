@@ -0,0 +1,110 @@
+package totp
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 key of the RFC 6238 test vectors,
+// "12345678901234567890", base32 encoded.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// The RFC's eight-digit codes, of which authenticator apps show the last six.
+func TestCodeRFC6238(t *testing.T) {
+	for _, tc := range []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	} {
+		code, err := Code(rfcSecret, Step(time.Unix(tc.unix, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != tc.code {
+			t.Errorf("Code at %d = %s, want %s", tc.unix, code, tc.code)
+		}
+	}
+}
+
+func TestCodeAcceptsLowerCaseSecret(t *testing.T) {
+	code, err := Code("gezdgnbvgy3tqojqgezdgnbvgy3tqojq", 1)
+	if err != nil || code != "287082" {
+		t.Fatalf("Code = %s, %v; want 287082", code, err)
+	}
+	if _, err := Code("not base32!", 1); err == nil {
+		t.Fatal("Code accepted a malformed secret")
+	}
+}
+
+func TestValidateAllowsOneStepOfSkew(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := Step(now)
+
+	for offset := int64(-2); offset <= 2; offset++ {
+		code, err := Code(rfcSecret, current+offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		step, ok := Validate(rfcSecret, code, now)
+		if want := offset >= -1 && offset <= 1; ok != want {
+			t.Errorf("code %d steps off: ok = %v, want %v", offset, ok, want)
+		}
+		if ok && step != current+offset {
+			t.Errorf("code %d steps off matched step %d, want %d", offset, step, current+offset)
+		}
+	}
+}
+
+func TestValidateInput(t *testing.T) {
+	now := time.Unix(59, 0)
+	for code, want := range map[string]bool{
+		"287082":   true,
+		"287 082":  true,
+		"287083":   false,
+		"28708":    false,
+		"2870820":  false,
+		"":         false,
+		"abcdef":   false,
+		"94287082": false,
+	} {
+		if _, ok := Validate(rfcSecret, code, now); ok != want {
+			t.Errorf("Validate(%q) = %v, want %v", code, ok, want)
+		}
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := RecoveryCodes(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	format := regexp.MustCompile(`^[a-z2-7]{5}-[a-z2-7]{5}$`)
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		if !format.MatchString(code) {
+			t.Errorf("recovery code %q is not like k3f7x-2mq5d", code)
+		}
+		if seen[code] {
+			t.Errorf("recovery code %q given twice", code)
+		}
+		seen[code] = true
+	}
+
+	for typed, want := range map[string]string{
+		"k3f7x-2mq5d":   "k3f7x-2mq5d",
+		"K3F7X-2MQ5D":   "k3f7x-2mq5d",
+		" k3f7x 2mq5d ": "k3f7x-2mq5d",
+		"k3f7x2mq5d":    "k3f7x-2mq5d",
+	} {
+		if got := NormalizeRecoveryCode(typed); got != want {
+			t.Errorf("NormalizeRecoveryCode(%q) = %q, want %q", typed, got, want)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package votes
+
+import (
+	"forum/backend/store"
+)
+
+// Next is the vote state machine: pressing the direction the user already
+// voted clears the vote, anything else switches to the pressed direction.
+func Next(current, pressed store.VoteState) store.VoteState {
+	if current == pressed {
+		return store.VoteNone
+	}
+	return pressed
+}
+
+type Service struct {
+	repo store.VoteRepo
+}
+
+func NewService(repo store.VoteRepo) *Service {
+	return &Service{repo: repo}
+}
+
+func (s *Service) Up(userID int, target store.VoteTarget) (store.VoteTally, error) {
+	return s.press(userID, target, store.VoteUp)
+}
+
+func (s *Service) Down(userID int, target store.VoteTarget) (store.VoteTally, error) {
+	return s.press(userID, target, store.VoteDown)
+}
+
+func (s *Service) Reconcile() (int, error) {
+	return s.repo.Reconcile()
+}
+
+func (s *Service) press(userID int, target store.VoteTarget, pressed store.VoteState) (store.VoteTally, error) {
+	return s.repo.Apply(userID, target, func(current store.VoteState) store.VoteState {
+		return Next(current, pressed)
+	})
+}
@@ -0,0 +1,163 @@
+package adminpage
+
+import (
+	"html/template"
+	"net/http"
+
+	"forum/backend/auth"
+	"forum/backend/controllers/structs"
+	"forum/backend/csrf"
+	"forum/backend/rbac"
+	"forum/backend/requests"
+	"forum/backend/store"
+)
+
+// viewer is the logged in user looking at an admin page, with what their
+// role lets them do there.
+type viewer struct {
+	User           structs.User
+	CanSetRole     bool
+	CanBan         bool
+	CanUnlock      bool
+	CanRevoke      bool
+	CanAdjust      bool
+	CanDeletePosts bool
+	CanDeleteComs  bool
+	CanEditTags    bool
+	CanCreateTags  bool
+	CanDeleteTags  bool
+	CanResolve     bool
+	CSRFToken      string
+}
+
+// currentViewer answers 401 or 403 unless the browser's user may see the
+// admin pages. The API checks every action again.
+func currentViewer(w http.ResponseWriter, r *http.Request) (viewer, string, bool) {
+	cookie, cookieErr := r.Cookie(auth.SessionCookieName)
+	if cookieErr != nil {
+		http.Error(w, "ERROR: You are not logged in", http.StatusUnauthorized)
+		return viewer{}, "", false
+	}
+
+	repos := store.Get()
+	authenticated, userId, _ := auth.IsAuthenticated(r, repos.Sessions())
+	if !authenticated {
+		http.Error(w, "ERROR: You are not logged in", http.StatusUnauthorized)
+		return viewer{}, "", false
+	}
+	user, err := repos.Users().ByID(userId)
+	if err != nil {
+		http.Error(w, "ERROR: Query error", http.StatusInternalServerError)
+		return viewer{}, "", false
+	}
+	if !rbac.Can(auth.RoleOf(user), rbac.AdminAccess) {
+		http.Error(w, "ERROR: You don't have access to the admin pages", http.StatusForbidden)
+		return viewer{}, "", false
+	}
+	if !auth.Can(user, rbac.AdminAccess) {
+		http.Error(w, "ERROR: Turn on two-factor authentication to use your role's privileges", http.StatusForbidden)
+		return viewer{}, "", false
+	}
+
+	return viewer{
+		User:           user,
+		CanSetRole:     auth.Can(user, rbac.UserSetRole),
+		CanBan:         auth.Can(user, rbac.UserBan),
+		CanUnlock:      auth.Can(user, rbac.UserUnlock),
+		CanRevoke:      auth.Can(user, rbac.UserSessions),
+		CanAdjust:      auth.Can(user, rbac.UserReputation),
+		CanDeletePosts: auth.Can(user, rbac.PostDeleteAny),
+		CanDeleteComs:  auth.Can(user, rbac.CommentDeleteAny),
+		CanEditTags:    auth.Can(user, rbac.TagEdit),
+		CanCreateTags:  auth.Can(user, rbac.TagCreate),
+		CanDeleteTags:  auth.Can(user, rbac.TagDelete),
+		CanResolve:     auth.Can(user, rbac.ReportResolve),
+		CSRFToken:      csrf.FromRequest(r),
+	}, cookie.Value, true
+}
+
+// render executes one of the admin templates, which share nav.html.
+func render(w http.ResponseWriter, page string, data any) {
+	tmpl, err := template.ParseFiles("frontend/pages/adminPage/"+page, "frontend/pages/adminPage/nav.html")
+	if err != nil {
+		http.Error(w, "ERROR: Unable to parse template", http.StatusInternalServerError)
+		return
+	}
+
+	err = tmpl.Execute(w, data)
+	if err != nil {
+		http.Error(w, "ERROR: Unable to execute template", http.StatusInternalServerError)
+		return
+	}
+}
+
+// act runs an admin action for a form post and sends the browser back to
+// the page the form was on.
+func act(w http.ResponseWriter, r *http.Request, back string, call func(cookieValue string) error) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	cookie, cookieErr := r.Cookie(auth.SessionCookieName)
+	if cookieErr != nil {
+		http.Error(w, "ERROR: You are not logged in", http.StatusUnauthorized)
+		return
+	}
+
+	if err := call(cookie.Value); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	http.Redirect(w, r, back, http.StatusSeeOther)
+}
+
+type dayBar struct {
+	Day             string
+	Posts, Comments int
+	// PostsPct and CommentsPct scale the bars to the busiest day.
+	PostsPct, CommentsPct int
+}
+
+// Dashboard shows the site statistics.
+func Dashboard(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	v, cookie, ok := currentViewer(w, r)
+	if !ok {
+		return
+	}
+
+	stats, err := requests.GetStatsRequest("http://localhost:8080/api/stats", cookie)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	busiest := 1
+	for i := range stats.PostsPerDay {
+		busiest = max(busiest, stats.PostsPerDay[i].Count, stats.CommentsPerDay[i].Count)
+	}
+	bars := make([]dayBar, len(stats.PostsPerDay))
+	for i, day := range stats.PostsPerDay {
+		comments := stats.CommentsPerDay[i].Count
+		bars[i] = dayBar{
+			Day:         day.Day,
+			Posts:       day.Count,
+			Comments:    comments,
+			PostsPct:    day.Count * 100 / busiest,
+			CommentsPct: comments * 100 / busiest,
+		}
+	}
+
+	render(w, "adminPage.html", struct {
+		Viewer viewer
+		Stats  structs.SiteStats
+		Days   []dayBar
+		Page   string
+	}{v, stats, bars, "dashboard"})
+}
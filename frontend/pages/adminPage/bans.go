@@ -0,0 +1,72 @@
+package adminpage
+
+import (
+	"net/http"
+
+	"forum/backend/controllers/structs"
+	"forum/backend/requests"
+)
+
+// BansPage lists the banned IP addresses and email domains.
+func BansPage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	v, cookie, ok := currentViewer(w, r)
+	if !ok {
+		return
+	}
+
+	bans, err := requests.GetAddressBansRequest("http://localhost:8080/api/addressbans", cookie)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	render(w, "adminBansPage.html", struct {
+		Viewer viewer
+		Bans   []structs.AddressBan
+		Page   string
+	}{v, bans, "bans"})
+}
+
+// BanHistoryPage lists every ban one user has had.
+func BanHistoryPage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	v, cookie, ok := currentViewer(w, r)
+	if !ok {
+		return
+	}
+
+	bans, err := requests.GetUserBansRequest("http://localhost:8080/api/userbans", r.FormValue("id"), cookie)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	render(w, "adminBanHistoryPage.html", struct {
+		Viewer viewer
+		UserID string
+		Bans   []structs.Ban
+		Page   string
+	}{v, r.FormValue("id"), bans, "users"})
+}
+
+func BanAddress(w http.ResponseWriter, r *http.Request) {
+	act(w, r, "/admin/bans", func(cookie string) error {
+		return requests.BanAddressRequest("http://localhost:8080/api/banaddress", r.FormValue("kind"), r.FormValue("value"),
+			r.FormValue("reason"), cookie)
+	})
+}
+
+func DeleteAddressBan(w http.ResponseWriter, r *http.Request) {
+	act(w, r, "/admin/bans", func(cookie string) error {
+		return requests.DeleteAddressBanRequest("http://localhost:8080/api/deleteaddressban", r.FormValue("id"), cookie)
+	})
+}
@@ -0,0 +1,102 @@
+package adminpage
+
+import (
+	"net/http"
+
+	"forum/backend/controllers/structs"
+	"forum/backend/requests"
+)
+
+func PostsPage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	v, cookie, ok := currentViewer(w, r)
+	if !ok {
+		return
+	}
+
+	posts, err := requests.GetAdminPostsRequest("http://localhost:8080/api/adminposts", cookie, r.FormValue("cursor"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	render(w, "adminPostsPage.html", struct {
+		Viewer viewer
+		structs.PostList
+		Page string
+	}{v, posts, "posts"})
+}
+
+func CommentsPage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	v, cookie, ok := currentViewer(w, r)
+	if !ok {
+		return
+	}
+
+	comments, err := requests.GetAdminCommentsRequest("http://localhost:8080/api/admincomments", cookie, r.FormValue("cursor"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	render(w, "adminCommentsPage.html", struct {
+		Viewer viewer
+		structs.CommentList
+		Page string
+	}{v, comments, "comments"})
+}
+
+func DeletePosts(w http.ResponseWriter, r *http.Request) {
+	act(w, r, "/admin/posts", func(cookie string) error {
+		if err := r.ParseForm(); err != nil {
+			return err
+		}
+		return requests.DeleteManyRequest("http://localhost:8080/api/deleteposts", r.Form["id"], cookie)
+	})
+}
+
+func DeleteComments(w http.ResponseWriter, r *http.Request) {
+	act(w, r, "/admin/comments", func(cookie string) error {
+		if err := r.ParseForm(); err != nil {
+			return err
+		}
+		return requests.DeleteManyRequest("http://localhost:8080/api/deletecomments", r.Form["id"], cookie)
+	})
+}
+
+func RestorePosts(w http.ResponseWriter, r *http.Request) {
+	act(w, r, "/admin/posts", func(cookie string) error {
+		if err := r.ParseForm(); err != nil {
+			return err
+		}
+		return requests.RestoreManyRequest("http://localhost:8080/api/restoreposts", r.Form["id"], cookie)
+	})
+}
+
+func RestoreComments(w http.ResponseWriter, r *http.Request) {
+	act(w, r, "/admin/comments", func(cookie string) error {
+		if err := r.ParseForm(); err != nil {
+			return err
+		}
+		return requests.RestoreManyRequest("http://localhost:8080/api/restorecomments", r.Form["id"], cookie)
+	})
+}
+
+func Restore(w http.ResponseWriter, r *http.Request) {
+	back := "/admin/posts"
+	if r.FormValue("isComment") == "true" {
+		back = "/admin/comments"
+	}
+	act(w, r, back, func(cookie string) error {
+		return requests.RestoreRequest("http://localhost:8080/api/restore", r.FormValue("id"), r.FormValue("isComment"), cookie)
+	})
+}
@@ -0,0 +1,42 @@
+package adminpage
+
+import (
+	"net/http"
+
+	"forum/backend/controllers/structs"
+	"forum/backend/moderation"
+	"forum/backend/requests"
+)
+
+// ReportsPage is the moderation queue.
+func ReportsPage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	v, cookie, ok := currentViewer(w, r)
+	if !ok {
+		return
+	}
+
+	items, err := requests.GetReportsRequest("http://localhost:8080/api/reports", cookie)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	render(w, "adminReportsPage.html", struct {
+		Viewer  viewer
+		Items   []structs.ReportedItem
+		Actions []string
+		Page    string
+	}{v, items, moderation.Actions, "reports"})
+}
+
+func ResolveReport(w http.ResponseWriter, r *http.Request) {
+	act(w, r, "/admin/reports", func(cookie string) error {
+		return requests.ResolveReportRequest("http://localhost:8080/api/resolvereport",
+			r.FormValue("id"), r.FormValue("isComment"), r.FormValue("action"), r.FormValue("note"), cookie)
+	})
+}
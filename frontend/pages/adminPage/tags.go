@@ -0,0 +1,67 @@
+package adminpage
+
+import (
+	"net/http"
+	"strconv"
+
+	"forum/backend/controllers/structs"
+	"forum/backend/requests"
+)
+
+func TagsPage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	v, _, ok := currentViewer(w, r)
+	if !ok {
+		return
+	}
+
+	tags, err := requests.GetTags("http://localhost:8080/api/tags")
+	if err != nil {
+		http.Error(w, "ERROR: Could not fetch tags", http.StatusInternalServerError)
+		return
+	}
+
+	render(w, "adminTagsPage.html", struct {
+		Viewer viewer
+		Tags   []structs.Tag
+		Page   string
+	}{v, tags, "tags"})
+}
+
+func CreateTag(w http.ResponseWriter, r *http.Request) {
+	act(w, r, "/admin/tags", func(cookie string) error {
+		tag := structs.Tag{
+			Slug:        r.FormValue("slug"),
+			Name:        r.FormValue("name"),
+			Description: r.FormValue("description"),
+			Color:       r.FormValue("color"),
+		}
+		return requests.CreateTagRequest("http://localhost:8080/api/createtag", tag, cookie)
+	})
+}
+
+func UpdateTag(w http.ResponseWriter, r *http.Request) {
+	act(w, r, "/admin/tags", func(cookie string) error {
+		tagId, err := strconv.Atoi(r.FormValue("id"))
+		if err != nil {
+			return err
+		}
+		tag := structs.Tag{
+			ID:          tagId,
+			Name:        r.FormValue("name"),
+			Description: r.FormValue("description"),
+			Color:       r.FormValue("color"),
+		}
+		return requests.UpdateTagRequest("http://localhost:8080/api/updatetag", tag, cookie)
+	})
+}
+
+func DeleteTag(w http.ResponseWriter, r *http.Request) {
+	act(w, r, "/admin/tags", func(cookie string) error {
+		return requests.DeleteTagRequest("http://localhost:8080/api/deletetag", r.FormValue("id"), cookie)
+	})
+}
@@ -0,0 +1,108 @@
+package adminpage
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"forum/backend/controllers/structs"
+	"forum/backend/rbac"
+	"forum/backend/requests"
+)
+
+type userRow struct {
+	structs.UserSummary
+	Locked bool
+	// Manageable is set for users below the viewer's role.
+	Manageable bool
+}
+
+func UsersPage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	v, cookie, ok := currentViewer(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.FormValue("q")
+	users, err := requests.GetAdminUsersRequest("http://localhost:8080/api/adminusers", query, cookie, r.FormValue("cursor"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	now := time.Now()
+	viewerRole := rbac.ParseRole(v.User.Role)
+	rows := make([]userRow, len(users.Users))
+	for i, user := range users.Users {
+		rows[i] = userRow{
+			UserSummary: user,
+			Locked:      user.Locked(now),
+			Manageable:  viewerRole.Outranks(rbac.ParseRole(user.Role)),
+		}
+	}
+
+	// Roles the viewer may hand out.
+	var roles []rbac.Role
+	for _, role := range rbac.Assignable {
+		if viewerRole.Outranks(role) {
+			roles = append(roles, role)
+		}
+	}
+
+	render(w, "adminUsersPage.html", struct {
+		Viewer  viewer
+		Users   []userRow
+		Roles   []rbac.Role
+		Query   string
+		Cursors structs.Cursors
+		Page    string
+	}{v, rows, roles, query, users.Cursors, "users"})
+}
+
+// usersBack is the user list the form was posted from.
+func usersBack(r *http.Request) string {
+	return "/admin/users?q=" + url.QueryEscape(r.FormValue("q"))
+}
+
+func SetRole(w http.ResponseWriter, r *http.Request) {
+	act(w, r, usersBack(r), func(cookie string) error {
+		return requests.SetRoleRequest("http://localhost:8080/api/setrole", r.FormValue("id"), r.FormValue("role"), cookie)
+	})
+}
+
+func BanUser(w http.ResponseWriter, r *http.Request) {
+	act(w, r, usersBack(r), func(cookie string) error {
+		return requests.BanUserRequest("http://localhost:8080/api/banuser", r.FormValue("id"), r.FormValue("reason"),
+			r.FormValue("duration"), r.FormValue("shadow"), cookie)
+	})
+}
+
+func UnbanUser(w http.ResponseWriter, r *http.Request) {
+	act(w, r, usersBack(r), func(cookie string) error {
+		return requests.UnbanUserRequest("http://localhost:8080/api/unbanuser", r.FormValue("id"), cookie)
+	})
+}
+
+func UnlockUser(w http.ResponseWriter, r *http.Request) {
+	act(w, r, usersBack(r), func(cookie string) error {
+		return requests.UnlockUserRequest("http://localhost:8080/api/unlockuser", r.FormValue("id"), cookie)
+	})
+}
+
+func RevokeSessions(w http.ResponseWriter, r *http.Request) {
+	act(w, r, usersBack(r), func(cookie string) error {
+		return requests.RevokeSessionsRequest("http://localhost:8080/api/revokesessions", r.FormValue("id"), cookie)
+	})
+}
+
+func AdjustReputation(w http.ResponseWriter, r *http.Request) {
+	act(w, r, usersBack(r), func(cookie string) error {
+		return requests.AdjustReputationRequest("http://localhost:8080/api/adjustreputation", r.FormValue("id"),
+			r.FormValue("points"), r.FormValue("note"), cookie)
+	})
+}
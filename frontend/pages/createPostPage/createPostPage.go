@@ -1,19 +1,37 @@
 package createpostpage
 
 import (
+	"html/template"
 	"net/http"
 	"path/filepath"
+	"slices"
 	"strings"
 
+	"forum/backend/controllers/structs"
+	"forum/backend/csrf"
 	"forum/backend/requests"
 )
 
-const createPostApiUrl = "http://localhost:8080/api/createpost"
+const (
+	createPostApiUrl = "http://localhost:8080/api/createpost"
+	tagsApiUrl       = "http://localhost:8080/api/tags"
+	previewApiUrl    = "http://localhost:8080/api/preview"
+)
+
+type pageData struct {
+	Tags      []structs.Tag
+	CSRFToken string
+	Title     string
+	Content   string
+	Question  bool
+	Checked   []string
+	Preview   template.HTML
+}
 
 func CreatePostPage(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case "GET":
-		http.ServeFile(w, r, "frontend/pages/createPostPage/createPostPage.html")
+		renderPage(w, r, pageData{})
 	case "POST":
 		cookie, cookieErr := r.Cookie("session_token")
 		if cookieErr != nil {
@@ -22,6 +40,23 @@ func CreatePostPage(w http.ResponseWriter, r *http.Request) {
 		}
 		title := r.FormValue("title")
 		content := r.FormValue("content")
+		question := r.FormValue("question") == "true"
+
+		if r.FormValue("preview") != "" {
+			preview, err := requests.PreviewRequest(previewApiUrl, content, cookie.Value)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			renderPage(w, r, pageData{
+				Title:    title,
+				Content:  content,
+				Question: question,
+				Checked:  r.Form["tags"],
+				Preview:  template.HTML(preview),
+			})
+			return
+		}
 
 		file, handler, err := r.FormFile("photo")
 		if err != nil {
@@ -36,9 +71,7 @@ func CreatePostPage(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		categoryDatas := GetCategoryDatas(r)
-
-		err = requests.CreatePostRequest(createPostApiUrl, title, content, categoryDatas, cookie.Value, file, handler.Filename)
+		err = requests.CreatePostRequest(createPostApiUrl, title, content, question, r.Form["tags"], cookie.Value, file, handler.Filename)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
@@ -48,16 +81,28 @@ func CreatePostPage(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func GetCategoryDatas(r *http.Request) map[string]string {
-	categories := []string{"go", "html", "css", "php", "python", "c", "cpp", "csharp", "js", "assembly", "react", "flutter", "rust"}
-	categoryDatas := make(map[string]string)
+// renderPage shows the form, filled in again with what was written so far
+// and its preview when the author asked for one.
+func renderPage(w http.ResponseWriter, r *http.Request, data pageData) {
+	tags, err := requests.GetTags(tagsApiUrl)
+	if err != nil {
+		http.Error(w, "ERROR: Could not fetch tags", http.StatusInternalServerError)
+		return
+	}
+	data.Tags = tags
+	data.CSRFToken = csrf.FromRequest(r)
 
-	for _, category := range categories {
-		if r.FormValue(category) == "true" {
-			categoryDatas[category] = "true"
-		} else {
-			categoryDatas[category] = "false"
-		}
+	tmpl, err := template.New("createPostPage.html").Funcs(template.FuncMap{
+		"checked": func(slug string) bool { return slices.Contains(data.Checked, slug) },
+	}).ParseFiles("frontend/pages/createPostPage/createPostPage.html")
+	if err != nil {
+		http.Error(w, "ERROR: Unable to parse template", http.StatusInternalServerError)
+		return
+	}
+
+	err = tmpl.Execute(w, data)
+	if err != nil {
+		http.Error(w, "ERROR: Unable to execute template", http.StatusInternalServerError)
+		return
 	}
-	return categoryDatas
 }
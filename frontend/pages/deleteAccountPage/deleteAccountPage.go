@@ -2,17 +2,47 @@ package deleteaccountpage
 
 import (
 	"fmt"
+	"html/template"
 	"net/http"
 
+	"forum/backend/csrf"
 	"forum/backend/requests"
 )
 
 func DeleteAccountPage(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case "GET":
-		http.ServeFile(w, r, "frontend/pages/deleteAccountPage/deleteAccountPage.html")
+		cookie, cookieErr := r.Cookie("session_token")
+		if cookieErr != nil {
+			http.Error(w, "ERROR: You are not logged in", http.StatusUnauthorized)
+			return
+		}
+
+		methods, err := requests.GetLoginMethodsRequest("http://localhost:8080/api/loginmethods", cookie.Value)
+		if err != nil {
+			http.Error(w, "ERROR: Bad request", http.StatusBadRequest)
+			return
+		}
+
+		tmpl, err := template.ParseFiles("frontend/pages/deleteAccountPage/deleteAccountPage.html")
+		if err != nil {
+			http.Error(w, "ERROR: Unable to parse template", http.StatusInternalServerError)
+			return
+		}
+
+		data := struct {
+			HasPassword bool
+			CSRFToken   string
+		}{methods.HasPassword, csrf.FromRequest(r)}
+
+		err = tmpl.Execute(w, data)
+		if err != nil {
+			http.Error(w, "ERROR: Unable to execute template", http.StatusInternalServerError)
+			return
+		}
 	case "POST":
 		password := r.FormValue("password")
+		confirm := r.FormValue("confirm")
 
 		cookie, cookieErr := r.Cookie("session_token")
 		if cookieErr != nil {
@@ -20,7 +50,7 @@ func DeleteAccountPage(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		err := requests.DeleteAccountRequest("http://localhost:8080/api/deleteaccount", password, cookie.Value)
+		err := requests.DeleteAccountRequest("http://localhost:8080/api/deleteaccount", password, confirm, cookie.Value)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			fmt.Println(err)
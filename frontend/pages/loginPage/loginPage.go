@@ -1,27 +1,83 @@
 package loginpage
 
 import (
+	"html/template"
 	"net/http"
 
+	"forum/backend/csrf"
+	"forum/backend/oauth"
 	"forum/backend/requests"
 )
 
-const loginApiUrl = "http://localhost:8080/api/login"
+const (
+	loginApiUrl          = "http://localhost:8080/api/login"
+	loginTwoFactorApiUrl = "http://localhost:8080/api/logintwofactor"
+)
+
+type pageData struct {
+	Providers []oauth.Button
+	// Challenge is set on the second step, when the page asks for a
+	// two-factor code.
+	Challenge string
+	CSRFToken string
+}
 
 func LoginPage(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case "GET":
-		http.ServeFile(w, r, "frontend/pages/loginPage/loginPage.html")
+		render(w, pageData{Providers: oauth.Buttons(), CSRFToken: csrf.FromRequest(r)})
 	case "POST":
 		email := r.FormValue("email")
 		password := r.FormValue("password")
 
-		err := requests.LoginRequest(loginApiUrl, email, password, w)
+		result, err := requests.LoginRequest(loginApiUrl, email, password, w, r)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
+		if result.Challenge != "" {
+			render(w, pageData{Challenge: result.Challenge, CSRFToken: csrf.FromRequest(r)})
+			return
+		}
+		if result.SetupTwoFactor {
+			http.Redirect(w, r, "/settings/twofactor", http.StatusSeeOther)
+			return
+		}
+
 		http.Redirect(w, r, "/", http.StatusSeeOther)
 	}
 }
+
+// TwoFactorLogin asks for the code of a login whose first step passed:
+// provider logins are redirected here with their challenge.
+func TwoFactorLogin(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		render(w, pageData{Challenge: r.URL.Query().Get("challenge"), CSRFToken: csrf.FromRequest(r)})
+	case "POST":
+		err := requests.LoginTwoFactorRequest(loginTwoFactorApiUrl, r.FormValue("challenge"), r.FormValue("code"), w, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+	default:
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+	}
+}
+
+func render(w http.ResponseWriter, data pageData) {
+	tmpl, err := template.ParseFiles("frontend/pages/loginPage/loginPage.html")
+	if err != nil {
+		http.Error(w, "ERROR: Unable to parse template", http.StatusInternalServerError)
+		return
+	}
+
+	err = tmpl.Execute(w, data)
+	if err != nil {
+		http.Error(w, "ERROR: Unable to execute template", http.StatusInternalServerError)
+		return
+	}
+}
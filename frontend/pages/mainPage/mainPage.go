@@ -1,12 +1,16 @@
 package mainpage
 
 import (
+	"html/template"
 	"net/http"
-	"text/template"
+	"net/url"
 
 	"forum/backend/auth"
-	"forum/backend/database"
+	"forum/backend/controllers/structs"
+	"forum/backend/csrf"
+	"forum/backend/rbac"
 	"forum/backend/requests"
+	"forum/backend/store"
 )
 
 func MainPage(w http.ResponseWriter, r *http.Request) {
@@ -15,38 +19,60 @@ func MainPage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, errDb := database.OpenDb(w)
-	if errDb != nil {
-		http.Error(w, "ERROR: Database cannot open", http.StatusBadRequest)
-		return
-	}
-	defer db.Close()
+	repos := store.Get()
 
 	var tmpl *template.Template
 	var err error
+	funcs := template.FuncMap{"pathEscape": url.PathEscape}
 
-	authenticated, _, _ := auth.IsAuthenticated(r, db)
+	canAdmin, userName := false, ""
+	authenticated, userId, _ := auth.IsAuthenticated(r, repos.Sessions())
+	if authenticated {
+		user, err := repos.Users().ByID(userId)
+		canAdmin = err == nil && auth.Can(user, rbac.AdminAccess)
+		userName = user.UserName
+	}
 	if !authenticated {
-		tmpl, err = template.ParseFiles("frontend/pages/mainPage/sessionless/main.html")
+		tmpl, err = template.New("main.html").Funcs(funcs).ParseFiles("frontend/pages/mainPage/sessionless/main.html")
 		if err != nil {
 			http.Error(w, "ERROR: Unable to parse template", http.StatusInternalServerError)
 			return
 		}
 	} else {
-		tmpl, err = template.ParseFiles("frontend/pages/mainPage/session/main.html")
+		tmpl, err = template.New("main.html").Funcs(funcs).ParseFiles("frontend/pages/mainPage/session/main.html")
 		if err != nil {
 			http.Error(w, "ERROR: Unable to parse template", http.StatusInternalServerError)
 			return
 		}
 	}
 
-	posts, err := requests.GetDataForServe("http://localhost:8080/api/allposts")
+	// Logged in users also get what they posted while shadow banned.
+	var posts structs.PostList
+	if cookie, cookieErr := r.Cookie(auth.SessionCookieName); authenticated && cookieErr == nil {
+		posts, err = requests.GetDataForServeWithReq("http://localhost:8080/api/allposts", cookie.Value, r.FormValue("cursor"))
+	} else {
+		posts, err = requests.GetDataForServe("http://localhost:8080/api/allposts", r.FormValue("cursor"))
+	}
 	if err != nil {
 		http.Error(w, "Could not fetch post data", http.StatusInternalServerError)
 		return
 	}
 
-	err = tmpl.Execute(w, posts)
+	tags, err := requests.GetTags("http://localhost:8080/api/tags")
+	if err != nil {
+		http.Error(w, "Could not fetch tag data", http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		structs.PostList
+		Tags      []structs.Tag
+		CanAdmin  bool
+		UserName  string
+		CSRFToken string
+	}{posts, tags, canAdmin, userName, csrf.FromRequest(r)}
+
+	err = tmpl.Execute(w, data)
 	if err != nil {
 		http.Error(w, "ERROR: Unable to execute template", http.StatusInternalServerError)
 		return
@@ -0,0 +1,71 @@
+package passwordresetpage
+
+import (
+	"html/template"
+	"net/http"
+
+	"forum/backend/csrf"
+	"forum/backend/requests"
+)
+
+var requestPasswordResetApiUrl = "http://localhost:8080/api/requestpasswordreset"
+
+type pageData struct {
+	Token     string
+	Message   string
+	CSRFToken string
+}
+
+func ForgotPasswordPage(w http.ResponseWriter, r *http.Request) {
+	data := pageData{CSRFToken: csrf.FromRequest(r)}
+
+	switch r.Method {
+	case "GET":
+	case "POST":
+		err := requests.RequestPasswordResetRequest(requestPasswordResetApiUrl, r.FormValue("email"), r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		data.Message = "If an account uses this email, we have sent it a link to reset the password."
+	default:
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	render(w, "frontend/pages/passwordResetPage/forgotPasswordPage.html", data)
+}
+
+func ResetPasswordPage(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		render(w, "frontend/pages/passwordResetPage/resetPasswordPage.html", pageData{
+			Token:     r.URL.Query().Get("token"),
+			CSRFToken: csrf.FromRequest(r),
+		})
+	case "POST":
+		err := requests.ResetPasswordRequest("http://localhost:8080/api/resetpassword", r.FormValue("token"), r.FormValue("password"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+	default:
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+	}
+}
+
+func render(w http.ResponseWriter, file string, data pageData) {
+	tmpl, err := template.ParseFiles(file)
+	if err != nil {
+		http.Error(w, "ERROR: Unable to parse template", http.StatusInternalServerError)
+		return
+	}
+
+	err = tmpl.Execute(w, data)
+	if err != nil {
+		http.Error(w, "ERROR: Unable to execute template", http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,68 @@
+package passwordresetpage
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"forum/backend/auth"
+	passwordreset "forum/backend/controllers/passwordReset"
+	"forum/backend/database"
+	"forum/backend/ratelimit"
+	"forum/backend/store"
+)
+
+// The page forwards each browser's address, so one client asking for reset
+// links doesn't use up the budget of every other.
+func TestForgotPasswordBudgetPerClient(t *testing.T) {
+	st, err := database.OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Set(st)
+	ratelimit.Set(ratelimit.NewMemory(auth.LoginFailureWindow))
+
+	api := httptest.NewServer(http.HandlerFunc(passwordreset.RequestPasswordReset))
+	t.Cleanup(api.Close)
+	defer func(old string) { requestPasswordResetApiUrl = old }(requestPasswordResetApiUrl)
+	requestPasswordResetApiUrl = api.URL
+
+	// The page renders its template relative to the repository root.
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir("../../.."); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	ask := func(ip, email string) int {
+		form := url.Values{"email": {email}}
+		r := httptest.NewRequest("POST", "/forgotpassword", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.RemoteAddr = ip + ":40000"
+		w := httptest.NewRecorder()
+		ForgotPasswordPage(w, r)
+		return w.Code
+	}
+
+	// Different addresses each time, so only the IP's budget runs out.
+	asked := 0
+	for ask("203.0.113.1", fmt.Sprintf("user%d@example.com", asked)) == http.StatusOK {
+		if asked++; asked > 50 {
+			t.Fatal("the first client was never throttled")
+		}
+	}
+	if asked == 0 {
+		t.Fatal("the first client was throttled from the start")
+	}
+
+	if code := ask("203.0.113.2", "someone@example.com"); code != http.StatusOK {
+		t.Fatalf("second client answered %d, want %d", code, http.StatusOK)
+	}
+}
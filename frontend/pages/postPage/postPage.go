@@ -3,30 +3,121 @@ package postpage
 import (
 	"html/template"
 	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
 
+	"forum/backend/auth"
+	"forum/backend/controllers/structs"
+	"forum/backend/csrf"
+	"forum/backend/moderation"
+	"forum/backend/rbac"
 	"forum/backend/requests"
+	"forum/backend/store"
 )
 
+type tagOption struct {
+	structs.Tag
+	Checked bool
+}
+
+type pageData struct {
+	structs.PostWithComments
+	CSRFToken         string
+	Reasons           []string
+	Reported          bool
+	ViewerID          int
+	CanEditPost       bool
+	CanEditOwnComment bool
+	CanEditAnyComment bool
+	CanAccept         bool
+	Tags              []tagOption
+	Sort              string
+	// Thread is the comment whose replies are shown instead of the whole
+	// post's comments, 0 for all of them.
+	Thread int
+}
+
+// commentNode is what the recursive "comment" template gets: one comment of
+// the thread and the page around it.
+type commentNode struct {
+	structs.Comment
+	Page *pageData
+}
+
 func PostPage(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
 		return
 	}
 	postId := r.FormValue("id")
+	sort := string(store.ParseCommentSort(r.FormValue("sort")))
 
-	data, err := requests.GetPostWithComments("http://localhost:8080/api/postandcomments", postId)
+	cookieValue := ""
+	if cookie, err := r.Cookie("session_token"); err == nil {
+		cookieValue = cookie.Value
+	}
+
+	data, err := requests.GetPostWithComments("http://localhost:8080/api/postandcomments", postId, sort, cookieValue, r.FormValue("cursor"))
 	if err != nil {
 		http.Error(w, "ERROR: Cannot get post and comments", http.StatusBadRequest)
 		return
 	}
 
-	tmpl, err := template.ParseFiles("frontend/pages/postPage/postPage.html")
+	// Following a thread deeper than the post shows it.
+	thread, _ := strconv.Atoi(r.FormValue("thread"))
+	if thread != 0 {
+		comment, err := requests.GetRepliesRequest("http://localhost:8080/api/replies", strconv.Itoa(thread), sort, cookieValue)
+		if err != nil || comment.PostId != data.Post.ID {
+			http.Error(w, "ERROR: Cannot get replies", http.StatusBadRequest)
+			return
+		}
+		data.Comments = []structs.Comment{comment}
+		data.Cursors = structs.Cursors{}
+	}
+
+	page := &pageData{}
+	tmpl, err := template.New("postPage.html").Funcs(template.FuncMap{
+		"node": func(comment structs.Comment) commentNode { return commentNode{comment, page} },
+		// The HTML was sanitized when it was rendered from Markdown.
+		"rendered":   func(html string) template.HTML { return template.HTML(html) },
+		"pathEscape": url.PathEscape,
+	}).ParseFiles("frontend/pages/postPage/postPage.html")
 	if err != nil {
 		http.Error(w, "ERROR: Unable to parse template", http.StatusInternalServerError)
 		return
 	}
 
-	err = tmpl.Execute(w, data)
+	// What the viewer may edit: their own posts and comments, or anyone's
+	// with the permission.
+	var viewer structs.User
+	repos := store.Get()
+	if authenticated, userId, _ := auth.IsAuthenticated(r, repos.Sessions()); authenticated {
+		viewer, _ = repos.Users().ByID(userId)
+	}
+	canEditPost := data.Post.DeletedAt.IsZero() &&
+		(auth.Can(viewer, rbac.PostEditAny) || (viewer.ID == data.Post.UserID && auth.Can(viewer, rbac.PostEditOwn)))
+	canAccept := data.Post.DeletedAt.IsZero() && data.Post.Question &&
+		(auth.Can(viewer, rbac.AnswerAcceptAny) || (viewer.ID == data.Post.UserID && auth.Can(viewer, rbac.PostEditOwn)))
+
+	var tags []tagOption
+	if canEditPost {
+		allTags, err := requests.GetTags("http://localhost:8080/api/tags")
+		if err != nil {
+			http.Error(w, "ERROR: Cannot get tags", http.StatusBadRequest)
+			return
+		}
+		for _, tag := range allTags {
+			checked := slices.ContainsFunc(data.Post.Tags, func(t structs.Tag) bool { return t.ID == tag.ID })
+			tags = append(tags, tagOption{tag, checked})
+		}
+	}
+
+	*page = pageData{data, csrf.FromRequest(r), moderation.Reasons, r.FormValue("reported") != "",
+		viewer.ID, canEditPost, auth.Can(viewer, rbac.CommentEditOwn), auth.Can(viewer, rbac.CommentEditAny), canAccept,
+		tags, sort, thread}
+
+	err = tmpl.Execute(w, page)
 	if err != nil {
 		http.Error(w, "ERROR: Unable to execute template", http.StatusInternalServerError)
 		return
@@ -48,12 +139,17 @@ func PostPageCreateComment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := requests.CreateCommentRequest("http://localhost:8080/api/createcomment", postId, comment, cookie.Value)
+	err := requests.CreateCommentRequest("http://localhost:8080/api/createcomment", postId, r.FormValue("parent"), comment, cookie.Value)
 	if err != nil {
 		http.Error(w, "ERROR: Bad request", http.StatusBadRequest)
 		return
 	}
 
+	// Replies on a followed thread go back to it.
+	if thread := r.FormValue("thread"); thread != "" {
+		http.Redirect(w, r, "/post?id="+postId+"&thread="+url.QueryEscape(thread), http.StatusSeeOther)
+		return
+	}
 	http.Redirect(w, r, "/post?id="+postId, http.StatusSeeOther)
 }
 
@@ -106,3 +202,97 @@ func PostPageDownVote(w http.ResponseWriter, r *http.Request) {
 
 	http.Redirect(w, r, "/post?id="+postId, http.StatusSeeOther)
 }
+
+func PostPageReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	postId := r.FormValue("post_id")
+
+	cookie, cookieErr := r.Cookie("session_token")
+	if cookieErr != nil {
+		http.Error(w, "ERROR: You are not authorized to report", http.StatusUnauthorized)
+		return
+	}
+
+	err := requests.ReportRequest("http://localhost:8080/api/report", r.FormValue("id"), r.FormValue("isComment"),
+		r.FormValue("reason"), r.FormValue("details"), cookie.Value)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	http.Redirect(w, r, "/post?id="+postId+"&reported=1", http.StatusSeeOther)
+}
+
+func PostPageEditPost(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	postId := r.FormValue("id")
+
+	cookie, cookieErr := r.Cookie("session_token")
+	if cookieErr != nil {
+		http.Error(w, "ERROR: You are not authorized to edit post", http.StatusUnauthorized)
+		return
+	}
+
+	err := requests.EditPostRequest("http://localhost:8080/api/editpost", postId, r.FormValue("title"), r.FormValue("content"),
+		r.Form["tags"], cookie.Value)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	http.Redirect(w, r, "/post?id="+postId, http.StatusSeeOther)
+}
+
+func PostPageEditComment(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	postId := r.FormValue("post_id")
+
+	cookie, cookieErr := r.Cookie("session_token")
+	if cookieErr != nil {
+		http.Error(w, "ERROR: You are not authorized to edit comment", http.StatusUnauthorized)
+		return
+	}
+
+	err := requests.EditCommentRequest("http://localhost:8080/api/editcomment", r.FormValue("id"), r.FormValue("comment"), cookie.Value)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	http.Redirect(w, r, "/post?id="+postId, http.StatusSeeOther)
+}
+
+func PostPageAcceptAnswer(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	postId := r.FormValue("post_id")
+
+	cookie, cookieErr := r.Cookie("session_token")
+	if cookieErr != nil {
+		http.Error(w, "ERROR: You are not authorized to accept answers", http.StatusUnauthorized)
+		return
+	}
+
+	err := requests.AcceptAnswerRequest("http://localhost:8080/api/acceptanswer", r.FormValue("id"), r.FormValue("undo"), cookie.Value)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	http.Redirect(w, r, "/post?id="+postId, http.StatusSeeOther)
+}
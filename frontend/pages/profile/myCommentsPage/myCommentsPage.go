@@ -4,6 +4,8 @@ import (
 	"html/template"
 	"net/http"
 
+	"forum/backend/controllers/structs"
+	"forum/backend/csrf"
 	"forum/backend/requests"
 )
 
@@ -19,7 +21,7 @@ func MyCommentsPage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	data, errReq := requests.GetCommentDataForServeWithReq("http://localhost:8080/api/mycomments", cookie.Value)
+	data, errReq := requests.GetCommentDataForServeWithReq("http://localhost:8080/api/mycomments", cookie.Value, r.FormValue("cursor"))
 	if errReq != nil {
 		http.Error(w, "ERROR: Bad request", http.StatusBadRequest)
 		return
@@ -31,7 +33,12 @@ func MyCommentsPage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = tmpl.Execute(w, data)
+	page := struct {
+		structs.CommentList
+		CSRFToken string
+	}{data, csrf.FromRequest(r)}
+
+	err = tmpl.Execute(w, page)
 	if err != nil {
 		http.Error(w, "ERROR: Unable to execute template", http.StatusInternalServerError)
 		return
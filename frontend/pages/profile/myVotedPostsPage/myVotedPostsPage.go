@@ -1,8 +1,8 @@
 package myvotedpostspage
 
 import (
+	"html/template"
 	"net/http"
-	"text/template"
 
 	"forum/backend/requests"
 )
@@ -19,7 +19,7 @@ func MyVotedPostsPage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	data, errReq := requests.GetDataForServeWithReq("http://localhost:8080/api/myvotedposts", cookie.Value)
+	data, errReq := requests.GetDataForServeWithReq("http://localhost:8080/api/myvotedposts", cookie.Value, r.FormValue("cursor"))
 	if errReq != nil {
 		http.Error(w, "ERROR: Bad request", http.StatusBadRequest)
 		return
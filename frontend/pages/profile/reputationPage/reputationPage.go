@@ -0,0 +1,69 @@
+package reputationpage
+
+import (
+	"html/template"
+	"net/http"
+
+	"forum/backend/controllers/structs"
+	"forum/backend/rbac"
+	"forum/backend/reputation"
+	"forum/backend/requests"
+)
+
+type privilege struct {
+	Name      string
+	Threshold int
+	Earned    bool
+}
+
+func ReputationPage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	cookie, cookieErr := r.Cookie("session_token")
+	if cookieErr != nil {
+		http.Error(w, "ERROR: You are not authorized to see your reputation", http.StatusUnauthorized)
+		return
+	}
+
+	history, errReq := requests.GetReputationRequest("http://localhost:8080/api/reputation", "", cookie.Value, r.FormValue("cursor"))
+	if errReq != nil {
+		http.Error(w, "ERROR: Bad request", http.StatusBadRequest)
+		return
+	}
+
+	var privileges []privilege
+	for _, p := range []struct {
+		name       string
+		permission rbac.Permission
+	}{
+		{"Downvote posts and comments", rbac.VoteDown},
+		{"Create tags", rbac.TagCreate},
+		{"Edit any post", rbac.PostEditAny},
+	} {
+		threshold, _ := reputation.Threshold(p.permission)
+		privileges = append(privileges, privilege{p.name, threshold, history.Reputation >= threshold})
+	}
+
+	tmpl, err := template.ParseFiles("frontend/pages/profile/reputationPage/reputationPage.html")
+	if err != nil {
+		http.Error(w, "ERROR: Unable to parse template", http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Reputation int
+		Privileges []privilege
+		Events     []structs.ReputationEvent
+		NextCursor string
+		PrevCursor string
+	}{history.Reputation, privileges, history.Events, history.NextCursor, history.PrevCursor}
+
+	err = tmpl.Execute(w, data)
+	if err != nil {
+		http.Error(w, "ERROR: Unable to execute template", http.StatusInternalServerError)
+		return
+	}
+}
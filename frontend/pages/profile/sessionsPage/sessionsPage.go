@@ -0,0 +1,98 @@
+package sessionspage
+
+import (
+	"html/template"
+	"net/http"
+
+	"forum/backend/auth"
+	"forum/backend/controllers/structs"
+	"forum/backend/csrf"
+	"forum/backend/requests"
+)
+
+func SessionsPage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	cookie, cookieErr := r.Cookie("session_token")
+	if cookieErr != nil {
+		http.Error(w, "ERROR: You are not authorized to see sessions", http.StatusUnauthorized)
+		return
+	}
+
+	sessions, errReq := requests.GetSessionsRequest("http://localhost:8080/api/sessions", cookie.Value)
+	if errReq != nil {
+		http.Error(w, "ERROR: Bad request", http.StatusBadRequest)
+		return
+	}
+
+	tmpl, err := template.ParseFiles("frontend/pages/profile/sessionsPage/sessionsPage.html")
+	if err != nil {
+		http.Error(w, "ERROR: Unable to parse template", http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Sessions  []structs.Session
+		CSRFToken string
+	}{sessions, csrf.FromRequest(r)}
+
+	err = tmpl.Execute(w, data)
+	if err != nil {
+		http.Error(w, "ERROR: Unable to execute template", http.StatusInternalServerError)
+		return
+	}
+}
+
+func DeleteSession(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sessionId := r.FormValue("id")
+
+	cookie, cookieErr := r.Cookie("session_token")
+	if cookieErr != nil {
+		http.Error(w, "ERROR: You are not authorized to revoke sessions", http.StatusUnauthorized)
+		return
+	}
+
+	err := requests.DeleteSessionRequest("http://localhost:8080/api/deletesession", sessionId, cookie.Value)
+	if err != nil {
+		http.Error(w, "ERROR: Bad request", http.StatusBadRequest)
+		return
+	}
+
+	if r.FormValue("current") == "true" {
+		auth.RemoveCookie(w, r, auth.SessionCookieName)
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+
+	http.Redirect(w, r, "/sessions", http.StatusSeeOther)
+}
+
+func LogoutEverywhere(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	cookie, cookieErr := r.Cookie("session_token")
+	if cookieErr != nil {
+		http.Error(w, "ERROR: You are not logged in", http.StatusUnauthorized)
+		return
+	}
+
+	err := requests.LogoutEverywhereRequest("http://localhost:8080/api/logouteverywhere", cookie.Value)
+	if err != nil {
+		http.Error(w, "ERROR: Bad request", http.StatusBadRequest)
+		return
+	}
+
+	auth.RemoveCookie(w, r, auth.SessionCookieName)
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
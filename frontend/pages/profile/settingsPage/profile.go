@@ -0,0 +1,94 @@
+package settingspage
+
+import (
+	"html/template"
+	"io"
+	"net/http"
+	"net/url"
+
+	"forum/backend/avatar"
+	"forum/backend/controllers/structs"
+	"forum/backend/csrf"
+	"forum/backend/requests"
+)
+
+func ProfilePage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	cookie, cookieErr := r.Cookie("session_token")
+	if cookieErr != nil {
+		http.Error(w, "ERROR: You are not logged in", http.StatusUnauthorized)
+		return
+	}
+
+	profile, errReq := requests.GetProfileRequest("http://localhost:8080/api/profile", "", cookie.Value)
+	if errReq != nil {
+		http.Error(w, "ERROR: Bad request", http.StatusBadRequest)
+		return
+	}
+
+	tmpl, err := template.New("profilePage.html").Funcs(template.FuncMap{
+		"pathEscape": url.PathEscape,
+	}).ParseFiles("frontend/pages/profile/settingsPage/profilePage.html")
+	if err != nil {
+		http.Error(w, "ERROR: Unable to parse template", http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Profile   structs.Profile
+		CSRFToken string
+	}{profile.Profile, csrf.FromRequest(r)}
+
+	err = tmpl.Execute(w, data)
+	if err != nil {
+		http.Error(w, "ERROR: Unable to execute template", http.StatusInternalServerError)
+		return
+	}
+}
+
+func UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	cookie, cookieErr := r.Cookie("session_token")
+	if cookieErr != nil {
+		http.Error(w, "ERROR: You are not logged in", http.StatusUnauthorized)
+		return
+	}
+
+	err := r.ParseMultipartForm(avatar.MaxSize)
+	if err != nil {
+		http.Error(w, "ERROR: The avatar must be under 2MB", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	profile := structs.Profile{
+		DisplayName: r.FormValue("displayname"),
+		Bio:         r.FormValue("bio"),
+		Website:     r.FormValue("website"),
+		Location:    r.FormValue("location"),
+	}
+
+	var image io.Reader
+	var imageName string
+	file, handler, err := r.FormFile("avatar")
+	if err == nil {
+		defer file.Close()
+		image, imageName = file, handler.Filename
+	}
+
+	err = requests.UpdateProfileRequest("http://localhost:8080/api/updateprofile", profile, r.FormValue("removeavatar") == "true",
+		cookie.Value, image, imageName)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	http.Redirect(w, r, "/settings/profile", http.StatusSeeOther)
+}
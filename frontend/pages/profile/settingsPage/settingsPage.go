@@ -0,0 +1,135 @@
+package settingspage
+
+import (
+	"html/template"
+	"net/http"
+
+	"forum/backend/controllers/structs"
+	"forum/backend/csrf"
+	"forum/backend/oauth"
+	"forum/backend/requests"
+)
+
+type linkedLogin struct {
+	structs.Identity
+	DisplayName string
+}
+
+func SettingsPage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	cookie, cookieErr := r.Cookie("session_token")
+	if cookieErr != nil {
+		http.Error(w, "ERROR: You are not logged in", http.StatusUnauthorized)
+		return
+	}
+
+	methods, errReq := requests.GetLoginMethodsRequest("http://localhost:8080/api/loginmethods", cookie.Value)
+	if errReq != nil {
+		http.Error(w, "ERROR: Bad request", http.StatusBadRequest)
+		return
+	}
+
+	twoFactor, errReq := requests.GetTwoFactorRequest("http://localhost:8080/api/twofactor", cookie.Value)
+	if errReq != nil {
+		http.Error(w, "ERROR: Bad request", http.StatusBadRequest)
+		return
+	}
+
+	var logins []linkedLogin
+	for _, identity := range methods.Identities {
+		displayName := identity.Provider
+		if provider, ok := oauth.Get(identity.Provider); ok {
+			displayName = provider.DisplayName()
+		}
+		logins = append(logins, linkedLogin{identity, displayName})
+	}
+
+	tmpl, err := template.ParseFiles("frontend/pages/profile/settingsPage/settingsPage.html")
+	if err != nil {
+		http.Error(w, "ERROR: Unable to parse template", http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Email         string
+		EmailVerified bool
+		HasPassword   bool
+		TwoFactor     structs.TwoFactorStatus
+		Logins        []linkedLogin
+		Providers     []oauth.Button
+		CSRFToken     string
+	}{methods.Email, methods.EmailVerified, methods.HasPassword, twoFactor, logins, oauth.Buttons(), csrf.FromRequest(r)}
+
+	err = tmpl.Execute(w, data)
+	if err != nil {
+		http.Error(w, "ERROR: Unable to execute template", http.StatusInternalServerError)
+		return
+	}
+}
+
+func UpdatePassword(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	cookie, cookieErr := r.Cookie("session_token")
+	if cookieErr != nil {
+		http.Error(w, "ERROR: You are not logged in", http.StatusUnauthorized)
+		return
+	}
+
+	err := requests.UpdatePasswordRequest("http://localhost:8080/api/updatepassword", r.FormValue("currentpassword"), r.FormValue("password"), cookie.Value, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	http.Redirect(w, r, "/settings", http.StatusSeeOther)
+}
+
+func UnlinkLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	cookie, cookieErr := r.Cookie("session_token")
+	if cookieErr != nil {
+		http.Error(w, "ERROR: You are not logged in", http.StatusUnauthorized)
+		return
+	}
+
+	err := requests.DeleteIdentityRequest("http://localhost:8080/api/deleteidentity", r.FormValue("id"), cookie.Value)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	http.Redirect(w, r, "/settings", http.StatusSeeOther)
+}
+
+func SendVerification(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	cookie, cookieErr := r.Cookie("session_token")
+	if cookieErr != nil {
+		http.Error(w, "ERROR: You are not logged in", http.StatusUnauthorized)
+		return
+	}
+
+	err := requests.SendVerificationRequest("http://localhost:8080/api/sendverification", cookie.Value)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	http.Redirect(w, r, "/settings", http.StatusSeeOther)
+}
@@ -0,0 +1,140 @@
+package settingspage
+
+import (
+	"html/template"
+	"net/http"
+
+	"forum/backend/controllers/structs"
+	"forum/backend/csrf"
+	"forum/backend/requests"
+)
+
+type twoFactorData struct {
+	Status structs.TwoFactorStatus
+	// Setup is shown while the user adds the account to their app.
+	Setup *structs.TwoFactorSetup
+	// RecoveryCodes are shown once, right after they were made.
+	RecoveryCodes []string
+	CSRFToken     string
+}
+
+func TwoFactorPage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	cookie, cookieErr := r.Cookie("session_token")
+	if cookieErr != nil {
+		http.Error(w, "ERROR: You are not logged in", http.StatusUnauthorized)
+		return
+	}
+
+	renderTwoFactor(w, r, cookie.Value, twoFactorData{})
+}
+
+func SetupTwoFactor(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	cookie, cookieErr := r.Cookie("session_token")
+	if cookieErr != nil {
+		http.Error(w, "ERROR: You are not logged in", http.StatusUnauthorized)
+		return
+	}
+
+	setup, err := requests.SetupTwoFactorRequest("http://localhost:8080/api/setuptwofactor", cookie.Value)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	renderTwoFactor(w, r, cookie.Value, twoFactorData{Setup: &setup})
+}
+
+func EnableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	cookie, cookieErr := r.Cookie("session_token")
+	if cookieErr != nil {
+		http.Error(w, "ERROR: You are not logged in", http.StatusUnauthorized)
+		return
+	}
+
+	codes, err := requests.EnableTwoFactorRequest("http://localhost:8080/api/enabletwofactor", r.FormValue("code"), cookie.Value)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	renderTwoFactor(w, r, cookie.Value, twoFactorData{RecoveryCodes: codes})
+}
+
+func DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	cookie, cookieErr := r.Cookie("session_token")
+	if cookieErr != nil {
+		http.Error(w, "ERROR: You are not logged in", http.StatusUnauthorized)
+		return
+	}
+
+	err := requests.DisableTwoFactorRequest("http://localhost:8080/api/disabletwofactor", r.FormValue("code"), cookie.Value)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	http.Redirect(w, r, "/settings/twofactor", http.StatusSeeOther)
+}
+
+func RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	cookie, cookieErr := r.Cookie("session_token")
+	if cookieErr != nil {
+		http.Error(w, "ERROR: You are not logged in", http.StatusUnauthorized)
+		return
+	}
+
+	codes, err := requests.RegenerateRecoveryCodesRequest("http://localhost:8080/api/regeneraterecoverycodes", r.FormValue("code"), cookie.Value)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	renderTwoFactor(w, r, cookie.Value, twoFactorData{RecoveryCodes: codes})
+}
+
+func renderTwoFactor(w http.ResponseWriter, r *http.Request, cookieValue string, data twoFactorData) {
+	status, err := requests.GetTwoFactorRequest("http://localhost:8080/api/twofactor", cookieValue)
+	if err != nil {
+		http.Error(w, "ERROR: Bad request", http.StatusBadRequest)
+		return
+	}
+	data.Status = status
+	data.CSRFToken = csrf.FromRequest(r)
+
+	tmpl, err := template.ParseFiles("frontend/pages/profile/settingsPage/twoFactorPage.html")
+	if err != nil {
+		http.Error(w, "ERROR: Unable to parse template", http.StatusInternalServerError)
+		return
+	}
+
+	err = tmpl.Execute(w, data)
+	if err != nil {
+		http.Error(w, "ERROR: Unable to execute template", http.StatusInternalServerError)
+		return
+	}
+}
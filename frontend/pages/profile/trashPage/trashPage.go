@@ -0,0 +1,100 @@
+package trashpage
+
+import (
+	"html/template"
+	"net/http"
+	"time"
+
+	"forum/backend/csrf"
+	"forum/backend/requests"
+)
+
+type trashItem struct {
+	ID          int
+	IsComment   bool
+	PostID      int
+	Text        string
+	DeletedAt   time.Time
+	PurgeAt     time.Time
+	ByModerator bool
+}
+
+func TrashPage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	cookie, cookieErr := r.Cookie("session_token")
+	if cookieErr != nil {
+		http.Error(w, "ERROR: You are not authorized to see the trash", http.StatusUnauthorized)
+		return
+	}
+
+	trash, errReq := requests.GetTrashRequest("http://localhost:8080/api/trash", cookie.Value)
+	if errReq != nil {
+		http.Error(w, "ERROR: Bad request", http.StatusBadRequest)
+		return
+	}
+
+	var items []trashItem
+	for _, post := range trash.Posts {
+		items = append(items, trashItem{
+			ID:          post.ID,
+			PostID:      post.ID,
+			Text:        post.Title,
+			DeletedAt:   post.DeletedAt,
+			PurgeAt:     post.DeletedAt.Add(trash.Retention),
+			ByModerator: post.DeletedBy != post.UserID,
+		})
+	}
+	for _, comment := range trash.Comments {
+		items = append(items, trashItem{
+			ID:          comment.ID,
+			IsComment:   true,
+			PostID:      comment.PostId,
+			Text:        comment.Comment,
+			DeletedAt:   comment.DeletedAt,
+			PurgeAt:     comment.DeletedAt.Add(trash.Retention),
+			ByModerator: comment.DeletedBy != comment.UserId,
+		})
+	}
+
+	tmpl, err := template.ParseFiles("frontend/pages/profile/trashPage/trashPage.html")
+	if err != nil {
+		http.Error(w, "ERROR: Unable to parse template", http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Items     []trashItem
+		CSRFToken string
+	}{items, csrf.FromRequest(r)}
+
+	err = tmpl.Execute(w, data)
+	if err != nil {
+		http.Error(w, "ERROR: Unable to execute template", http.StatusInternalServerError)
+		return
+	}
+}
+
+func Restore(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	cookie, cookieErr := r.Cookie("session_token")
+	if cookieErr != nil {
+		http.Error(w, "ERROR: You are not authorized to restore", http.StatusUnauthorized)
+		return
+	}
+
+	err := requests.RestoreRequest("http://localhost:8080/api/restore", r.FormValue("id"), r.FormValue("isComment"), cookie.Value)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	http.Redirect(w, r, "/trash", http.StatusSeeOther)
+}
@@ -1,8 +1,11 @@
 package registerpage
 
 import (
+	"html/template"
 	"net/http"
 
+	"forum/backend/csrf"
+	"forum/backend/oauth"
 	"forum/backend/requests"
 )
 
@@ -11,13 +14,28 @@ const registerApiUrl = "http://localhost:8080/api/register"
 func RegisterPage(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case "GET":
-		http.ServeFile(w, r, "frontend/pages/registerPage/registerPage.html")
+		tmpl, err := template.ParseFiles("frontend/pages/registerPage/registerPage.html")
+		if err != nil {
+			http.Error(w, "ERROR: Unable to parse template", http.StatusInternalServerError)
+			return
+		}
+
+		data := struct {
+			Providers []oauth.Button
+			CSRFToken string
+		}{oauth.Buttons(), csrf.FromRequest(r)}
+
+		err = tmpl.Execute(w, data)
+		if err != nil {
+			http.Error(w, "ERROR: Unable to execute template", http.StatusInternalServerError)
+			return
+		}
 	case "POST":
 		email := r.FormValue("email")
 		userName := r.FormValue("username")
 		password := r.FormValue("password")
 
-		err := requests.RegisterRequest(registerApiUrl, email, userName, password)
+		err := requests.RegisterRequest(registerApiUrl, email, userName, password, r)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
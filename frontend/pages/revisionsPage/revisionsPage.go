@@ -0,0 +1,111 @@
+package revisionspage
+
+import (
+	"html/template"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"forum/backend/controllers/structs"
+	"forum/backend/diff"
+	"forum/backend/requests"
+)
+
+type version struct {
+	structs.Revision
+	Number int
+	// Previous is the number of the version before, or 0 for the first.
+	Previous int
+}
+
+// RevisionsPage lists the versions of a post or comment and compares two of
+// them, by default the current one and the one before it.
+func RevisionsPage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	cookieValue := ""
+	if cookie, err := r.Cookie("session_token"); err == nil {
+		cookieValue = cookie.Value
+	}
+
+	isComment := r.FormValue("comment")
+	history, err := requests.GetRevisionsRequest("http://localhost:8080/api/revisions", r.FormValue("id"), isComment, cookieValue)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	versions := make([]version, len(history.Versions))
+	for i, revision := range history.Versions {
+		versions[i] = version{revision, i + 1, i}
+	}
+
+	to, ok := number(r.FormValue("to"), len(versions), len(versions))
+	if !ok {
+		http.Error(w, "ERROR: No such version", http.StatusBadRequest)
+		return
+	}
+	from, ok := number(r.FormValue("from"), max(to-1, 1), len(versions))
+	if !ok {
+		http.Error(w, "ERROR: No such version", http.StatusBadRequest)
+		return
+	}
+	older, newer := versions[from-1], versions[to-1]
+
+	tmpl, err := template.ParseFiles("frontend/pages/revisionsPage/revisionsPage.html")
+	if err != nil {
+		http.Error(w, "ERROR: Unable to parse template", http.StatusInternalServerError)
+		return
+	}
+
+	page := struct {
+		History     structs.RevisionHistory
+		Comment     string
+		Versions    []version
+		From, To    version
+		Lines       []diff.Line
+		Changed     bool
+		TagsRemoved []string
+		TagsAdded   []string
+	}{
+		History:     history,
+		Comment:     isComment,
+		Versions:    versions,
+		From:        older,
+		To:          newer,
+		Lines:       diff.Lines(older.Body, newer.Body),
+		TagsRemoved: missing(older.Tags, newer.Tags),
+		TagsAdded:   missing(newer.Tags, older.Tags),
+	}
+	page.Changed = diff.Changed(page.Lines)
+
+	err = tmpl.Execute(w, page)
+	if err != nil {
+		http.Error(w, "ERROR: Unable to execute template", http.StatusInternalServerError)
+		return
+	}
+}
+
+// number reads a version number from 1 to last, or gives the default if
+// the value is empty.
+func number(value string, def, last int) (int, bool) {
+	if value == "" {
+		return def, true
+	}
+	n, err := strconv.Atoi(value)
+	return n, err == nil && n >= 1 && n <= last
+}
+
+// missing returns the tags in a that aren't in b.
+func missing(a, b []string) []string {
+	var tags []string
+	for _, tag := range a {
+		if !slices.Contains(b, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
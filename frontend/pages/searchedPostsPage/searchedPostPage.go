@@ -4,6 +4,7 @@ import (
 	"html/template"
 	"net/http"
 
+	"forum/backend/controllers/structs"
 	"forum/backend/requests"
 )
 
@@ -17,19 +18,32 @@ func SearchedPostsPage(w http.ResponseWriter, r *http.Request) {
 	category := r.FormValue("category")
 	search := r.FormValue("search")
 
-	data, err := requests.GetSearchedDataForServeWithReq("http://localhost:8080/api/searchedposts", filter, category, search)
+	data, err := requests.GetSearchedDataForServeWithReq("http://localhost:8080/api/searchedposts", filter, category, search, r.FormValue("cursor"))
 	if err != nil {
 		http.Error(w, "ERROR: Cannot get post", http.StatusBadRequest)
 		return
 	}
 
-	tmpl, err := template.ParseFiles("frontend/pages/searchedPostsPage/searchedPostsPage.html")
+	// Snippets come back from the API already escaped, with <mark> around
+	// the matched words.
+	funcs := template.FuncMap{
+		"snippet": func(s string) template.HTML { return template.HTML(s) },
+	}
+
+	tmpl, err := template.New("searchedPostsPage.html").Funcs(funcs).ParseFiles("frontend/pages/searchedPostsPage/searchedPostsPage.html")
 	if err != nil {
 		http.Error(w, "ERROR: Unable to parse template", http.StatusInternalServerError)
 		return
 	}
 
-	err = tmpl.Execute(w, data)
+	page := struct {
+		structs.PostList
+		Search   string
+		Filter   string
+		Category string
+	}{data, search, filter, category}
+
+	err = tmpl.Execute(w, page)
 	if err != nil {
 		http.Error(w, "ERROR: Unable to execute template", http.StatusInternalServerError)
 		return
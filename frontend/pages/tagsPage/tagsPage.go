@@ -0,0 +1,74 @@
+package tagspage
+
+import (
+	"html/template"
+	"net/http"
+
+	"forum/backend/auth"
+	"forum/backend/controllers/structs"
+	"forum/backend/csrf"
+	"forum/backend/rbac"
+	"forum/backend/requests"
+	"forum/backend/store"
+)
+
+func TagsPage(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		repos := store.Get()
+
+		isAdmin := false
+		authenticated, userId, _ := auth.IsAuthenticated(r, repos.Sessions())
+		if authenticated {
+			user, err := repos.Users().ByID(userId)
+			isAdmin = err == nil && auth.Can(user, rbac.TagCreate)
+		}
+
+		tags, err := requests.GetTags("http://localhost:8080/api/tags")
+		if err != nil {
+			http.Error(w, "ERROR: Could not fetch tags", http.StatusInternalServerError)
+			return
+		}
+
+		tmpl, err := template.ParseFiles("frontend/pages/tagsPage/tagsPage.html")
+		if err != nil {
+			http.Error(w, "ERROR: Unable to parse template", http.StatusInternalServerError)
+			return
+		}
+
+		data := struct {
+			Tags      []structs.Tag
+			IsAdmin   bool
+			CSRFToken string
+		}{tags, isAdmin, csrf.FromRequest(r)}
+
+		err = tmpl.Execute(w, data)
+		if err != nil {
+			http.Error(w, "ERROR: Unable to execute template", http.StatusInternalServerError)
+			return
+		}
+	case "POST":
+		cookie, cookieErr := r.Cookie("session_token")
+		if cookieErr != nil {
+			http.Error(w, "ERROR: You are not authorized to create tag", http.StatusUnauthorized)
+			return
+		}
+
+		tag := structs.Tag{
+			Slug:        r.FormValue("slug"),
+			Name:        r.FormValue("name"),
+			Description: r.FormValue("description"),
+			Color:       r.FormValue("color"),
+		}
+
+		err := requests.CreateTagRequest("http://localhost:8080/api/createtag", tag, cookie.Value)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		http.Redirect(w, r, "/tags", http.StatusSeeOther)
+	default:
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,46 @@
+package unlockaccountpage
+
+import (
+	"html/template"
+	"net/http"
+
+	"forum/backend/csrf"
+	"forum/backend/requests"
+)
+
+// UnlockAccountPage asks for a click before unlocking, so mail scanners that
+// follow the link don't unlock the account on their own.
+func UnlockAccountPage(w http.ResponseWriter, r *http.Request) {
+	data := struct {
+		Token     string
+		Unlocked  bool
+		CSRFToken string
+	}{CSRFToken: csrf.FromRequest(r)}
+
+	switch r.Method {
+	case "GET":
+		data.Token = r.URL.Query().Get("token")
+	case "POST":
+		err := requests.UnlockAccountRequest("http://localhost:8080/api/unlockaccount", r.FormValue("token"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		data.Unlocked = true
+	default:
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	tmpl, err := template.ParseFiles("frontend/pages/unlockAccountPage/unlockAccountPage.html")
+	if err != nil {
+		http.Error(w, "ERROR: Unable to parse template", http.StatusInternalServerError)
+		return
+	}
+
+	err = tmpl.Execute(w, data)
+	if err != nil {
+		http.Error(w, "ERROR: Unable to execute template", http.StatusInternalServerError)
+		return
+	}
+}
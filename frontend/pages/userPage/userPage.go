@@ -0,0 +1,68 @@
+package userpage
+
+import (
+	"html/template"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"forum/backend/requests"
+)
+
+// excerptLength is how much of a comment the profile shows, in characters.
+const excerptLength = 140
+
+// UserPage is the public profile at /user/{username}.
+func UserPage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userName := strings.TrimPrefix(r.URL.Path, "/user/")
+	if userName == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	cookieValue := ""
+	if cookie, err := r.Cookie("session_token"); err == nil {
+		cookieValue = cookie.Value
+	}
+
+	profile, err := requests.GetProfileRequest("http://localhost:8080/api/profile", userName, cookieValue)
+	if err != nil {
+		http.Error(w, "ERROR: User not found", http.StatusNotFound)
+		return
+	}
+
+	tmpl, err := template.New("userPage.html").Funcs(template.FuncMap{
+		"excerpt": excerpt,
+		"initial": initial,
+	}).ParseFiles("frontend/pages/userPage/userPage.html")
+	if err != nil {
+		http.Error(w, "ERROR: Unable to parse template", http.StatusInternalServerError)
+		return
+	}
+
+	err = tmpl.Execute(w, profile)
+	if err != nil {
+		http.Error(w, "ERROR: Unable to execute template", http.StatusInternalServerError)
+		return
+	}
+}
+
+// excerpt shortens text to excerptLength characters on one line.
+func excerpt(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= excerptLength {
+		return text
+	}
+	return string([]rune(text)[:excerptLength]) + "…"
+}
+
+// initial is what stands in for a missing avatar.
+func initial(userName string) string {
+	r, _ := utf8.DecodeRuneInString(userName)
+	return strings.ToUpper(string(r))
+}
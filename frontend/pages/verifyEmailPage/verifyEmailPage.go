@@ -0,0 +1,46 @@
+package verifyemailpage
+
+import (
+	"html/template"
+	"net/http"
+
+	"forum/backend/csrf"
+	"forum/backend/requests"
+)
+
+// VerifyEmailPage asks for a click before confirming, so mail scanners that
+// follow the link don't verify the address on their own.
+func VerifyEmailPage(w http.ResponseWriter, r *http.Request) {
+	data := struct {
+		Token     string
+		Verified  bool
+		CSRFToken string
+	}{CSRFToken: csrf.FromRequest(r)}
+
+	switch r.Method {
+	case "GET":
+		data.Token = r.URL.Query().Get("token")
+	case "POST":
+		err := requests.VerifyEmailRequest("http://localhost:8080/api/verifyemail", r.FormValue("token"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		data.Verified = true
+	default:
+		http.Error(w, "ERROR: Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	tmpl, err := template.ParseFiles("frontend/pages/verifyEmailPage/verifyEmailPage.html")
+	if err != nil {
+		http.Error(w, "ERROR: Unable to parse template", http.StatusInternalServerError)
+		return
+	}
+
+	err = tmpl.Execute(w, data)
+	if err != nil {
+		http.Error(w, "ERROR: Unable to execute template", http.StatusInternalServerError)
+		return
+	}
+}
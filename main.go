@@ -1,15 +1,365 @@
 package main
 
 import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"forum/backend/auth"
+	"forum/backend/config"
 	"forum/backend/database"
 	"forum/backend/handlers"
+	"forum/backend/mail"
+	"forum/backend/markdown"
+	"forum/backend/purge"
+	"forum/backend/ratelimit"
+	"forum/backend/rbac"
 	"forum/backend/server"
+	"forum/backend/store"
+	"forum/backend/votes"
 )
 
 func main() {
-	database.CreateDatabaseIfNotExists()
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, "ERROR:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	cfg := config.Load()
+
+	database.Init(cfg)
+	defer database.Close()
+
+	mailer, err := mail.New(cfg.Mail)
+	if err != nil {
+		log.Fatal(err)
+	}
+	mail.Set(mailer)
+
+	limiter, err := newLoginLimiter(cfg, store.Get())
+	if err != nil {
+		log.Fatal(err)
+	}
+	ratelimit.Set(limiter)
+
+	// Posts and comments from before Markdown rendering have no HTML yet.
+	posts, comments, err := markdown.RenderStored(store.Get(), false)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if posts+comments > 0 {
+		log.Printf("Rendered Markdown for %d posts and %d comments", posts, comments)
+	}
+
+	go purge.Run(store.Get(), cfg.TrashRetention, cfg.PurgeInterval)
 
 	handlers.ImportHandlers()
 
 	server.StartServer()
 }
+
+func runCommand(args []string) error {
+	switch args[0] {
+	case "migrate":
+		return runMigrate(args[1:])
+	case "votes":
+		return runVotes(args[1:])
+	case "sessions":
+		return runSessions(args[1:])
+	case "users":
+		return runUsers(args[1:])
+	case "logins":
+		return runLogins(args[1:])
+	case "trash":
+		return runTrash(args[1:])
+	case "markdown":
+		return runMarkdown(args[1:])
+	case "reputation":
+		return runReputation(args[1:])
+	default:
+		return fmt.Errorf("unknown command %q (commands: migrate, votes, sessions, users, logins, trash, markdown, reputation)", args[0])
+	}
+}
+
+func newLoginLimiter(cfg config.Config, st store.Store) (ratelimit.Limiter, error) {
+	switch cfg.LoginLimiter {
+	case config.LimiterMemory:
+		return ratelimit.NewMemory(auth.LoginFailureWindow), nil
+	case config.LimiterDatabase:
+		return ratelimit.NewDatabase(st.RateLimits(), auth.LoginFailureWindow), nil
+	default:
+		return nil, fmt.Errorf("unknown LOGIN_LIMITER %q (want memory or database)", cfg.LoginLimiter)
+	}
+}
+
+func runUsers(args []string) error {
+	switch {
+	case len(args) == 2 && args[0] == "unlock":
+		return unlockUser(args[1])
+	case len(args) == 3 && args[0] == "promote":
+		return promoteUser(args[1], args[2])
+	default:
+		return fmt.Errorf("usage: forum users unlock <email> | forum users promote <email> <role>")
+	}
+}
+
+func unlockUser(email string) error {
+	cfg := config.Load()
+	st, err := database.Connect(cfg)
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+
+	// Counters kept in another process's memory can't be cleared from here;
+	// the lock itself is in the database.
+	limiter, err := newLoginLimiter(cfg, st)
+	if err != nil {
+		return err
+	}
+	ratelimit.Set(limiter)
+
+	user, err := st.Users().ByEmail(email)
+	if err != nil {
+		return fmt.Errorf("no user with email %q", email)
+	}
+	if err := auth.UnlockAccount(st.Users(), user); err != nil {
+		return err
+	}
+	fmt.Printf("Unlocked %s\n", user.UserName)
+	return nil
+}
+
+func promoteUser(email, name string) error {
+	role, ok := rbac.Lookup(name)
+	if !ok {
+		return fmt.Errorf("unknown role %q (roles: %s)", name, joinRoles(rbac.Assignable))
+	}
+
+	st, err := database.Connect(config.Load())
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+
+	user, err := st.Users().ByEmail(email)
+	if err != nil {
+		return fmt.Errorf("no user with email %q", email)
+	}
+	if err := st.Users().SetRole(user.ID, string(role)); err != nil {
+		return err
+	}
+	fmt.Printf("%s is now %s\n", user.UserName, role)
+	user.Role = string(role)
+	if !auth.TwoFactorSatisfied(st.TwoFactor(), user) {
+		fmt.Printf("%s has to turn on two-factor authentication before using the role\n", user.UserName)
+	}
+	return nil
+}
+
+func joinRoles(roles []rbac.Role) string {
+	names := make([]string, len(roles))
+	for i, role := range roles {
+		names[i] = string(role)
+	}
+	return strings.Join(names, ", ")
+}
+
+func runLogins(args []string) error {
+	if len(args) < 1 || len(args) > 2 || args[0] != "failures" {
+		return fmt.Errorf("usage: forum logins failures [count]")
+	}
+
+	limit := 50
+	if len(args) == 2 {
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid count %q", args[1])
+		}
+		limit = n
+	}
+
+	st, err := database.Connect(config.Load())
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+
+	failures, err := st.LoginFailures().Recent(limit)
+	if err != nil {
+		return err
+	}
+	for _, f := range failures {
+		fmt.Printf("%s\t%s\t%s\t%s\n", f.CreatedAt.Format(time.RFC3339), f.IP, f.Reason, f.Email)
+	}
+	return nil
+}
+
+func runVotes(args []string) error {
+	if len(args) != 1 || args[0] != "reconcile" {
+		return fmt.Errorf("usage: forum votes reconcile")
+	}
+
+	st, err := database.Connect(config.Load())
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+
+	drifted, err := votes.NewService(st.Votes()).Reconcile()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Recomputed vote tallies, %d posts/comments were out of sync\n", drifted)
+	return nil
+}
+
+func runSessions(args []string) error {
+	if len(args) != 1 || args[0] != "prune" {
+		return fmt.Errorf("usage: forum sessions prune")
+	}
+
+	st, err := database.Connect(config.Load())
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+
+	pruned, err := st.Sessions().DeleteExpired(time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Removed %d expired sessions\n", pruned)
+	return nil
+}
+
+func runTrash(args []string) error {
+	if len(args) != 1 || args[0] != "purge" {
+		return fmt.Errorf("usage: forum trash purge")
+	}
+
+	cfg := config.Load()
+	st, err := database.Connect(cfg)
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+
+	posts, comments, err := purge.Once(st, cfg.TrashRetention, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Purged %d posts and %d comments deleted more than %s ago\n", posts, comments, cfg.TrashRetention)
+	return nil
+}
+
+func runMarkdown(args []string) error {
+	if len(args) != 1 || args[0] != "render" {
+		return fmt.Errorf("usage: forum markdown render")
+	}
+
+	cfg := config.Load()
+	st, err := database.Connect(cfg)
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+
+	posts, comments, err := markdown.RenderStored(st, true)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Rendered %d posts and %d comments\n", posts, comments)
+	return nil
+}
+
+func runReputation(args []string) error {
+	if len(args) != 1 || args[0] != "recompute" {
+		return fmt.Errorf("usage: forum reputation recompute")
+	}
+
+	cfg := config.Load()
+	st, err := database.Connect(cfg)
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+
+	changed, err := st.Reputation().Recompute(time.Now())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Recomputed reputation; %d users' totals changed\n", changed)
+	return nil
+}
+
+func runMigrate(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: forum migrate up|down|status")
+	}
+
+	cfg := config.Load()
+	db, err := database.Open(cfg.DatabaseDriver, cfg.DatabaseURL)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := database.NewMigrator(db, cfg.DatabaseDriver)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		ran, err := migrator.Up()
+		for _, migration := range ran {
+			fmt.Printf("Applied %04d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(ran) == 0 {
+			fmt.Println("Database is up to date")
+		}
+	case "down":
+		migration, err := migrator.Down()
+		if err != nil {
+			return err
+		}
+		if migration == nil {
+			fmt.Println("No migrations to roll back")
+			return nil
+		}
+		fmt.Printf("Rolled back %04d_%s\n", migration.Version, migration.Name)
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		current, err := migrator.CurrentVersion()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Current version: %d\n", current)
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = "applied " + status.AppliedAt
+			}
+			fmt.Printf("%04d_%s\t%s\n", status.Migration.Version, status.Migration.Name, state)
+		}
+		if err := migrator.CheckSchemaVersion(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown migrate action %q (usage: forum migrate up|down|status)", args[0])
+	}
+	return nil
+}